@@ -36,6 +36,19 @@ func BenchmarkJsonParserLarge(b *testing.B) {
 	}
 }
 
+func BenchmarkJsonParserDeleteLarge(b *testing.B) {
+	fixture := make([]byte, 0, len(largeFixture))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fixture = append(fixture[:0], largeFixture...)
+		fixture = jsonparser.Delete(fixture, "users", "[0]")
+		fixture = jsonparser.Delete(fixture, "topics", "more_topics_url")
+		fixture = jsonparser.Delete(fixture, "topics", "topics", "[29]", "posters")
+
+		nothing()
+	}
+}
+
 /*
    encoding/json
 */
@@ -51,6 +51,19 @@ func BenchmarkJsonParserDeleteMedium(b *testing.B) {
 	}
 }
 
+func BenchmarkJsonParserSetMedium(b *testing.B) {
+	fixture := make([]byte, 0, len(mediumFixture))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fixture = append(fixture[:0], mediumFixture...)
+		fixture, _ = jsonparser.Set(fixture, []byte(`"Jane Doe"`), "person", "name", "fullName")
+		fixture, _ = jsonparser.Set(fixture, []byte(`1000`), "person", "github", "followers")
+		fixture, _ = jsonparser.Set(fixture, []byte(`{"name": "Acme"}`), "company")
+
+		nothing()
+	}
+}
+
 func BenchmarkJsonParserEachKeyManualMedium(b *testing.B) {
 	paths := [][]string{
 		[]string{"person", "name", "fullName"},
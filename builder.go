@@ -0,0 +1,114 @@
+package jsonparser
+
+import (
+	"strconv"
+)
+
+type builderEntry struct {
+	key   string
+	value []byte
+}
+
+// ObjectBuilder assembles a JSON object field by field and emits it with a single allocation in `Build`.
+// Keys are emitted in the order they were first added, unless `Order` is used to move some of them to the front.
+// Setting an existing key again replaces its value but keeps its position.
+// The zero value is an empty builder ready to use.
+type ObjectBuilder struct {
+	entries []builderEntry
+	order   []string
+}
+
+// Set adds `key` with a raw JSON `value`. The value is not validated or copied, so it must stay unchanged until `Build` is called.
+func (b *ObjectBuilder) Set(key string, value []byte) *ObjectBuilder {
+	for i := range b.entries {
+		if b.entries[i].key == key {
+			b.entries[i].value = value
+			return b
+		}
+	}
+	b.entries = append(b.entries, builderEntry{key: key, value: value})
+	return b
+}
+
+// SetString adds `key` with `value` encoded as an escaped JSON string
+func (b *ObjectBuilder) SetString(key string, value string) *ObjectBuilder {
+	return b.Set(key, appendEscaped(nil, StringToBytes(value)))
+}
+
+// SetInt adds `key` with an integer value
+func (b *ObjectBuilder) SetInt(key string, value int64) *ObjectBuilder {
+	return b.Set(key, strconv.AppendInt(nil, value, 10))
+}
+
+// SetFloat adds `key` with a float value, formatted with the shortest representation that round-trips
+func (b *ObjectBuilder) SetFloat(key string, value float64) *ObjectBuilder {
+	return b.Set(key, strconv.AppendFloat(nil, value, 'g', -1, 64))
+}
+
+// SetBoolean adds `key` with a boolean value
+func (b *ObjectBuilder) SetBoolean(key string, value bool) *ObjectBuilder {
+	if value {
+		return b.Set(key, trueLiteral)
+	}
+	return b.Set(key, falseLiteral)
+}
+
+// SetNull adds `key` with a null value
+func (b *ObjectBuilder) SetNull(key string) *ObjectBuilder {
+	return b.Set(key, nullLiteral)
+}
+
+// Order makes `Build` emit the given keys first, in the given order. Keys that were never set are ignored,
+// and keys not listed follow in insertion order.
+func (b *ObjectBuilder) Order(keys ...string) *ObjectBuilder {
+	b.order = keys
+	return b
+}
+
+// Len returns the number of distinct keys in the builder
+func (b *ObjectBuilder) Len() int {
+	return len(b.entries)
+}
+
+// Build returns the assembled JSON object
+func (b *ObjectBuilder) Build() []byte {
+	size := 2 // {}
+	for _, e := range b.entries {
+		// "key":value,
+		size += len(e.key) + len(e.value) + 4
+		for i := 0; i < len(e.key); i++ {
+			if c := e.key[i]; c < 0x20 || c == '"' || c == '\\' {
+				size += 5 // worst case \u00XX
+			}
+		}
+	}
+
+	emitted := make([]bool, len(b.entries))
+	out := make([]byte, 0, size)
+	out = append(out, '{')
+
+	emit := func(i int) {
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(appendEscaped(out, StringToBytes(b.entries[i].key)), ':')
+		out = append(out, b.entries[i].value...)
+		emitted[i] = true
+	}
+
+	for _, k := range b.order {
+		for i := range b.entries {
+			if !emitted[i] && b.entries[i].key == k {
+				emit(i)
+				break
+			}
+		}
+	}
+	for i := range b.entries {
+		if !emitted[i] {
+			emit(i)
+		}
+	}
+
+	return append(out, '}')
+}
@@ -0,0 +1,41 @@
+package jsonparser
+
+import (
+	"testing"
+)
+
+func TestObjectBuilderInsertionOrder(t *testing.T) {
+	var b ObjectBuilder
+	b.SetString("name", "a \"quoted\" name").
+		SetInt("count", -3).
+		SetFloat("ratio", 0.5).
+		SetBoolean("ok", true).
+		SetNull("missing").
+		Set("nested", []byte(`{"a":[1,2]}`)).
+		SetInt("count", 4) // replaces the value, keeps the position
+
+	expected := `{"name":"a \"quoted\" name","count":4,"ratio":0.5,"ok":true,"missing":null,"nested":{"a":[1,2]}}`
+	if out := string(b.Build()); out != expected {
+		t.Errorf("ObjectBuilder expected %s, obtained %s", expected, out)
+	}
+	if b.Len() != 6 {
+		t.Errorf("ObjectBuilder expected 6 keys, obtained %d", b.Len())
+	}
+}
+
+func TestObjectBuilderOrder(t *testing.T) {
+	var b ObjectBuilder
+	b.SetString("data", "x").SetInt("status", 500).SetString("error", "boom").Order("error", "unknown", "status")
+
+	expected := `{"error":"boom","status":500,"data":"x"}`
+	if out := string(b.Build()); out != expected {
+		t.Errorf("ObjectBuilder expected %s, obtained %s", expected, out)
+	}
+}
+
+func TestObjectBuilderEmpty(t *testing.T) {
+	var b ObjectBuilder
+	if out := string(b.Build()); out != `{}` {
+		t.Errorf("ObjectBuilder expected {}, obtained %s", out)
+	}
+}
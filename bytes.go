@@ -1,5 +1,9 @@
 package jsonparser
 
+import (
+	"encoding/binary"
+)
+
 const absMinInt64 = 1 << 63
 const maxInt64 = 1<<63 - 1
 const maxUint64 = 1<<64 - 1
@@ -17,6 +21,25 @@ func parseInt(bytes []byte) (v int64, ok bool, overflow bool) {
 	}
 
 	var n uint64 = 0
+
+	// Long tokens, such as numeric IDs, are consumed 8 digits at a time while possible
+	for len(bytes) >= 8 {
+		v, ok := parseEightDigits(binary.LittleEndian.Uint64(bytes))
+		if !ok {
+			break // let the loop below find the offending byte
+		}
+		if n > maxUint64/100000000 {
+			return 0, false, true
+		}
+		n *= 100000000
+		n1 := n + v
+		if n1 < n {
+			return 0, false, true
+		}
+		n = n1
+		bytes = bytes[8:]
+	}
+
 	for _, c := range bytes {
 		if c < '0' || c > '9' {
 			return 0, false, false
@@ -45,3 +68,74 @@ func parseInt(bytes []byte) (v int64, ok bool, overflow bool) {
 		return int64(n), true, false
 	}
 }
+
+// parseEightDigits converts 8 ASCII digits, loaded as a little-endian integer so that the first digit is the lowest byte,
+// to their value. All bytes are checked and converted at once (SWAR); ok is false if any of them isn't a digit.
+func parseEightDigits(chunk uint64) (v uint64, ok bool) {
+	// A byte is a digit iff its high nibble is 3 and adding 6 to it doesn't carry into the high nibble
+	if chunk&0xF0F0F0F0F0F0F0F0|(chunk+0x0606060606060606)&0xF0F0F0F0F0F0F0F0>>4 != 0x3333333333333333 {
+		return 0, false
+	}
+
+	// Combine adjacent digits into 2-digit, then 4-digit, then 8-digit values
+	chunk = (chunk & 0x0F0F0F0F0F0F0F0F) * (10<<8 + 1) >> 8
+	chunk = (chunk & 0x00FF00FF00FF00FF) * (100<<16 + 1) >> 16
+	return (chunk & 0x0000FFFF0000FFFF) * (10000<<32 + 1) >> 32, true
+}
+
+// isNumber reports whether the bytes form a number as defined by the JSON grammar (RFC 7159, section 6):
+// an optional minus sign, an integer part without leading zeros, an optional fraction and an optional exponent.
+func isNumber(b []byte) bool {
+	i, ln := 0, len(b)
+	if i < ln && b[i] == '-' {
+		i++
+	}
+
+	// Integer part
+	if i == ln {
+		return false
+	}
+	if b[i] == '0' {
+		i++
+	} else if b[i] >= '1' && b[i] <= '9' {
+		for i < ln && b[i] >= '0' && b[i] <= '9' {
+			i++
+		}
+	} else {
+		return false
+	}
+
+	// Fraction
+	if i < ln && b[i] == '.' {
+		i++
+		start := i
+		for i < ln && b[i] >= '0' && b[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+
+	// Exponent
+	if i < ln && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < ln && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		start := i
+		for i < ln && b[i] >= '0' && b[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+
+	return i == ln
+}
+
+// isSpace reports whether c is insignificant whitespace between JSON tokens
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t'
+}
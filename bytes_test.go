@@ -114,6 +114,77 @@ func TestBytesParseInt(t *testing.T) {
 	}
 }
 
+// parseIntBytewise is the original digit-by-digit implementation of parseInt, kept as a reference for equivalence tests
+// and benchmarks
+func parseIntBytewise(bytes []byte) (v int64, ok bool, overflow bool) {
+	if len(bytes) == 0 {
+		return 0, false, false
+	}
+
+	var neg bool = false
+	if bytes[0] == '-' {
+		neg = true
+		bytes = bytes[1:]
+	}
+
+	var n uint64 = 0
+	for _, c := range bytes {
+		if c < '0' || c > '9' {
+			return 0, false, false
+		}
+		if n > maxUint64/10 {
+			return 0, false, true
+		}
+		n *= 10
+		n1 := n + uint64(c-'0')
+		if n1 < n {
+			return 0, false, true
+		}
+		n = n1
+	}
+
+	if n > maxInt64 {
+		if neg && n == absMinInt64 {
+			return -absMinInt64, true, false
+		}
+		return 0, false, true
+	}
+
+	if neg {
+		return -int64(n), true, false
+	} else {
+		return int64(n), true, false
+	}
+}
+
+func TestParseIntChunked(t *testing.T) {
+	inputs := []string{
+		"12345678", "00000000", "99999999", "123456789", "1234567812345678", "-1234567812345678",
+		"1234567x", "12345678x", "x2345678", "1234:678", "1234/678", "123456781234567/",
+		"18446744073709551615", "18446744073709551615x", "184467440737095516150", "99999999999999999999999999x",
+		"00000000000000000000000000000001", "-00000000000000009223372036854775808",
+	}
+	for _, test := range parseIntTests {
+		inputs = append(inputs, test.in)
+	}
+	// Every byte value at every position of a 16 digit token, to exercise the digit check
+	for pos := 0; pos < 16; pos++ {
+		for c := 0; c < 256; c++ {
+			b := []byte("1234567890123456")
+			b[pos] = byte(c)
+			inputs = append(inputs, string(b))
+		}
+	}
+
+	for _, in := range inputs {
+		v, ok, overflow := parseInt([]byte(in))
+		ev, eok, eoverflow := parseIntBytewise([]byte(in))
+		if v != ev || ok != eok || overflow != eoverflow {
+			t.Errorf("parseInt(%q) expected (%d, %t, %t), obtained (%d, %t, %t)", in, ev, eok, eoverflow, v, ok, overflow)
+		}
+	}
+}
+
 func BenchmarkParseInt(b *testing.B) {
 	bytes := []byte("123")
 	for i := 0; i < b.N; i++ {
@@ -121,6 +192,20 @@ func BenchmarkParseInt(b *testing.B) {
 	}
 }
 
+var longIntBytes = []byte("1234567890123456789")
+
+func BenchmarkParseIntLong(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		parseInt(longIntBytes)
+	}
+}
+
+func BenchmarkParseIntLongBytewise(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		parseIntBytewise(longIntBytes)
+	}
+}
+
 // Alternative implementation using unsafe and delegating to strconv.ParseInt
 func BenchmarkParseIntUnsafeSlower(b *testing.B) {
 	bytes := []byte("123")
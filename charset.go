@@ -0,0 +1,68 @@
+package jsonparser
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Errors
+var (
+	MalformedUTF16Error = errors.New("Data is not valid UTF-16: odd length or unpaired surrogate")
+)
+
+// DetectEncoding reports the encoding of a JSON document: "UTF-8", "UTF-16LE" or "UTF-16BE", and the length of its byte
+// order mark, 0 if there is none. Without a BOM, UTF-16 is recognised by the zero bytes of the first character, which
+// is always ASCII in JSON (RFC 4627, section 3); anything else is taken for UTF-8.
+func DetectEncoding(data []byte) (encoding string, bomLen int) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF:
+		return "UTF-8", 3
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return "UTF-16LE", 2
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return "UTF-16BE", 2
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		return "UTF-16BE", 0
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		return "UTF-16LE", 0
+	}
+	return "UTF-8", 0
+}
+
+// DecodeToUTF8 normalizes a JSON document to UTF-8 without BOM, which is what the parser reads, based on
+// `DetectEncoding`. A UTF-8 document is returned as a subslice of `data`; a UTF-16 one is converted into a new slice.
+func DecodeToUTF8(data []byte) ([]byte, error) {
+	encoding, bomLen := DetectEncoding(data)
+	data = data[bomLen:]
+	if encoding == "UTF-8" {
+		return data, nil
+	}
+	if len(data)%2 != 0 {
+		return nil, MalformedUTF16Error
+	}
+
+	unit := func(i int) rune {
+		if encoding == "UTF-16LE" {
+			return rune(data[i]) | rune(data[i+1])<<8
+		}
+		return rune(data[i])<<8 | rune(data[i+1])
+	}
+
+	out := make([]byte, 0, len(data)/2)
+	var buf [utf8.UTFMax]byte
+	for i := 0; i < len(data); i += 2 {
+		r := unit(i)
+		if utf16.IsSurrogate(r) {
+			if i += 2; i == len(data) {
+				return nil, MalformedUTF16Error
+			}
+			if r = utf16.DecodeRune(r, unit(i)); r == utf8.RuneError {
+				return nil, MalformedUTF16Error
+			}
+		}
+		n := utf8.EncodeRune(buf[:], r)
+		out = append(out, buf[:n]...)
+	}
+	return out, nil
+}
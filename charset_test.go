@@ -0,0 +1,85 @@
+package jsonparser
+
+import (
+	"testing"
+)
+
+// utf16Bytes encodes ASCII text, plus the rune U+1F600 for '*', as UTF-16 in the given byte order
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, r := range s {
+		units := []uint16{uint16(r)}
+		if r == '*' {
+			units = []uint16{0xD83D, 0xDE00}
+		}
+		for _, u := range units {
+			if bigEndian {
+				out = append(out, byte(u>>8), byte(u))
+			} else {
+				out = append(out, byte(u), byte(u>>8))
+			}
+		}
+	}
+	return out
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		encoding string
+		bomLen   int
+	}{
+		{data: []byte("\xEF\xBB\xBF{}"), encoding: "UTF-8", bomLen: 3},
+		{data: []byte(`{"a": 1}`), encoding: "UTF-8", bomLen: 0},
+		{data: append([]byte{0xFF, 0xFE}, utf16Bytes(`{}`, false)...), encoding: "UTF-16LE", bomLen: 2},
+		{data: append([]byte{0xFE, 0xFF}, utf16Bytes(`{}`, true)...), encoding: "UTF-16BE", bomLen: 2},
+		{data: utf16Bytes(`{}`, false), encoding: "UTF-16LE", bomLen: 0},
+		{data: utf16Bytes(`{}`, true), encoding: "UTF-16BE", bomLen: 0},
+		{data: []byte("1"), encoding: "UTF-8", bomLen: 0},
+		{data: nil, encoding: "UTF-8", bomLen: 0},
+	}
+
+	for _, test := range tests {
+		if encoding, bomLen := DetectEncoding(test.data); encoding != test.encoding || bomLen != test.bomLen {
+			t.Errorf("DetectEncoding(% x) expected %s, %d, obtained %s, %d", test.data, test.encoding, test.bomLen, encoding, bomLen)
+		}
+	}
+}
+
+func TestDecodeToUTF8(t *testing.T) {
+	const doc = `{"a": "é*"}`
+	expected := `{"a": "é😀"}`
+
+	for _, bigEndian := range []bool{false, true} {
+		bom := []byte{0xFF, 0xFE}
+		if bigEndian {
+			bom = []byte{0xFE, 0xFF}
+		}
+		for _, data := range [][]byte{append(bom, utf16Bytes(doc, bigEndian)...), utf16Bytes(doc, bigEndian)} {
+			out, err := DecodeToUTF8(data)
+			if err != nil || string(out) != expected {
+				t.Errorf("DecodeToUTF8(% x) expected %s, obtained %s, %v", data, expected, out, err)
+			}
+			if v, err := GetString(out, "a"); err != nil || v != "é😀" {
+				t.Errorf("GetString on the decoded document returned unexpected %q, %v", v, err)
+			}
+		}
+	}
+
+	// UTF-8 loses its BOM, without copying
+	data := []byte("\xEF\xBB\xBF{}")
+	if out, err := DecodeToUTF8(data); err != nil || string(out) != "{}" || &out[0] != &data[3] {
+		t.Errorf("DecodeToUTF8 of UTF-8 returned unexpected %q, %v", out, err)
+	}
+
+	for _, data := range [][]byte{
+		{0xFF, 0xFE, '{', 0, '}'},            // odd length
+		{0xFF, 0xFE, 0x3D, 0xD8},             // truncated surrogate pair
+		{0xFF, 0xFE, 0x3D, 0xD8, 'a', 0},     // high surrogate without low one
+		{0xFE, 0xFF, 0xDE, 0x00, 0x00, 0x7B}, // lone low surrogate
+	} {
+		if out, err := DecodeToUTF8(data); err != MalformedUTF16Error {
+			t.Errorf("DecodeToUTF8(% x) should fail with MalformedUTF16Error, obtained %q, %v", data, out, err)
+		}
+	}
+}
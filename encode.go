@@ -0,0 +1,345 @@
+package jsonparser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// structField describes how a single struct field maps onto a JSON object key
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	tagged    bool // name comes from a json tag
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the JSON-visible fields of a struct type, honouring `json:"name,omitempty"` and `json:"-"` tags.
+// Untagged embedded structs have their fields promoted into the parent object. When several fields end up with the same
+// name, the same rules as encoding/json apply: the least nested one wins, then a tagged one, and if that still leaves
+// more than one candidate, all of them are omitted.
+func structFields(t reflect.Type) []structField {
+	if f, ok := structFieldsCache.Load(t); ok {
+		return f.([]structField)
+	}
+
+	fields := collectFields(t, nil, map[reflect.Type]bool{})
+
+	// Group by name, keeping the most dominant candidates of each group first
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if j == i+1 || len(fields[i+1].index) > len(fields[i].index) || fields[i].tagged && !fields[i+1].tagged {
+			dominant = append(dominant, fields[i])
+		}
+		i = j
+	}
+
+	// Restore declaration order
+	sort.Slice(dominant, func(i, j int) bool {
+		a, b := dominant[i].index, dominant[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	structFieldsCache.Store(t, dominant)
+	return dominant
+}
+
+// collectFields lists every candidate field of `t`, including those promoted from embedded structs, prefixing their
+// index with `index`. `visiting` guards against types which embed themselves through a pointer.
+func collectFields(t reflect.Type, index []int, visiting map[reflect.Type]bool) []structField {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, collectFields(ft, fieldIndex, visiting)...)
+				continue
+			}
+		}
+
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+
+		f := structField{name: name, index: fieldIndex, tagged: name != ""}
+		if name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range opts[1:] {
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false instead of panicking on a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+/*
+
+Marshal - Receives a Go value and encodes it as JSON using reflection.
+
+Supported kinds are structs (honouring `json` struct tags), maps with string keys, slices, arrays, pointers, interfaces,
+strings, booleans, integers and floats. Map keys are emitted in sorted order so output is deterministic.
+Like encoding/json, a []byte is encoded as a base64 string.
+
+Returns:
+`data` - JSON encoding of `v`
+`err` - If `v` contains a value which can't be represented in JSON (channels, functions, NaN, cyclic data structures, etc)
+
+*/
+func Marshal(v interface{}) (data []byte, err error) {
+	return appendValue(nil, reflect.ValueOf(v), 0, nil)
+}
+
+// SetAuto is like `Set`, but takes a Go value and encodes it with `Marshal` first, so that e.g. a plain string is quoted and
+// escaped rather than spliced into the document as is. Supported values are nil, strings, booleans, integers and floats
+// (including named types based on them), and json.RawMessage, which is written verbatim after checking that it holds a
+// single valid JSON value.
+func SetAuto(data []byte, value interface{}, keys ...string) ([]byte, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		var v ValidateIncremental
+		v.Write(raw)
+		if err := v.Done(); err != nil {
+			return nil, err
+		}
+		return Set(data, raw, keys...)
+	}
+
+	if value != nil {
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+		default:
+			return nil, fmt.Errorf("Unsupported type %T for SetAuto", value)
+		}
+	}
+	setValue, err := Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return Set(data, setValue, keys...)
+}
+
+// visitKey identifies a pointer, map or slice currently being encoded, to detect cycles
+type visitKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// appendValue appends the JSON encoding of `v` to `dst`, inside `depth` objects and arrays. `visiting` holds the references on
+// the path to `v`; it is allocated on first use.
+func appendValue(dst []byte, v reflect.Value, depth int, visiting map[visitKey]bool) ([]byte, error) {
+	if !v.IsValid() {
+		return append(dst, nullLiteral...), nil
+	}
+
+	var key visitKey
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if !v.IsNil() {
+			key = visitKey{ptr: v.Pointer(), typ: v.Type()}
+		}
+	case reflect.Slice:
+		if v.Len() > 0 {
+			key = visitKey{ptr: v.Pointer(), len: v.Len(), typ: v.Type()}
+		}
+	}
+	if key.typ != nil {
+		if visiting[key] {
+			return nil, fmt.Errorf("Unsupported value: cycle via %s", v.Type())
+		}
+		if visiting == nil {
+			visiting = map[visitKey]bool{}
+		}
+		visiting[key] = true
+		defer delete(visiting, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return append(dst, nullLiteral...), nil
+		}
+		return appendValue(dst, v.Elem(), depth, visiting)
+	case reflect.Bool:
+		return strconv.AppendBool(dst, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(dst, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(dst, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("Unsupported float value: %v", f)
+		}
+		bitSize := 64
+		if v.Kind() == reflect.Float32 {
+			bitSize = 32
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, bitSize), nil
+	case reflect.String:
+		return appendEscaped(dst, StringToBytes(v.String())), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return append(dst, nullLiteral...), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := v.Bytes()
+			dst = append(dst, '"')
+			n := len(dst)
+			dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(b)))...)
+			base64.StdEncoding.Encode(dst[n:], b)
+			return append(dst, '"'), nil
+		}
+		fallthrough
+	case reflect.Array:
+		if depth >= MaxNestingDepth {
+			return nil, MaxDepthExceededError
+		}
+		var err error
+		dst = append(dst, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = appendValue(dst, v.Index(i), depth+1, visiting); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return append(dst, nullLiteral...), nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("Unsupported map key type: %s", v.Type().Key())
+		}
+		if depth >= MaxNestingDepth {
+			return nil, MaxDepthExceededError
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		var err error
+		dst = append(dst, '{')
+		for i, k := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = append(appendEscaped(dst, StringToBytes(k.String())), ':')
+			if dst, err = appendValue(dst, v.MapIndex(k), depth+1, visiting); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, '}'), nil
+	case reflect.Struct:
+		if depth >= MaxNestingDepth {
+			return nil, MaxDepthExceededError
+		}
+		var err error
+		first := true
+		dst = append(dst, '{')
+		for _, f := range structFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			if !first {
+				dst = append(dst, ',')
+			}
+			first = false
+			dst = append(appendEscaped(dst, StringToBytes(f.name)), ':')
+			if dst, err = appendValue(dst, fv, depth+1, visiting); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, '}'), nil
+	}
+
+	return nil, fmt.Errorf("Unsupported type: %s", v.Type())
+}
@@ -0,0 +1,232 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+type marshalInner struct {
+	B int `json:"b"`
+}
+
+type MarshalEmbedded struct {
+	E string `json:"e"`
+}
+
+type marshalTest struct {
+	MarshalEmbedded
+	Name     string            `json:"name"`
+	Skip     string            `json:"-"`
+	Empty    string            `json:"empty,omitempty"`
+	Count    int64             `json:"count"`
+	Ratio    float64           `json:"ratio"`
+	Ok       bool              `json:"ok"`
+	Inner    *marshalInner     `json:"inner"`
+	Nil      *marshalInner     `json:"nil"`
+	List     []int             `json:"list"`
+	Tags     map[string]string `json:"tags"`
+	Untagged uint8
+	private  int
+}
+
+type marshalDeep struct {
+	Name   string `json:"name"`
+	Hidden string
+}
+
+type marshalConflictA struct {
+	Dup string
+}
+
+type marshalConflictB struct {
+	Dup string
+}
+
+type marshalTaggedA struct {
+	Dup string `json:"dup"`
+}
+
+type marshalTaggedB struct {
+	Other string `json:"dup"`
+}
+
+type marshalDominance struct {
+	marshalDeep
+	marshalConflictA
+	marshalConflictB
+	marshalTaggedA
+	marshalTaggedB `json:"-"`
+	Name           string `json:"name"`
+	Opt            string `json:",omitempty"`
+}
+
+type marshalTaggedDominance struct {
+	marshalTaggedA
+	marshalConflictA
+}
+
+type marshalNode struct {
+	Next *marshalNode `json:"next"`
+}
+
+var marshalTests = []struct {
+	desc  string
+	in    interface{}
+	out   string
+	isErr bool
+}{
+	{desc: "nil", in: nil, out: `null`},
+	{desc: "string", in: "a\"b\\c\n\u0001", out: `"a\"b\\c\n\u0001"`},
+	{desc: "int", in: -12, out: `-12`},
+	{desc: "uint", in: uint64(18446744073709551615), out: `18446744073709551615`},
+	{desc: "float", in: 1.5, out: `1.5`},
+	{desc: "bool", in: true, out: `true`},
+	{desc: "nil slice", in: []string(nil), out: `null`},
+	{desc: "empty slice", in: []string{}, out: `[]`},
+	{desc: "array", in: [2]int{1, 2}, out: `[1,2]`},
+	{desc: "sorted map", in: map[string]int{"b": 2, "a": 1}, out: `{"a":1,"b":2}`},
+	{desc: "interface slice", in: []interface{}{1, "x", nil}, out: `[1,"x",null]`},
+	{
+		desc: "struct",
+		in: marshalTest{
+			MarshalEmbedded: MarshalEmbedded{E: "embedded"},
+			Name:            "test",
+			Skip:            "skipped",
+			Count:           3,
+			Ratio:           0.25,
+			Ok:              true,
+			Inner:           &marshalInner{B: 7},
+			List:            []int{1, 2},
+			Tags:            map[string]string{"k": "v"},
+			Untagged:        9,
+			private:         1,
+		},
+		out: `{"e":"embedded","name":"test","count":3,"ratio":0.25,"ok":true,"inner":{"b":7},"nil":null,"list":[1,2],"tags":{"k":"v"},"Untagged":9}`,
+	},
+	{
+		desc: "embedded field dominance",
+		in: marshalDominance{
+			marshalDeep:      marshalDeep{Name: "deep", Hidden: "promoted"},
+			marshalConflictA: marshalConflictA{Dup: "a"},
+			marshalConflictB: marshalConflictB{Dup: "b"},
+			marshalTaggedA:   marshalTaggedA{Dup: "tagged"},
+			Name:             "shallow",
+		},
+		out: `{"Hidden":"promoted","dup":"tagged","name":"shallow"}`,
+	},
+	{
+		desc: "tagged field wins at equal depth",
+		in:   marshalTaggedDominance{marshalTaggedA{Dup: "tagged"}, marshalConflictA{Dup: "untagged"}},
+		out:  `{"dup":"tagged","Dup":"untagged"}`,
+	},
+	{desc: "bytes", in: []byte("hello"), out: `"aGVsbG8="`},
+	{desc: "empty bytes", in: []byte{}, out: `""`},
+	{desc: "byte array", in: [2]byte{1, 2}, out: `[1,2]`},
+	{desc: "shared pointer", in: []*marshalInner{{B: 1}, {B: 1}}, out: `[{"b":1},{"b":1}]`},
+	{desc: "NaN", in: math.NaN(), isErr: true},
+	{desc: "channel", in: make(chan int), isErr: true},
+	{desc: "non-string map key", in: map[int]int{1: 1}, isErr: true},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, test := range marshalTests {
+		out, err := Marshal(test.in)
+		if isErr := (err != nil); isErr != test.isErr {
+			t.Errorf("Marshal test '%s' isErr mismatch: expected %t, obtained %t (err %v)", test.desc, test.isErr, isErr, err)
+		} else if !isErr && string(out) != test.out {
+			t.Errorf("Marshal test '%s' expected %s, obtained %s", test.desc, test.out, string(out))
+		}
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	n := &marshalNode{}
+	n.Next = &marshalNode{Next: n}
+	if _, err := Marshal(n); err == nil {
+		t.Error("Marshal should fail on a cyclic pointer")
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := Marshal(m); err == nil {
+		t.Error("Marshal should fail on a map containing itself")
+	}
+
+	// The same value may appear several times as long as it doesn't contain itself
+	shared := &marshalNode{}
+	if out, err := Marshal([]*marshalNode{shared, shared}); err != nil || string(out) != `[{"next":null},{"next":null}]` {
+		t.Errorf("Marshal of a shared pointer returned unexpected %s, %v", out, err)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	in := marshalTest{
+		Name:  "quote\" and unicode °",
+		Count: -42,
+		Ratio: 1e-7,
+		Inner: &marshalInner{B: 5},
+		List:  []int{3, 4, 5},
+	}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := GetString(data, "name"); err != nil || v != in.Name {
+		t.Errorf("name round trip: expected %q, obtained %q (err %v)", in.Name, v, err)
+	}
+	if v, err := GetInt(data, "count"); err != nil || v != in.Count {
+		t.Errorf("count round trip: expected %d, obtained %d (err %v)", in.Count, v, err)
+	}
+	if v, err := GetFloat(data, "ratio"); err != nil || v != in.Ratio {
+		t.Errorf("ratio round trip: expected %v, obtained %v (err %v)", in.Ratio, v, err)
+	}
+	if v, err := GetInt(data, "inner", "b"); err != nil || v != 5 {
+		t.Errorf("inner round trip: expected 5, obtained %d (err %v)", v, err)
+	}
+	if v, err := GetInt(data, "list", "[2]"); err != nil || v != 5 {
+		t.Errorf("list round trip: expected 5, obtained %d (err %v)", v, err)
+	}
+	if _, dt, _, _ := Get(data, "nil"); dt != Null {
+		t.Errorf("nil pointer should encode as null, obtained %s", dt)
+	}
+}
+
+type setAutoColor string
+
+func TestSetAuto(t *testing.T) {
+	tests := []struct {
+		desc  string
+		value interface{}
+		out   string
+		isErr bool
+	}{
+		{desc: "string", value: `bare "word"`, out: `{"a":"bare \"word\"","b":1}`},
+		{desc: "named string", value: setAutoColor("red"), out: `{"a":"red","b":1}`},
+		{desc: "int", value: -42, out: `{"a":-42,"b":1}`},
+		{desc: "uint8", value: uint8(7), out: `{"a":7,"b":1}`},
+		{desc: "float", value: 1.5, out: `{"a":1.5,"b":1}`},
+		{desc: "bool", value: true, out: `{"a":true,"b":1}`},
+		{desc: "nil", value: nil, out: `{"a":null,"b":1}`},
+		{desc: "raw message", value: json.RawMessage(`{"c": [1, 2]}`), out: `{"a":{"c": [1, 2]},"b":1}`},
+		{desc: "invalid raw message", value: json.RawMessage(`{"c": }`), isErr: true},
+		{desc: "NaN", value: math.NaN(), isErr: true},
+		{desc: "unsupported type", value: []string{"x"}, isErr: true},
+	}
+
+	for _, test := range tests {
+		out, err := SetAuto([]byte(`{"a":"old","b":1}`), test.value, "a")
+		if isErr := (err != nil); isErr != test.isErr {
+			t.Errorf("SetAuto test '%s' isErr mismatch: expected %t, obtained %t (err %v)", test.desc, test.isErr, isErr, err)
+		} else if !isErr && string(out) != test.out {
+			t.Errorf("SetAuto test '%s' expected %s, obtained %s", test.desc, test.out, string(out))
+		}
+	}
+
+	// A new key is created like with Set
+	if out, err := SetAuto([]byte(`{}`), "x", "n", "m"); err != nil || string(out) != `{"n":{"m":"x"}}` {
+		t.Errorf("SetAuto of a new path returned unexpected %s, %v", out, err)
+	}
+}
@@ -171,3 +171,37 @@ func Unescape(in, out []byte) ([]byte, error) {
 	// Trim the out buffer to the amount that was actually emitted
 	return out[:len(out)-len(buf)], nil
 }
+
+const hexDigits = "0123456789abcdef"
+
+// charBackslashEscapeTable is the inverse of backslashCharEscapeTable for the bytes `appendEscaped` has to escape: a
+// non-zero entry for byte X means X is written as '\' followed by charBackslashEscapeTable[X]. '/' needs no escaping.
+var charBackslashEscapeTable = func() (table ['\\' + 1]byte) {
+	for e, c := range backslashCharEscapeTable {
+		if c != 0 && c != '/' {
+			table[c] = byte(e)
+		}
+	}
+	return table
+}()
+
+// appendEscaped appends 's' to 'dst' as a quoted JSON string, escaping quotes, backslashes and control characters.
+func appendEscaped(dst []byte, s []byte) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i, c := range s {
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+
+		dst = append(dst, s[start:i]...)
+		if e := charBackslashEscapeTable[c]; e != 0 {
+			dst = append(dst, '\\', e)
+		} else {
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+		}
+		start = i + 1
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -190,3 +190,31 @@ func TestUnescape(t *testing.T) {
 		}
 	}
 }
+
+func TestAppendEscaped(t *testing.T) {
+	escapeTests := []struct {
+		in  string
+		out string
+	}{
+		{in: ``, out: `""`},
+		{in: `a/b`, out: `"a/b"`},
+		{in: "q\"b\\\b\f\n\r\t", out: `"q\"b\\\b\f\n\r\t"`},
+		{in: "\x00\x1f", out: `"\u0000\u001f"`},
+		{in: "ü\x7f", out: "\"ü\x7f\""},
+	}
+	for _, test := range escapeTests {
+		if out := appendEscaped(nil, []byte(test.in)); string(out) != test.out {
+			t.Errorf("appendEscaped(%q) expected %s, obtained %s", test.in, test.out, out)
+		}
+	}
+
+	// Every byte must survive a round trip through Unescape
+	in := make([]byte, 0x80)
+	for i := range in {
+		in[i] = byte(i)
+	}
+	escaped := appendEscaped(nil, in)
+	if out, err := Unescape(escaped[1:len(escaped)-1], nil); err != nil || !bytes.Equal(out, in) {
+		t.Errorf("Unescape(appendEscaped(%q)) expected the input back, obtained %q, %v", in, out, err)
+	}
+}
@@ -0,0 +1,40 @@
+package jsonparser
+
+/*
+
+AppendLine - Appends `value` to `dst` as one line of NDJSON (newline-delimited JSON): compacted, i.e. with all
+whitespace outside of strings removed, and followed by '\n'. This makes it easy to build NDJSON output incrementally
+from values extracted with `Get`, e.g. `dst = AppendLine(dst, value)`.
+
+`value` must be exactly one valid JSON value, as checked by `ValidateIncremental`; it can't be a string returned by
+`Get`, which strips the quotes. If it isn't valid, `dst` is returned unchanged, so callers who need to know can compare
+lengths.
+
+*/
+func AppendLine(dst []byte, value []byte) []byte {
+	var v ValidateIncremental
+	if _, err := v.Write(value); err != nil || v.Done() != nil {
+		return dst
+	}
+
+	inString := false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				dst = append(dst, c)
+				i++
+				c = value[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case isSpace(c):
+			continue
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, '\n')
+}
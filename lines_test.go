@@ -0,0 +1,61 @@
+package jsonparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppendLine(t *testing.T) {
+	tests := []struct {
+		value string
+		line  string // "" when the value is rejected
+	}{
+		{value: `{ "a" : [1, 2 ],` + "\n\t" + `"b": "x y" }`, line: `{"a":[1,2],"b":"x y"}`},
+		{value: `  "a \" \\ b"  `, line: `"a \" \\ b"`},
+		{value: ` -1.5e3 `, line: `-1.5e3`},
+		{value: `null`, line: `null`},
+		{value: `{"a": 1} {"b": 2}`},
+		{value: `{"a": }`},
+		{value: `abc`},
+		{value: ``},
+	}
+
+	for _, test := range tests {
+		dst := []byte("prefix\n")
+		out := AppendLine(dst, []byte(test.value))
+		expected := "prefix\n"
+		if test.line != "" {
+			expected += test.line + "\n"
+		}
+		if string(out) != expected {
+			t.Errorf("AppendLine(%s) expected %q, obtained %q", test.value, expected, out)
+		}
+	}
+}
+
+func TestAppendLineRoundTrip(t *testing.T) {
+	doc := []byte(`{"users": [{"name": "Ann", "tags": ["a", "b"]}, {"name": "Bob\nby", "tags": []}], "total": 2}`)
+
+	var buf []byte
+	_, err := ArrayEach(doc, func(value []byte, dataType ValueType, offset int, err error) {
+		buf = AppendLine(buf, value)
+	}, "users")
+	if err != nil {
+		t.Fatalf("ArrayEach failed: %v", err)
+	}
+	total, _, _, _ := Get(doc, "total")
+	buf = AppendLine(buf, total)
+
+	lines := bytes.Split(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("AppendLine expected 3 lines, obtained %d: %q", len(lines), buf)
+	}
+	for i, name := range []string{"Ann", `Bob\nby`} {
+		if v, _, _, err := Get(lines[i], "name"); err != nil || string(v) != name {
+			t.Errorf("Line %d expected name %s, obtained %s (error %v)", i, name, v, err)
+		}
+	}
+	if string(lines[2]) != "2" {
+		t.Errorf("Line 2 expected 2, obtained %s", lines[2])
+	}
+}
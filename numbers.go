@@ -0,0 +1,93 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// NumberParser converts Number values, as returned by `Get`, into the Go type a consumer works with. Setting one as
+// `Parser.Numbers` configures the numeric strategy of a Parser in one place.
+type NumberParser interface {
+	ParseNumber(b []byte) (interface{}, error)
+}
+
+// Float64Parser parses numbers into a float64 with `ParseFloat`
+type Float64Parser struct{}
+
+// ParseNumber implements NumberParser
+func (Float64Parser) ParseNumber(b []byte) (interface{}, error) {
+	v, err := ParseFloat(b)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// JSONNumberParser keeps numbers as a json.Number, which holds a copy of the token, so they can be converted later without
+// losing precision
+type JSONNumberParser struct{}
+
+// ParseNumber implements NumberParser
+func (JSONNumberParser) ParseNumber(b []byte) (interface{}, error) {
+	if !isNumber(b) {
+		return nil, MalformedValueError
+	}
+	return json.Number(b), nil
+}
+
+// BigFloatParser parses numbers into a *big.Float with `ParseBigFloat`
+type BigFloatParser struct{}
+
+// ParseNumber implements NumberParser
+func (BigFloatParser) ParseNumber(b []byte) (interface{}, error) {
+	v, err := ParseBigFloat(b)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// numberFloat converts a value returned by one of the built-in NumberParsers into a float64
+func numberFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case json.Number:
+		if f, err := n.Float64(); err == nil {
+			return f, nil
+		}
+		return 0, MalformedValueError
+	case *big.Float:
+		f, _ := n.Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("Number parser returned %T, which can't be converted to float64", v)
+}
+
+// numberInt converts a value returned by one of the built-in NumberParsers into an int64, failing if it isn't an integer
+func numberInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, MalformedValueError
+		}
+		// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+		if n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, OverflowIntegerError
+		}
+		return int64(n), nil
+	case json.Number:
+		return ParseInt([]byte(n))
+	case *big.Float:
+		if !n.IsInt() {
+			return 0, MalformedValueError
+		}
+		if i, acc := n.Int64(); acc == big.Exact {
+			return i, nil
+		}
+		return 0, OverflowIntegerError
+	}
+	return 0, fmt.Errorf("Number parser returned %T, which can't be converted to int64", v)
+}
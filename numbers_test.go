@@ -0,0 +1,82 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+var numbersData = []byte(`{"price": 0.1, "qty": [3, 12345678901234567890.5], "name": "x", "ok": true, "none": null}`)
+
+func TestParserGetAny(t *testing.T) {
+	var p Parser
+	v, err := p.GetAny(numbersData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"price": 0.1,
+		"qty":   []interface{}{3.0, 12345678901234567890.5},
+		"name":  "x",
+		"ok":    true,
+		"none":  nil,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Parser.GetAny expected %#v, obtained %#v", expected, v)
+	}
+
+	if v, err := p.GetAny(numbersData, "qty", "[0]"); err != nil || v != 3.0 {
+		t.Errorf("Parser.GetAny of a number returned unexpected %#v, %v", v, err)
+	}
+	if _, err := p.GetAny([]byte(`{"a": [1, }`), "a"); err == nil {
+		t.Error("Parser.GetAny should reject a malformed array")
+	}
+	if _, err := p.GetAny([]byte(`{"a": 1}`), "b"); err != KeyPathNotFoundError {
+		t.Errorf("Parser.GetAny of a missing key should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+
+	// Extensions apply to nested values too
+	lenient := Parser{AllowSingleQuotes: true, AllowJSON5Numbers: true}
+	if v, err := lenient.GetAny([]byte(`{'a': ['b', .5]}`)); err != nil ||
+		!reflect.DeepEqual(v, map[string]interface{}{"a": []interface{}{"b", 0.5}}) {
+		t.Errorf("Parser.GetAny with extensions returned unexpected %#v, %v", v, err)
+	}
+}
+
+func TestParserNumbers(t *testing.T) {
+	p := Parser{Numbers: BigFloatParser{}}
+	v, err := p.GetAny(numbersData, "qty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qty := v.([]interface{})
+	if f, ok := qty[1].(*big.Float); !ok || f.Text('f', 1) != "12345678901234567890.5" {
+		t.Errorf("Parser.GetAny with BigFloatParser should keep all digits, obtained %#v", qty[1])
+	}
+	if f, ok := qty[0].(*big.Float); !ok || f.Text('f', -1) != "3" {
+		t.Errorf("Parser.GetAny with BigFloatParser returned unexpected %#v", qty[0])
+	}
+
+	p.Numbers = JSONNumberParser{}
+	if v, err := p.GetAny(numbersData, "price"); err != nil || v != json.Number("0.1") {
+		t.Errorf("Parser.GetAny with JSONNumberParser returned unexpected %#v, %v", v, err)
+	}
+
+	// GetFloat and GetInt convert the values of every built-in parser
+	for _, numbers := range []NumberParser{Float64Parser{}, JSONNumberParser{}, BigFloatParser{}} {
+		p.Numbers = numbers
+		if v, err := p.GetFloat(numbersData, "price"); err != nil || v != 0.1 {
+			t.Errorf("Parser.GetFloat with %T returned unexpected %v, %v", numbers, v, err)
+		}
+		if v, err := p.GetInt(numbersData, "qty", "[0]"); err != nil || v != 3 {
+			t.Errorf("Parser.GetInt with %T returned unexpected %v, %v", numbers, v, err)
+		}
+		if v, err := p.GetInt(numbersData, "price"); err == nil {
+			t.Errorf("Parser.GetInt with %T should reject a fraction, obtained %v", numbers, v)
+		}
+		if v, err := p.GetInt([]byte(`[1e30]`), "[0]"); err == nil {
+			t.Errorf("Parser.GetInt with %T should reject an out of range value, obtained %v", numbers, v)
+		}
+	}
+}
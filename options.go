@@ -0,0 +1,465 @@
+package jsonparser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var (
+	infinityLiteral    = []byte("Infinity")
+	negInfinityLiteral = []byte("-Infinity")
+	nanLiteral         = []byte("NaN")
+)
+
+// Parser reads JSON with optional support for non-standard extensions emitted by some producers.
+// All extensions are disabled by default, in which case a Parser behaves exactly like the package-level functions.
+type Parser struct {
+	// AllowNonFiniteNumbers makes the JavaScript literals `Infinity`, `-Infinity` and `NaN` valid Number values,
+	// which `GetFloat` returns as math.Inf(1), math.Inf(-1) and math.NaN(). Without it, `GetFloat` and `ParseFloat`
+	// reject any non-finite value.
+	AllowNonFiniteNumbers bool
+
+	// AllowSingleQuotes makes strings delimited by `'` valid keys and values, e.g. `{'a':'b'}`. Inside them a single
+	// quote is escaped as `\'`, which `GetString` and `ParseString` also accept in double-quoted strings.
+	AllowSingleQuotes bool
+
+	// AllowJSON5Numbers makes the JSON5 number forms valid Number values: hexadecimal integers such as `0xFF`, and
+	// decimals with a leading or trailing point such as `.5` and `5.`, all optionally negative. `GetFloat` and
+	// `ParseFloat` accept all of them, `GetInt` and `ParseInt` hexadecimal integers and a trailing point.
+	AllowJSON5Numbers bool
+
+	// Numbers, if set, parses every number: `GetAny` returns its values, and `GetFloat` and `GetInt` convert them, which
+	// works for the values of the built-in parsers. It receives the tokens as they are, so the non-standard number forms
+	// enabled above are only understood by the default, which parses numbers as float64 like `ParseFloat`.
+	Numbers NumberParser
+}
+
+// isNonFinite reports whether the token is one of the JavaScript non-finite number literals
+func isNonFinite(b []byte) bool {
+	return bytes.Equal(b, infinityLiteral) || bytes.Equal(b, negInfinityLiteral) || bytes.Equal(b, nanLiteral)
+}
+
+// isJSON5Number reports whether the token is a number in one of the forms JSON5 adds to JSON
+func isJSON5Number(b []byte) bool {
+	if hexNumberDigits(b) != nil {
+		return true
+	}
+	return json5Decimal(b) != nil
+}
+
+// hexNumberDigits returns the digits of a hexadecimal integer token such as `-0xFF`, or nil if it isn't one
+func hexNumberDigits(b []byte) []byte {
+	if len(b) > 0 && b[0] == '-' {
+		b = b[1:]
+	}
+	if len(b) < 3 || b[0] != '0' || b[1] != 'x' && b[1] != 'X' {
+		return nil
+	}
+	for _, c := range b[2:] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return nil
+		}
+	}
+	return b[2:]
+}
+
+// json5Decimal returns the JSON equivalent of a decimal token with a leading or trailing point, e.g. `0.5` for `.5` and
+// `5.0` for `5.`, or nil if it isn't one
+func json5Decimal(b []byte) []byte {
+	dot := bytes.IndexByte(b, '.')
+	if dot == -1 {
+		return nil
+	}
+	leading := dot == 0 || dot == 1 && b[0] == '-'
+	trailing := dot == len(b)-1 || b[dot+1] == 'e' || b[dot+1] == 'E'
+	if leading == trailing { // a plain JSON number, or a lone point
+		return nil
+	}
+
+	out := make([]byte, 0, len(b)+1)
+	out = append(out, b[:dot]...)
+	if leading {
+		out = append(out, '0', '.')
+	} else {
+		out = append(out, '.', '0')
+	}
+	out = append(out, b[dot+1:]...)
+	if !isNumber(out) {
+		return nil
+	}
+	return out
+}
+
+// Get behaves like the package-level `Get`, taking the enabled extensions into account
+func (p *Parser) Get(data []byte, keys ...string) (value []byte, dataType ValueType, offset int, err error) {
+	// A single-quoted string may contain anything, even something the package-level lookup would take for the key,
+	// so with AllowSingleQuotes its result can't be trusted
+	if !p.AllowSingleQuotes {
+		value, dataType, offset, err = Get(data, keys...)
+		if err == nil || !p.AllowNonFiniteNumbers && !p.AllowJSON5Numbers {
+			return value, dataType, offset, err
+		}
+	}
+
+	// The package-level lookup fails on non-finite literals and numbers with a leading point, both when they are the
+	// requested value and when they are array elements on the way to it, so walk the path again stepping over them
+	if nextToken(data) == -1 {
+		return nil, NotExist, -1, EmptyInputError
+	}
+	base := 0
+	for _, k := range keys {
+		off := p.member(data[base:], k)
+		if off == -1 {
+			return nil, NotExist, -1, KeyPathNotFoundError
+		}
+		base += off
+	}
+
+	start, end, dataType, err := p.value(data[base:])
+	if err != nil {
+		return nil, NotExist, -1, err
+	}
+	start, end = base+start, base+end
+	if dataType == String {
+		return data[start+1 : end-1 : end-1], String, end, nil
+	}
+	return data[start:end:end], dataType, end, nil
+}
+
+// member returns the offset of the value selected by `key` (a member name, or an index such as `[2]`) within the object
+// or array at the beginning of `data`, or -1 if there is no such value
+func (p *Parser) member(data []byte, key string) int {
+	idx, isIndex := parseArrayIndex(key)
+	found := -1
+	p.walk(data, func(k []byte, i int, offset int) bool {
+		if isIndex && k == nil && i == idx || !isIndex && k != nil && string(k) == key {
+			found = offset
+			return true
+		}
+		return false
+	})
+	return found
+}
+
+// walk steps through the object or array at the beginning of `data`, calling `cb` (if not nil) with the unescaped key
+// (nil for array elements), the index and the offset of each value. It stops as soon as `cb` returns true, returning the
+// offset of that value; otherwise it returns the offset just past the container, or -1 if the data is malformed.
+func (p *Parser) walk(data []byte, cb func(key []byte, idx int, offset int) bool) int {
+	i := nextToken(data)
+	if i == -1 || data[i] != '{' && data[i] != '[' {
+		return -1
+	}
+	closeSym := byte(']')
+	if data[i] == '{' {
+		closeSym = '}'
+	}
+	i++
+
+	for idx := 0; ; idx++ {
+		off := nextToken(data[i:])
+		if off == -1 {
+			return -1
+		}
+		i += off
+		if idx == 0 && data[i] == closeSym {
+			return i + 1
+		}
+
+		var key []byte
+		if closeSym == '}' {
+			_, end, dt, err := p.value(data[i:])
+			if err != nil || dt != String {
+				return -1
+			}
+			if key, err = p.unescape(data[i+1 : i+end-1]); err != nil {
+				return -1
+			}
+			i += end
+
+			if off := nextToken(data[i:]); off == -1 || data[i+off] != ':' {
+				return -1
+			} else {
+				i += off + 1
+			}
+		}
+
+		start, end, _, err := p.value(data[i:])
+		if err != nil {
+			return -1
+		}
+		if cb != nil && cb(key, idx, i+start) {
+			return i + start
+		}
+		i += end
+
+		if off := nextToken(data[i:]); off == -1 {
+			return -1
+		} else if i += off; data[i] == closeSym {
+			return i + 1
+		} else if data[i] != ',' {
+			return -1
+		}
+		i++
+	}
+}
+
+// value locates the value at the beginning of `data` (after whitespace), taking the enabled extensions into account
+func (p *Parser) value(data []byte) (start, end int, dataType ValueType, err error) {
+	start = nextToken(data)
+	if start == -1 {
+		return -1, -1, NotExist, MalformedJsonError
+	}
+
+	switch c := data[start]; {
+	case c == '\'' && p.AllowSingleQuotes:
+		if se, _ := stringEndQuote(data[start+1:], '\''); se != -1 {
+			return start, start + 1 + se, String, nil
+		}
+		return -1, -1, String, MalformedStringError
+	case c == '{' && p.AllowSingleQuotes:
+		// blockEnd would take quotes inside single-quoted strings for string delimiters
+		if end = p.walk(data[start:], nil); end == -1 {
+			return -1, -1, Object, MalformedObjectError
+		}
+		return start, start + end, Object, nil
+	case c == '[' && p.AllowSingleQuotes:
+		if end = p.walk(data[start:], nil); end == -1 {
+			return -1, -1, Array, MalformedArrayError
+		}
+		return start, start + end, Array, nil
+	case p.AllowNonFiniteNumbers || p.AllowJSON5Numbers:
+		token := data[start : start+tokenEnd(data[start:])]
+		if p.AllowNonFiniteNumbers && isNonFinite(token) || p.AllowJSON5Numbers && isJSON5Number(token) {
+			return start, start + len(token), Number, nil
+		}
+	}
+
+	_, dataType, start, end, err = internalGet(data)
+	return start, end, dataType, err
+}
+
+// unescape unescapes the contents of a string, accepting `\'` with AllowSingleQuotes
+func (p *Parser) unescape(b []byte) ([]byte, error) {
+	if p.AllowSingleQuotes && bytes.Contains(b, []byte(`\'`)) {
+		out := make([]byte, 0, len(b))
+		for i := 0; i < len(b); i++ {
+			if b[i] == '\\' && i+1 < len(b) {
+				if i++; b[i] != '\'' {
+					out = append(out, '\\')
+				}
+			}
+			out = append(out, b[i])
+		}
+		b = out
+	}
+	return Unescape(b, nil)
+}
+
+// GetString behaves like the package-level `GetString`, taking the enabled extensions into account
+func (p *Parser) GetString(data []byte, keys ...string) (val string, err error) {
+	v, t, _, e := p.Get(data, keys...)
+
+	if e != nil {
+		return "", e
+	}
+
+	if t != String {
+		if t == Null {
+			return "", NullValueError
+		}
+		return "", fmt.Errorf("Value is not a string: %s", string(v))
+	}
+
+	return p.ParseString(v)
+}
+
+// ParseString behaves like the package-level `ParseString`, taking the enabled extensions into account
+func (p *Parser) ParseString(b []byte) (string, error) {
+	if bU, err := p.unescape(b); err != nil {
+		return "", MalformedValueError
+	} else {
+		return string(bU), nil
+	}
+}
+
+// GetFloat behaves like the package-level `GetFloat`, taking the enabled extensions into account
+func (p *Parser) GetFloat(data []byte, keys ...string) (val float64, err error) {
+	v, t, _, e := p.Get(data, keys...)
+
+	if e != nil {
+		return 0, e
+	}
+
+	if t != Number {
+		if t == Null {
+			return 0, NullValueError
+		}
+		return 0, fmt.Errorf("Value is not a number: %s", string(v))
+	}
+
+	if p.Numbers != nil {
+		n, err := p.Numbers.ParseNumber(v)
+		if err != nil {
+			return 0, err
+		}
+		return numberFloat(n)
+	}
+	return p.ParseFloat(v)
+}
+
+// ParseFloat behaves like the package-level `ParseFloat`, taking the enabled extensions into account
+func (p *Parser) ParseFloat(b []byte) (float64, error) {
+	if p.AllowNonFiniteNumbers {
+		switch {
+		case bytes.Equal(b, infinityLiteral):
+			return math.Inf(1), nil
+		case bytes.Equal(b, negInfinityLiteral):
+			return math.Inf(-1), nil
+		case bytes.Equal(b, nanLiteral):
+			return math.NaN(), nil
+		}
+	}
+
+	if p.AllowJSON5Numbers {
+		if digits := hexNumberDigits(b); digits != nil {
+			// strconv.ParseFloat only takes hexadecimal mantissas with an exponent, which also gets them rounded right
+			hex := make([]byte, 0, len(b)+2)
+			hex = append(append(hex, b[:len(b)-len(digits)]...), digits...)
+			b = append(hex, 'p', '0')
+		} else if dec := json5Decimal(b); dec != nil {
+			b = dec
+		}
+	}
+
+	// Unlike the package-level function, don't let strconv.ParseFloat turn e.g. `-Inf` or `1e999` into a non-finite value
+	if v, err := ParseFloat(b); err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, MalformedValueError
+	} else {
+		return v, nil
+	}
+}
+
+// GetInt behaves like the package-level `GetInt`, taking the enabled extensions into account
+func (p *Parser) GetInt(data []byte, keys ...string) (val int64, err error) {
+	v, t, _, e := p.Get(data, keys...)
+
+	if e != nil {
+		return 0, e
+	}
+
+	if t != Number {
+		if t == Null {
+			return 0, NullValueError
+		}
+		return 0, fmt.Errorf("Value is not a number: %s", string(v))
+	}
+
+	if p.Numbers != nil {
+		n, err := p.Numbers.ParseNumber(v)
+		if err != nil {
+			return 0, err
+		}
+		return numberInt(n)
+	}
+	return p.ParseInt(v)
+}
+
+// ParseInt behaves like the package-level `ParseInt`, taking the enabled extensions into account
+func (p *Parser) ParseInt(b []byte) (int64, error) {
+	if p.AllowJSON5Numbers {
+		if digits := hexNumberDigits(b); digits != nil {
+			u, err := strconv.ParseUint(string(digits), 16, 64)
+			neg := b[0] == '-'
+			if err != nil || !neg && u > math.MaxInt64 || neg && u > -math.MinInt64 {
+				return 0, OverflowIntegerError // digits are valid, so range is the only possible error
+			}
+			if neg {
+				return -int64(u), nil
+			}
+			return int64(u), nil
+		}
+		if n := len(b); n > 1 && b[n-1] == '.' {
+			b = b[:n-1]
+		}
+	}
+	return ParseInt(b)
+}
+
+// GetAny returns the value retrieved by `Get` decoded into Go values, like encoding/json decodes into an interface{}:
+// objects become a map[string]interface{}, arrays a []interface{}, and strings, booleans and null a string, bool and nil.
+// Numbers are parsed by `Numbers`, as float64 if it isn't set.
+func (p *Parser) GetAny(data []byte, keys ...string) (interface{}, error) {
+	v, t, _, err := p.Get(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return p.decodeAny(v, t, 0)
+}
+
+// decodeAny decodes a value as returned by `Get` for `GetAny`, found inside `depth` objects and arrays
+func (p *Parser) decodeAny(v []byte, t ValueType, depth int) (interface{}, error) {
+	switch t {
+	case String:
+		return p.ParseString(v)
+	case Number:
+		if p.Numbers != nil {
+			return p.Numbers.ParseNumber(v)
+		}
+		f, err := p.ParseFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case Boolean:
+		return ParseBoolean(v)
+	case Null:
+		return nil, nil
+	case Object, Array:
+		if depth >= MaxNestingDepth {
+			return nil, MaxDepthExceededError
+		}
+	default:
+		return nil, UnknownValueTypeError
+	}
+
+	obj := map[string]interface{}{}
+	arr := []interface{}{}
+	var err error
+	end := p.walk(v, func(key []byte, idx int, offset int) bool {
+		start, end, dt, e := p.value(v[offset:])
+		if e != nil {
+			err = e
+			return true
+		}
+		elem := v[offset+start : offset+end]
+		if dt == String {
+			elem = elem[1 : len(elem)-1]
+		}
+
+		var x interface{}
+		if x, err = p.decodeAny(elem, dt, depth+1); err != nil {
+			return true
+		}
+		if t == Object {
+			obj[string(key)] = x
+		} else {
+			arr = append(arr, x)
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if end == -1 {
+		if t == Object {
+			return nil, MalformedObjectError
+		}
+		return nil, MalformedArrayError
+	}
+	if t == Object {
+		return obj, nil
+	}
+	return arr, nil
+}
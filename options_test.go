@@ -0,0 +1,202 @@
+package jsonparser
+
+import (
+	"math"
+	"testing"
+)
+
+var nonFiniteTests = []struct {
+	json  string
+	path  []string
+	check func(float64) bool
+}{
+	{json: `{"a": Infinity}`, path: []string{"a"}, check: func(f float64) bool { return math.IsInf(f, 1) }},
+	{json: `{"a": -Infinity}`, path: []string{"a"}, check: func(f float64) bool { return math.IsInf(f, -1) }},
+	{json: `{"a":NaN, "b": 1}`, path: []string{"a"}, check: math.IsNaN},
+	{json: `{"a":[NaN,2]}`, path: []string{"a", "[0]"}, check: math.IsNaN},
+	{json: `{"a":[1, -Infinity ]}`, path: []string{"a", "[1]"}, check: func(f float64) bool { return math.IsInf(f, -1) }},
+	{json: `{"a":[{"b":[Infinity]}]}`, path: []string{"a", "[0]", "b", "[0]"}, check: func(f float64) bool { return math.IsInf(f, 1) }},
+}
+
+func TestParserNonFiniteNumbersStrict(t *testing.T) {
+	var p Parser
+	for _, test := range nonFiniteTests {
+		if v, err := p.GetFloat([]byte(test.json), test.path...); err == nil {
+			t.Errorf("Parser.GetFloat(%s) without AllowNonFiniteNumbers should fail, obtained %v", test.json, v)
+		}
+	}
+	if v, err := p.ParseFloat([]byte("1e999")); err == nil {
+		t.Errorf("Parser.ParseFloat should reject out of range values, obtained %v", v)
+	}
+}
+
+func TestNonFiniteNumbersPackageLevel(t *testing.T) {
+	// The package-level functions keep accepting whatever strconv.ParseFloat accepts for values classified as numbers
+	if v, err := GetFloat([]byte(`{"a": -Infinity}`), "a"); err != nil || !math.IsInf(v, -1) {
+		t.Errorf("GetFloat should read -Infinity as before, obtained %v, %v", v, err)
+	}
+	if _, err := GetFloat([]byte(`{"a": NaN}`), "a"); err != UnknownValueTypeError {
+		t.Errorf("GetFloat should not classify NaN as a value, obtained %v", err)
+	}
+}
+
+func TestParserNonFiniteNumbersLenient(t *testing.T) {
+	p := Parser{AllowNonFiniteNumbers: true}
+	for _, test := range nonFiniteTests {
+		if v, err := p.GetFloat([]byte(test.json), test.path...); err != nil || !test.check(v) {
+			t.Errorf("Parser.GetFloat(%s) returned unexpected %v, %v", test.json, v, err)
+		}
+		if _, dt, _, err := p.Get([]byte(test.json), test.path...); err != nil || dt != Number {
+			t.Errorf("Parser.Get(%s) should classify the value as a number, obtained %s, %v", test.json, dt, err)
+		}
+	}
+
+	// Values after a non-finite array element can be reached too, with offsets relative to the whole document
+	data := []byte(`{"a":[NaN,"x",{"b":true}]}`)
+	if v, dt, offset, err := p.Get(data, "a", "[1]"); err != nil || string(v) != "x" || dt != String || offset != 13 {
+		t.Errorf("Parser.Get after NaN returned unexpected %s, %s, %d, %v", v, dt, offset, err)
+	}
+	if v, err := p.GetFloat(data, "a", "[2]", "b"); err == nil {
+		t.Errorf("Parser.GetFloat should reject a boolean, obtained %v", v)
+	}
+	if _, _, _, err := p.Get(data, "a", "[3]"); err != KeyPathNotFoundError {
+		t.Errorf("Parser.Get past the end of the array should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+
+	// Other literals are still rejected
+	if _, err := p.GetFloat([]byte(`{"a": Inf}`), "a"); err == nil {
+		t.Error("Parser.GetFloat should reject Inf")
+	}
+	if _, err := p.GetFloat([]byte(`{"a": "NaN"}`), "a"); err == nil {
+		t.Error("Parser.GetFloat should reject a NaN string")
+	}
+	if v, err := p.GetFloat([]byte(`{"a": 1.5}`), "a"); err != nil || v != 1.5 {
+		t.Errorf("Parser.GetFloat should read regular numbers, obtained %v, %v", v, err)
+	}
+}
+
+var singleQuoteTests = []struct {
+	json  string
+	path  []string
+	value string
+}{
+	{json: `{'a':'b'}`, path: []string{"a"}, value: "b"},
+	{json: `{'a\'b' : 'c\'d', "e": 'f"g'}`, path: []string{"a'b"}, value: "c'd"},
+	{json: `{'a\'b' : 'c\'d', "e": 'f"g'}`, path: []string{"e"}, value: `f"g`},
+	{json: `{"a": "it\'s"}`, path: []string{"a"}, value: "it's"},
+	{json: `{'x': {'y': ['}', 'z\\']}, 'w': 'v'}`, path: []string{"x", "y", "[1]"}, value: `z\`},
+	{json: `{'x': {'y': ['}', 'z\\']}, 'w': 'v'}`, path: []string{"w"}, value: "v"},
+	{json: `{'k': '"a": "no"', 'a': 'yes'}`, path: []string{"a"}, value: "yes"},
+	{json: `{'a': 'é\n'}`, path: []string{"a"}, value: "é\n"},
+	{json: `'top'`, value: "top"},
+}
+
+func TestParserSingleQuotes(t *testing.T) {
+	var strict Parser
+	lenient := Parser{AllowSingleQuotes: true}
+	for _, test := range singleQuoteTests {
+		if v, err := lenient.GetString([]byte(test.json), test.path...); err != nil || v != test.value {
+			t.Errorf("Parser.GetString(%s, %q) expected %q, obtained %q, %v", test.json, test.path, test.value, v, err)
+		}
+		if test.json[0] == '{' && test.json[1] == '\'' {
+			if v, err := strict.GetString([]byte(test.json), test.path...); err == nil {
+				t.Errorf("Parser.GetString(%s) without AllowSingleQuotes should fail, obtained %q", test.json, v)
+			}
+		}
+	}
+
+	data := []byte(`{'a': ['x', {'b': true}], 'c': 1}`)
+	if v, dt, offset, err := lenient.Get(data, "a"); err != nil || string(v) != `['x', {'b': true}]` || dt != Array || offset != 24 {
+		t.Errorf("Parser.Get returned unexpected %s, %s, %d, %v", v, dt, offset, err)
+	}
+	if v, dt, offset, err := lenient.Get(data, "a", "[0]"); err != nil || string(v) != "x" || dt != String || offset != 10 {
+		t.Errorf("Parser.Get returned unexpected %s, %s, %d, %v", v, dt, offset, err)
+	}
+	if _, _, _, err := lenient.Get(data, "a", "[1]", "c"); err != KeyPathNotFoundError {
+		t.Errorf("Parser.Get of a missing key should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, _, _, err := lenient.Get([]byte(`{'a': 'unterminated}`), "a"); err == nil {
+		t.Error("Parser.Get should reject an unterminated single-quoted string")
+	}
+
+	// Both extensions together
+	both := Parser{AllowSingleQuotes: true, AllowNonFiniteNumbers: true}
+	if v, err := both.GetFloat([]byte(`{'a': ['NaN', -Infinity]}`), "a", "[1]"); err != nil || !math.IsInf(v, -1) {
+		t.Errorf("Parser.GetFloat with both extensions returned unexpected %v, %v", v, err)
+	}
+}
+
+var json5NumberTests = []struct {
+	json    string
+	path    []string
+	float   float64
+	int     int64
+	isFloat bool // not an integer, so GetInt fails
+}{
+	{json: `{"a": 0xFF}`, path: []string{"a"}, float: 255, int: 255},
+	{json: `{"a": -0x1a}`, path: []string{"a"}, float: -26, int: -26},
+	{json: `{"a": 0X7fffffffffffffff}`, path: []string{"a"}, float: math.MaxInt64, int: math.MaxInt64},
+	{json: `{"a": -0x8000000000000000}`, path: []string{"a"}, float: math.MinInt64, int: math.MinInt64},
+	{json: `{"a": .5}`, path: []string{"a"}, float: 0.5, isFloat: true},
+	{json: `{"a": [-.25, 1]}`, path: []string{"a", "[0]"}, float: -0.25, isFloat: true},
+	{json: `{"a": .5e1}`, path: []string{"a"}, float: 5, isFloat: true},
+	{json: `{"a": 5.}`, path: []string{"a"}, float: 5, int: 5},
+	{json: `{"a": [-5., 1]}`, path: []string{"a", "[0]"}, float: -5, int: -5},
+	{json: `{"a": 5.e2}`, path: []string{"a"}, float: 500, isFloat: true},
+}
+
+func TestParserJSON5NumbersStrict(t *testing.T) {
+	var p Parser
+	for _, test := range json5NumberTests {
+		v, _, _, err := p.Get([]byte(test.json), test.path...)
+		if err == nil {
+			if _, err := ParseFloatStrict(v); err == nil {
+				t.Errorf("ParseFloatStrict(%s) should fail", v)
+			}
+		}
+		if v, err := p.GetInt([]byte(test.json), test.path...); err == nil {
+			t.Errorf("Parser.GetInt(%s) without AllowJSON5Numbers should fail, obtained %v", test.json, v)
+		}
+	}
+	if v, err := p.GetFloat([]byte(`{"a": 0xFF}`), "a"); err == nil {
+		t.Errorf("Parser.GetFloat of a hexadecimal number without AllowJSON5Numbers should fail, obtained %v", v)
+	}
+	if _, err := p.GetFloat([]byte(`{"a": .5}`), "a"); err != UnknownValueTypeError {
+		t.Errorf("Parser.GetFloat should not classify .5 as a value without AllowJSON5Numbers, obtained %v", err)
+	}
+}
+
+func TestParserJSON5NumbersLenient(t *testing.T) {
+	p := Parser{AllowJSON5Numbers: true}
+	for _, test := range json5NumberTests {
+		if _, dt, _, err := p.Get([]byte(test.json), test.path...); err != nil || dt != Number {
+			t.Errorf("Parser.Get(%s) should classify the value as a number, obtained %s, %v", test.json, dt, err)
+		}
+		if v, err := p.GetFloat([]byte(test.json), test.path...); err != nil || v != test.float {
+			t.Errorf("Parser.GetFloat(%s) expected %v, obtained %v, %v", test.json, test.float, v, err)
+		}
+		if v, err := p.GetInt([]byte(test.json), test.path...); test.isFloat && err == nil {
+			t.Errorf("Parser.GetInt(%s) should fail, obtained %v", test.json, v)
+		} else if !test.isFloat && (err != nil || v != test.int) {
+			t.Errorf("Parser.GetInt(%s) expected %v, obtained %v, %v", test.json, test.int, v, err)
+		}
+	}
+
+	// Values after a number with a leading point can be reached too
+	if v, err := p.GetInt([]byte(`{"a": [.5, 0x10]}`), "a", "[1]"); err != nil || v != 16 {
+		t.Errorf("Parser.GetInt after .5 returned unexpected %v, %v", v, err)
+	}
+
+	// Malformed forms are still rejected
+	for _, in := range []string{"0x", "0xG", "-0x", ".", "-.", "..5", ".5.", "5..", ".e1", "00x1", "0x1.5"} {
+		if v, err := p.ParseFloat([]byte(in)); err == nil {
+			t.Errorf("Parser.ParseFloat(%s) should fail, obtained %v", in, v)
+		}
+	}
+	if _, err := p.ParseInt([]byte("0x8000000000000000")); err != OverflowIntegerError {
+		t.Errorf("Parser.ParseInt should fail on a hexadecimal overflow with OverflowIntegerError, obtained %v", err)
+	}
+	if _, err := p.ParseInt([]byte("-0x8000000000000001")); err != OverflowIntegerError {
+		t.Errorf("Parser.ParseInt should fail on a hexadecimal underflow with OverflowIntegerError, obtained %v", err)
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Errors
@@ -19,8 +23,23 @@ var (
 	OverflowIntegerError       = errors.New("Value is number, but overflowed while parsing")
 	MalformedStringEscapeError = errors.New("Encountered an invalid escape sequence in a string")
 	NullValueError             = errors.New("Value is null")
+	PathTypeMismatchError      = errors.New("Key path goes through a value which is not an object or array")
+	NotTraversableError        = PathTypeMismatchError // returned by `Get` for keys in a scalar root, e.g. "a" in `5`
+	EmptyInputError            = errors.New("Input is empty or contains only whitespace")
+	ValueTooLargeError         = errors.New("Value is longer than the allowed maximum")
+	TrailingDataError          = errors.New("Data found after the end of the root value")
+	MaxDepthExceededError      = errors.New("Objects and arrays are nested deeper than MaxNestingDepth")
 )
 
+// MaxNestingDepth caps how deep the functions which walk whole documents descend into nested objects and arrays:
+// `FindAll`, `GetDescendant`, `Query`, `Patch`, `Parser.GetAny` and `Marshal` fail with `MaxDepthExceededError` rather
+// than open a container nested deeper than this, counted like `Depth` does. It bounds the memory used on untrusted input.
+// Change it before parsing starts, as it is read without synchronization.
+var MaxNestingDepth = 10000
+
+// stopIteration is returned by internal `ObjectEach` callbacks to end the iteration early; it never escapes the package
+var stopIteration = errors.New("stop iteration")
+
 // How much stack space to allocate for unescaping JSON strings; if a string longer
 // than this needs to be escaped, it will result in a heap allocation
 const unescapeStackBufSize = 64
@@ -156,34 +175,65 @@ func lastToken(data []byte) int {
 	return -1
 }
 
+// Strings up to this length (which covers most keys) are faster to scan byte by byte than with bytes.IndexByte
+const shortStringLen = 16
+
 // Tries to find the end of string
 // Support if string contains escaped quote symbols.
+// The first few bytes are scanned one by one, longer strings and strings with backslashes are handed over to stringEndBulk.
 func stringEnd(data []byte) (int, bool) {
-	escaped := false
 	for i, c := range data {
 		if c == '"' {
-			if !escaped {
-				return i + 1, false
-			} else {
-				j := i - 1
-				for {
-					if j < 0 || data[j] != '\\' {
-						return i + 1, true // even number of backslashes
-					}
-					j--
-					if j < 0 || data[j] != '\\' {
-						break // odd number of backslashes
-					}
-					j--
+			return i + 1, false
+		}
+		if c == '\\' || i == shortStringLen {
+			return stringEndBulk(data, i, false, '"')
+		}
+	}
 
-				}
+	return -1, false
+}
+
+// stringEndQuote is like stringEnd for strings delimited by `quote` instead of '"'
+func stringEndQuote(data []byte, quote byte) (int, bool) {
+	return stringEndBulk(data, 0, false, quote)
+}
+
+// stringEndBulk continues stringEnd from offset i. Instead of looking at every byte, it jumps from one `quote` candidate
+// to the next with bytes.IndexByte, only checking the bytes in between for backslashes until the first one is found.
+func stringEndBulk(data []byte, i int, escaped bool, quote byte) (int, bool) {
+	for {
+		q := bytes.IndexByte(data[i:], quote)
+		if q == -1 {
+			if !escaped && bytes.IndexByte(data[i:], '\\') != -1 {
+				escaped = true
+			}
+			return -1, escaped
+		}
+		q += i
+
+		if !escaped {
+			if bytes.IndexByte(data[i:q], '\\') == -1 {
+				return q + 1, false
 			}
-		} else if c == '\\' {
 			escaped = true
 		}
+
+		if quoteUnescaped(data, q) {
+			return q + 1, true
+		}
+
+		i = q + 1
 	}
+}
 
-	return -1, escaped
+// quoteUnescaped reports whether the quote at data[q] is preceded by an even number of backslashes, i.e. closes the string
+func quoteUnescaped(data []byte, q int) bool {
+	j := q - 1
+	for j >= 0 && data[j] == '\\' {
+		j--
+	}
+	return (q-1-j)%2 == 0
 }
 
 // Find end of the data structure, array or object.
@@ -217,7 +267,60 @@ func blockEnd(data []byte, openSym byte, closeSym byte) int {
 	return -1
 }
 
+// skipValue returns the offset just past the value at the beginning of `data`, or -1 if its end can't be found. Unlike
+// `Get`, it doesn't check what's between the delimiters, e.g. whether a literal is `true`, `false` or `null`.
+func skipValue(data []byte) int {
+	i := nextToken(data)
+	if i == -1 {
+		return -1
+	}
+
+	var end int
+	switch data[i] {
+	case '"':
+		if end, _ = stringEnd(data[i+1:]); end != -1 {
+			end++
+		}
+	case '[':
+		end = blockEnd(data[i:], '[', ']')
+	case '{':
+		end = blockEnd(data[i:], '{', '}')
+	default:
+		end = tokenEnd(data[i:])
+	}
+	if end <= 0 {
+		return -1
+	}
+	return i + end
+}
+
+// containsKey reports whether `key` is one of `keys`
+func containsKey(keys []string, key []byte) bool {
+	for _, k := range keys {
+		if equalStr(&key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArrayIndex parses an array index path segment such as `[3]`. It reports false unless the brackets enclose decimal
+// digits only, so that e.g. `[]`, `[abc]`, `[-1]` or `[1e2]` never select an element.
+func parseArrayIndex(key string) (int, bool) {
+	if len(key) < 3 || key[0] != '[' || key[len(key)-1] != ']' {
+		return 0, false
+	}
+	for i := 1; i < len(key)-1; i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return 0, false
+		}
+	}
+	idx, err := strconv.Atoi(key[1 : len(key)-1])
+	return idx, err == nil
+}
+
 func searchKeys(data []byte, keys ...string) int {
+	base := 0 // offset of `data` in the original data, after descending into an array element
 	keyLevel := 0
 	level := 0
 	i := 0
@@ -260,10 +363,14 @@ func searchKeys(data []byte, keys ...string) int {
 				key := data[keyBegin:keyEnd]
 
 				// for unescape: if there are no escape sequences, this is cheap; if there are, it is a
-				// bit more expensive, but causes no allocations unless len(key) > unescapeStackBufSize
+				// bit more expensive, but causes no allocations unless len(key) > unescapeStackBufSize.
+				// Every escape sequence is longer than what it stands for, so an escaped key can only match a
+				// shorter target, and other keys are skipped without unescaping them.
 				var keyUnesc []byte
 				if !keyEscaped {
 					keyUnesc = key
+				} else if level <= len(keys) && len(keys[level-1]) >= len(key) {
+					keyUnesc = nil
 				} else if ku, err := Unescape(key, stackbuf[:]); err != nil {
 					return -1
 				} else {
@@ -271,7 +378,7 @@ func searchKeys(data []byte, keys ...string) int {
 				}
 
 				if level <= len(keys) {
-					if equalStr(&keyUnesc, keys[level-1]) {
+					if keyUnesc != nil && equalStr(&keyUnesc, keys[level-1]) {
 						lastMatched = true
 
 						// if key level match
@@ -279,7 +386,7 @@ func searchKeys(data []byte, keys ...string) int {
 							keyLevel++
 							// If we found all keys in path
 							if keyLevel == lk {
-								return i + 1
+								return base + i + 1
 							}
 						}
 					} else {
@@ -312,12 +419,8 @@ func searchKeys(data []byte, keys ...string) int {
 		case '[':
 			// If we want to get array element by index
 			if keyLevel == level && keys[level][0] == '[' {
-				keyLen := len(keys[level])
-				if keyLen < 3 || keys[level][0] != '[' || keys[level][keyLen-1] != ']' {
-					return -1
-				}
-				aIdx, err := strconv.Atoi(keys[level][1 : keyLen-1])
-				if err != nil {
+				aIdx, ok := parseArrayIndex(keys[level])
+				if !ok {
 					return -1
 				}
 				var curIdx int
@@ -338,13 +441,17 @@ func searchKeys(data []byte, keys ...string) int {
 
 				if valueFound == nil {
 					return -1
-				} else {
-					subIndex := searchKeys(valueFound, keys[level+1:]...)
-					if subIndex < 0 {
-						return -1
-					}
-					return i + valueOffset + subIndex
 				}
+
+				// Search the element for the remaining keys. This restarts the loop rather than recursing, so the
+				// stack use doesn't grow with the number of index segments.
+				base += i + valueOffset
+				data, keys = valueFound, keys[level+1:]
+				if len(keys) == 0 {
+					return base
+				}
+				keyLevel, level, i, ln, lk, lastMatched = 0, 0, 0, len(data), len(keys), true
+				continue
 			} else {
 				// Do not search for keys inside arrays
 				if arraySkip := blockEnd(data[i:], '[', ']'); arraySkip == -1 {
@@ -378,9 +485,123 @@ func sameTree(p1, p2 []string) bool {
 	return true
 }
 
+// PathsConflict reports whether two key paths passed to `EachKey` overlap, i.e. they are identical or one leads into the
+// value of the other, as with `a` and `a.b`. Both paths of such a pair are matched, so the value of the shorter one
+// contains the value of the longer one. Like in `EachKey`, a `[*]` segment stands for any array index, so `a.[*]`
+// conflicts with `a.[0].b`. Tooling generating many paths can use it to validate them before calling `EachKey`.
+func PathsConflict(a, b []string) bool {
+	minLen := len(a)
+	if len(b) < minLen {
+		minLen = len(b)
+	}
+
+	for i, k := range a[:minLen] {
+		if k == b[i] {
+			continue
+		}
+		isIndex := len(k) > 0 && k[0] == '[' && len(b[i]) > 0 && b[i][0] == '['
+		if !isIndex || k != "[*]" && b[i] != "[*]" {
+			return false
+		}
+	}
+	return true
+}
+
 const stackArraySize = 128
 
+// EachKey looks up several paths in a single pass, invoking `cb` with the index of the path in `paths` for every path found.
+// A path segment `[*]` matches every element of an array, in which case `cb` fires once per matching element, in order;
+// use `EachKeyIndexed` to learn which element matched. Only one `[*]` segment per path is supported.
 func EachKey(data []byte, cb func(int, []byte, ValueType, error), paths ...[]string) int {
+	return eachKey(data, func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error) {
+		cb(idx, value, vt, err)
+	}, paths...)
+}
+
+// EachKeyIndexed is like `EachKey`, but also passes the index of the array element matched by the `[*]` segment of the
+// path, or -1 for paths without a wildcard
+func EachKeyIndexed(data []byte, cb func(idx int, elem int, value []byte, vt ValueType, err error), paths ...[]string) int {
+	return eachKey(data, func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error) {
+		cb(idx, elem, value, vt, err)
+	}, paths...)
+}
+
+// EachKeyWithKey is like `EachKey`, but also passes the unescaped bytes of the key which matched the last path segment
+// (for paths ending with an array index, the index segment itself, e.g. `[3]`). The key slice may point into `data` or into a
+// temporary buffer, so it must be treated as read-only and is only valid during the callback.
+func EachKeyWithKey(data []byte, cb func(idx int, key []byte, value []byte, vt ValueType, err error), paths ...[]string) int {
+	var keyBuf []byte
+	return eachKey(data, func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error) {
+		if key == nil && idx >= 0 {
+			// Matched inside an array, where the key is the last path segment, or the element index for a trailing `[*]`
+			last := paths[idx][len(paths[idx])-1]
+			if last == "[*]" {
+				keyBuf = append(strconv.AppendInt(append(keyBuf[:0], '['), int64(elem), 10), ']')
+			} else {
+				keyBuf = append(keyBuf[:0], last...)
+			}
+			key = keyBuf[:len(keyBuf):len(keyBuf)]
+		}
+		cb(idx, key, value, vt, err)
+	}, paths...)
+}
+
+// EachKeyLast is like `EachKey`, but keeps only the last match of every path, for paths which match several times through
+// a `[*]` segment. `cb` is invoked once per found path after the whole pass, in the order of `paths`.
+// Errors which aren't tied to a path are passed on with index -1 as they occur, like `EachKey` does.
+func EachKeyLast(data []byte, cb func(int, []byte, ValueType, error), paths ...[]string) int {
+	type match struct {
+		value []byte
+		vt    ValueType
+		err   error
+		found bool
+	}
+	last := make([]match, len(paths))
+
+	offset := eachKey(data, func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error) {
+		if idx < 0 {
+			cb(idx, value, vt, err)
+			return
+		}
+		last[idx] = match{value: value, vt: vt, err: err, found: true}
+	}, paths...)
+
+	for idx, m := range last {
+		if m.found {
+			cb(idx, m.value, m.vt, m.err)
+		}
+	}
+	return offset
+}
+
+// KeyLoc is the result of looking up one path with `KeyIndex`
+type KeyLoc struct {
+	Offset int       // where the value ends, like the offset returned by `Get`, or -1 if it wasn't found
+	Found  bool      // whether the path exists and its value could be parsed
+	Type   ValueType // type of the value, or NotExist if it wasn't found
+}
+
+// KeyIndex looks up several paths in a single pass like `EachKey`, and returns where each value ends and its type as `Get`
+// would report them, in the order of `paths`. For a path with a `[*]` segment, the first matching element is reported.
+// A value which can't be parsed counts as not found.
+func KeyIndex(data []byte, paths ...[]string) []KeyLoc {
+	locs := make([]KeyLoc, len(paths))
+	for i := range locs {
+		locs[i] = KeyLoc{Offset: -1, Type: NotExist}
+	}
+
+	eachKey(data, func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error) {
+		if idx >= 0 && err == nil && !locs[idx].Found {
+			locs[idx] = KeyLoc{Offset: offset, Found: true, Type: vt}
+		}
+	}, paths...)
+	return locs
+}
+
+// eachKey implements `EachKey` and its variants; `offset` is where the value ends in `data`, like the offset returned by `Get`.
+// `key` is only set for matches of an object key: matches inside arrays pass nil, so that callers which don't need the key
+// don't pay for building it.
+func eachKey(data []byte, cb func(idx int, key []byte, elem int, value []byte, vt ValueType, offset int, err error), paths ...[]string) int {
 	var x struct{}
 	var level, pathsMatched, i int
 	ln := len(data)
@@ -446,7 +667,7 @@ func EachKey(data []byte, cb func(int, []byte, ValueType, error), paths ...[]str
 
 				if maxPath >= level {
 					if level < 1 {
-						cb(-1, nil, Unknown, MalformedJsonError)
+						cb(-1, nil, -1, nil, Unknown, -1, MalformedJsonError)
 						return -1
 					}
 
@@ -461,8 +682,8 @@ func EachKey(data []byte, cb func(int, []byte, ValueType, error), paths ...[]str
 						pathsMatched++
 						pathFlags[pi] = true
 
-						v, dt, _, e := Get(data[i+1:])
-						cb(pi, v, dt, e)
+						v, dt, of, e := Get(data[i+1:])
+						cb(pi, keyUnesc[:len(keyUnesc):len(keyUnesc)], -1, v, dt, i+1+of, e)
 
 						if pathsMatched == len(paths) {
 							break
@@ -507,40 +728,58 @@ func EachKey(data []byte, cb func(int, []byte, ValueType, error), paths ...[]str
 			pIdxFlags = pIdxFlags[0:len(paths)]
 
 			if level < 0 {
-				cb(-1, nil, Unknown, MalformedJsonError)
+				cb(-1, nil, -1, nil, Unknown, -1, MalformedJsonError)
 				return -1
 			}
 
+			var wildcards int
 			for pi, p := range paths {
 				if len(p) < level+1 || pathFlags[pi] || p[level][0] != '[' || !sameTree(p, pathsBuf[:level]) {
 					continue
 				}
-				if len(p[level]) >= 2 {
-					aIdx, _ := strconv.Atoi(p[level][1 : len(p[level])-1])
+				if p[level] == "[*]" {
+					wildcards++
+					pIdxFlags[pi] = true
+				} else if aIdx, ok := parseArrayIndex(p[level]); ok {
 					arrIdxFlags[aIdx] = x
 					pIdxFlags[pi] = true
 				}
 			}
 
-			if len(arrIdxFlags) > 0 {
+			if len(arrIdxFlags) > 0 || wildcards > 0 {
 				level++
 
 				var curIdx int
 				arrOff, _ := ArrayEach(data[i:], func(value []byte, dataType ValueType, offset int, err error) {
+					if wildcards > 0 {
+						for pi, p := range paths {
+							if !pIdxFlags[pi] || p[level-1] != "[*]" {
+								continue
+							}
+							if len(p) == level {
+								cb(pi, nil, curIdx, value, dataType, i+offset+len(value), err)
+							} else if of := searchKeys(value, p[level:]...); of != -1 {
+								v, dt, o, e := Get(value[of:])
+								cb(pi, nil, curIdx, v, dt, i+offset+of+o, e)
+							}
+						}
+					}
+
 					if _, ok = arrIdxFlags[curIdx]; ok {
 						for pi, p := range paths {
-							if pIdxFlags[pi] {
-								aIdx, _ := strconv.Atoi(p[level-1][1 : len(p[level-1])-1])
+							if pIdxFlags[pi] && p[level-1] != "[*]" {
+								aIdx, _ := parseArrayIndex(p[level-1])
 
 								if curIdx == aIdx {
-									of := searchKeys(value, p[level:]...)
-
 									pathsMatched++
 									pathFlags[pi] = true
 
-									if of != -1 {
-										v, dt, _, e := Get(value[of:])
-										cb(pi, v, dt, e)
+									// The element itself: string values come unquoted, so they can't be looked up again
+									if len(p) == level {
+										cb(pi, nil, -1, value, dataType, i+offset+len(value), err)
+									} else if of := searchKeys(value, p[level:]...); of != -1 {
+										v, dt, o, e := Get(value[of:])
+										cb(pi, nil, -1, v, dt, i+offset+of+o, e)
 									}
 								}
 							}
@@ -550,6 +789,14 @@ func EachKey(data []byte, cb func(int, []byte, ValueType, error), paths ...[]str
 					curIdx += 1
 				})
 
+				// Wildcard paths are done once the whole array has been visited
+				for pi, p := range paths {
+					if pIdxFlags[pi] && p[level-1] == "[*]" {
+						pathsMatched++
+						pathFlags[pi] = true
+					}
+				}
+
 				if pathsMatched == len(paths) {
 					return i
 				}
@@ -608,6 +855,16 @@ func (vt ValueType) String() string {
 	}
 }
 
+// ParseValueType is the inverse of `ValueType.String`, e.g. it returns `Number` for "number". Other names are rejected.
+func ParseValueType(s string) (ValueType, error) {
+	for vt := NotExist; vt <= Unknown; vt++ {
+		if vt.String() == s {
+			return vt, nil
+		}
+	}
+	return Unknown, fmt.Errorf("Unknown value type name: %q", s)
+}
+
 var (
 	trueLiteral  = []byte("true")
 	falseLiteral = []byte("false")
@@ -731,21 +988,29 @@ func Delete(data []byte, keys ...string) []byte {
 	if !array {
 		if len(keys) > 1 {
 			_, _, startOffset, endOffset, err = internalGet(data, keys[:lk-1]...)
-			if err == KeyPathNotFoundError {
+			if err != nil {
 				// problem parsing the data
 				return data
 			}
 		}
 
 		keyOffset, err = findKeyStart(data[startOffset:endOffset], keys[lk-1])
-		if err == KeyPathNotFoundError {
+		if err != nil {
 			// problem parsing the data
 			return data
 		}
 		keyOffset += startOffset
-		_, _, _, subEndOffset, _ := internalGet(data[startOffset:endOffset], keys[lk-1])
+		_, _, _, subEndOffset, err := internalGet(data[startOffset:endOffset], keys[lk-1])
+		if err != nil {
+			// problem parsing the data
+			return data
+		}
 		endOffset = startOffset + subEndOffset
 		tokEnd := tokenEnd(data[endOffset:])
+		if endOffset+tokEnd >= len(data) {
+			// the document ends right after the value, so it's truncated
+			return data
+		}
 		tokStart := findTokenStart(data[:keyOffset], ","[0])
 
 		if data[endOffset+tokEnd] == ","[0] {
@@ -757,12 +1022,16 @@ func Delete(data []byte, keys ...string) []byte {
 		}
 	} else {
 		_, _, keyOffset, endOffset, err = internalGet(data, keys...)
-		if err == KeyPathNotFoundError {
+		if err != nil {
 			// problem parsing the data
 			return data
 		}
 
 		tokEnd := tokenEnd(data[endOffset:])
+		if endOffset+tokEnd >= len(data) {
+			// the document ends right after the value, so it's truncated
+			return data
+		}
 		tokStart := findTokenStart(data[:keyOffset], ","[0])
 
 		if data[endOffset+tokEnd] == ","[0] {
@@ -802,16 +1071,62 @@ Returns:
 
 */
 func Set(data []byte, setValue []byte, keys ...string) (value []byte, err error) {
+	start, end, insert, exists, err := setSplice(data, setValue, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return append(data[:start], append(insert, data[end:]...)...), nil
+	}
+
+	startComponent := data[:start]
+	endComponent := data[end:]
+
+	value = make([]byte, len(startComponent)+len(endComponent)+len(insert))
+	newEndOffset := start + len(insert)
+	copy(value[0:start], startComponent)
+	copy(value[start:newEndOffset], insert)
+	copy(value[newEndOffset:], endComponent)
+	return value, nil
+}
+
+/*
+
+SetBuf - Like `Set`, but writes the result into `dst[:0]`, growing it as needed, instead of allocating a new slice on
+every call. Reusing the returned buffer across calls avoids allocations when editing documents at a high rate, e.g.
+`buf, err = SetBuf(buf, data, value, "a")`. `dst` must not overlap `data`.
+
+Returns:
+`value` - `dst` holding the modified data
+`err` - On any parsing error
+
+*/
+func SetBuf(dst []byte, data []byte, setValue []byte, keys ...string) (value []byte, err error) {
+	start, end, insert, _, err := setSplice(data, setValue, keys)
+	if err != nil {
+		return dst[:0], err
+	}
+
+	value = append(dst[:0], data[:start]...)
+	value = append(value, insert...)
+	return append(value, data[end:]...), nil
+}
+
+// setSplice works out how `Set` modifies `data`: `data[start:end]` is replaced with `insert`. If the path exists,
+// `insert` is `setValue` replacing the current value; otherwise it is the missing part of the path wrapped around
+// `setValue`.
+func setSplice(data []byte, setValue []byte, keys []string) (start, end int, insert []byte, exists bool, err error) {
 	// ensure keys are set
 	if len(keys) == 0 {
-		return nil, KeyPathNotFoundError
+		return 0, 0, nil, false, KeyPathNotFoundError
 	}
 
 	_, _, startOffset, endOffset, err := internalGet(data, keys...)
 	if err != nil {
 		if err != KeyPathNotFoundError {
 			// problem parsing the data
-			return nil, err
+			return 0, 0, nil, false, err
 		}
 		// full path doesnt exist
 		// does any subpath exist?
@@ -832,7 +1147,7 @@ func Set(data []byte, setValue []byte, keys ...string) (value []byte, err error)
 			firstToken := nextToken(data)
 			// We can't set a top-level key if data isn't an object
 			if firstToken < 0 || data[firstToken] != '{' {
-				return nil, KeyPathNotFoundError
+				return 0, 0, nil, false, KeyPathNotFoundError
 			}
 			// Don't need a comma if the input is an empty object
 			secondToken := firstToken + 1 + nextToken(data[firstToken+1:])
@@ -859,19 +1174,183 @@ func Set(data []byte, setValue []byte, keys ...string) (value []byte, err error)
 		} else {
 			startOffset = depthOffset
 		}
-		value = append(data[:startOffset], append(createInsertComponent(keys[depth:], setValue, comma, object), data[depthOffset:]...)...)
-	} else {
-		// path currently exists
-		startComponent := data[:startOffset]
-		endComponent := data[endOffset:]
+		return startOffset, depthOffset, createInsertComponent(keys[depth:], setValue, comma, object), false, nil
+	}
+
+	// path currently exists
+	return startOffset, endOffset, setValue, true, nil
+}
 
-		value = make([]byte, len(startComponent)+len(endComponent)+len(setValue))
-		newEndOffset := startOffset + len(setValue)
-		copy(value[0:startOffset], startComponent)
-		copy(value[startOffset:newEndOffset], setValue)
-		copy(value[newEndOffset:], endComponent)
+
+// SetArrayElementBy replaces the first element of the array at `keys` whose value at `childPath` equals `match` with
+// `newValue`, e.g. to update the user with `"id": 42` in a list of users without working out offsets by hand. The child
+// value and `match` are compared byte for byte as raw JSON, so a string must be given with its quotes (`"bob"`); an
+// empty `childPath` compares the element itself. Like with `Set`, `newValue` is written as is. `KeyPathNotFoundError` is
+// returned if no element matches.
+func SetArrayElementBy(data []byte, childPath []string, match []byte, newValue []byte, keys ...string) ([]byte, error) {
+	_, t, start, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
 	}
-	return value, nil
+	if t != Array {
+		return nil, MalformedArrayError
+	}
+
+	match = bytes.TrimSpace(match)
+	elemStart, elemEnd := -1, -1
+	_, err = ArrayEachFrom(data, start, func(value []byte, dataType ValueType, offset int) bool {
+		end := offset + len(value)
+		if dataType == String {
+			end += 2
+		}
+		elem := data[offset:end]
+		if _, _, cStart, cEnd, e := internalGet(elem, childPath...); e == nil && bytes.Equal(elem[cStart:cEnd], match) {
+			elemStart, elemEnd = offset, end
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if elemStart == -1 {
+		return nil, KeyPathNotFoundError
+	}
+
+	out := make([]byte, 0, len(data)-(elemEnd-elemStart)+len(newValue))
+	out = append(out, data[:elemStart]...)
+	out = append(out, newValue...)
+	return append(out, data[elemEnd:]...), nil
+}
+
+// SetBefore is like `Set`, but when the key at the end of `keys` doesn't exist yet, inserts it immediately before its
+// sibling `beforeKey` instead of at the end of the object, e.g. to keep a deterministic key order. An existing key is
+// replaced in place, and if the parent object exists but `beforeKey` isn't in it, the key is appended like with `Set`.
+// Like with `Set`, the key and `value` are written as is.
+func SetBefore(data []byte, value []byte, beforeKey string, keys ...string) ([]byte, error) {
+	return setBeside(data, value, beforeKey, false, keys)
+}
+
+// SetAfter is like `SetBefore`, but inserts the new key immediately after the value of `afterKey`
+func SetAfter(data []byte, value []byte, afterKey string, keys ...string) ([]byte, error) {
+	return setBeside(data, value, afterKey, true, keys)
+}
+
+// setBeside implements `SetBefore` and `SetAfter`
+func setBeside(data []byte, value []byte, sibling string, after bool, keys []string) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, KeyPathNotFoundError
+	}
+	if _, _, _, _, err := internalGet(data, keys...); err != KeyPathNotFoundError {
+		// the key exists, or the document is malformed; either way Set knows what to do
+		return Set(data, value, keys...)
+	}
+
+	ranges, err := ObjectRanges(data, keys[:len(keys)-1]...)
+	if err == KeyPathNotFoundError {
+		return Set(data, value, keys...)
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, r := range ranges {
+		key, err := ParseString(data[r.KeyStart+1 : r.KeyEnd-1])
+		if err != nil || key != sibling {
+			continue
+		}
+
+		entry := make([]byte, 0, len(keys[len(keys)-1])+len(value)+4)
+		insertAt := r.KeyStart
+		if after {
+			insertAt = r.ValueEnd
+			entry = append(entry, ',')
+		}
+		entry = append(entry, '"')
+		entry = append(entry, keys[len(keys)-1]...)
+		entry = append(entry, '"', ':')
+		entry = append(entry, value...)
+		if !after {
+			entry = append(entry, ',')
+		}
+
+		out := make([]byte, 0, len(data)+len(entry))
+		out = append(out, data[:insertAt]...)
+		out = append(out, entry...)
+		return append(out, data[insertAt:]...), nil
+	}
+	return Set(data, value, keys...)
+}
+
+// SetFold is like `Set`, but matches the last key of `keys` case-insensitively, using Unicode case folding as
+// strings.EqualFold does, so that setting `userId` updates an existing `userid` in place instead of adding a duplicate
+// key. The first entry which matches is updated; a new key is only added, with the casing given, if none does. The
+// keys leading to the parent object are matched exactly.
+func SetFold(data []byte, value []byte, keys ...string) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, KeyPathNotFoundError
+	}
+
+	last := keys[len(keys)-1]
+	var matched string
+	found := false
+	err := ObjectEach(data, func(key []byte, v []byte, dataType ValueType, offset int) error {
+		if strings.EqualFold(string(key), last) {
+			matched, found = string(key), true
+			return stopIteration
+		}
+		return nil
+	}, keys[:len(keys)-1]...)
+	if err != nil && err != stopIteration || !found {
+		// Let Set add the key, or report the problem with the document
+		return Set(data, value, keys...)
+	}
+
+	foldedKeys := append(keys[:len(keys)-1:len(keys)-1], matched)
+	return Set(data, value, foldedKeys...)
+}
+
+// SetFloat is like `Set`, setting `value` formatted with the shortest representation that round-trips (`'g'` format,
+// precision -1). Depending on the magnitude this may use scientific notation, e.g. `1e-05`; use `SetFloatFmt` to avoid it.
+func SetFloat(data []byte, value float64, keys ...string) ([]byte, error) {
+	return SetFloatFmt(data, value, 'g', -1, keys...)
+}
+
+// SetFloatFmt is like `Set`, setting `value` formatted by strconv.FormatFloat with the given format and precision.
+// Only the formats producing valid JSON numbers are accepted: 'e', 'E', 'f', 'g' and 'G'. Use 'f' to never get scientific
+// notation. NaN and infinities can't be represented in JSON and return an error.
+func SetFloatFmt(data []byte, value float64, format byte, prec int, keys ...string) ([]byte, error) {
+	switch format {
+	case 'e', 'E', 'f', 'g', 'G':
+	default:
+		return nil, errors.New("Float format must be one of 'e', 'E', 'f', 'g' or 'G'")
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, errors.New("Float value is not finite")
+	}
+	return Set(data, strconv.AppendFloat(nil, value, format, prec, 64), keys...)
+}
+
+// SetObject is like `Set`, but first checks that `obj` is a complete, valid JSON object, so a section of the document can
+// be replaced wholesale without risking to write a scalar or a broken value in its place.
+func SetObject(data []byte, obj []byte, keys ...string) ([]byte, error) {
+	return setContainer(data, obj, Object, keys...)
+}
+
+// SetArray is like `SetObject`, for arrays
+func SetArray(data []byte, arr []byte, keys ...string) ([]byte, error) {
+	return setContainer(data, arr, Array, keys...)
+}
+
+func setContainer(data []byte, setValue []byte, dataType ValueType, keys ...string) ([]byte, error) {
+	var v ValidateIncremental
+	v.Write(setValue)
+	if err := v.Done(); err != nil {
+		return nil, err
+	}
+	if t := Raw(setValue[nextToken(setValue):]).Type(); t != dataType {
+		return nil, fmt.Errorf("Value is not an %s: %s", dataType, string(setValue))
+	}
+	return Set(data, setValue, keys...)
 }
 
 func getType(data []byte, offset int) ([]byte, ValueType, int, error) {
@@ -947,74 +1426,392 @@ Returns:
 `value` - Pointer to original data structure containing key value, or just empty slice if nothing found or error
 `dataType` -    Can be: `NotExist`, `String`, `Number`, `Object`, `Array`, `Boolean` or `Null`
 `offset` - Offset from provided data structure where key value ends. Used mostly internally, for example for `ArrayEach` helper.
-`err` - If key not found or any other parsing issue it should return error. If key not found it also sets `dataType` to `NotExist`
+`err` - If key not found or any other parsing issue it should return error. If key not found it also sets `dataType` to `NotExist`.
+If keys are given but the root value is a number, string, boolean or null, it returns `NotTraversableError` instead of
+`KeyPathNotFoundError`; a scalar deeper in the path is still reported as not found, see `GetBytesForKeyPath`.
 
 Accept multiple keys to specify path to JSON value (in case of quering nested structures).
 If no keys provided it will try to extract closest JSON value (simple ones or object/array), useful for reading streams or arrays, see `ArrayEach` implementation.
+
+The returned value shares memory with `data`, so it is only valid as long as `data` is. In particular, a value taken
+from a memory-mapped file must not be used after the file is unmapped; see `GetDetached`.
 */
 func Get(data []byte, keys ...string) (value []byte, dataType ValueType, offset int, err error) {
 	a, b, _, d, e := internalGet(data, keys...)
+	if e == KeyPathNotFoundError && len(keys) > 0 {
+		if nT := nextToken(data); nT != -1 && data[nT] != '{' && data[nT] != '[' {
+			e = NotTraversableError
+		}
+	}
 	return a, b, d, e
 }
 
-func internalGet(data []byte, keys ...string) (value []byte, dataType ValueType, offset, endOffset int, err error) {
-	if len(keys) > 0 {
-		if offset = searchKeys(data, keys...); offset == -1 {
-			return nil, NotExist, -1, -1, KeyPathNotFoundError
+// GetWithKey is like `Get`, but also returns the key which matched the last path segment as it appears in the document:
+// without its quotes but still escaped, e.g. `some\u00B0key` for the key "some°key", so that it can be re-emitted
+// unchanged. For a path ending with an array index, or an empty path, `rawKey` is nil.
+func GetWithKey(data []byte, keys ...string) (rawKey []byte, value []byte, dataType ValueType, err error) {
+	value, dataType, start, _, err := internalGet(data, keys...)
+	if err != nil || len(keys) == 0 {
+		return nil, value, dataType, err
+	}
+
+	// The value of an object member follows the key and a colon, with optional whitespace in between
+	i := start - 1
+	for i >= 0 && isSpace(data[i]) {
+		i--
+	}
+	if i < 0 || data[i] != ':' {
+		return nil, value, dataType, nil
+	}
+	for i--; i >= 0 && isSpace(data[i]); i-- {
+	}
+	keyEnd := i
+
+	// The opening quote is the first one before the closing quote which isn't escaped
+	for i--; i >= 0; i-- {
+		if data[i] == '"' && quoteUnescaped(data, i) {
+			return data[i+1 : keyEnd : keyEnd], value, dataType, nil
 		}
 	}
+	return nil, value, dataType, nil
+}
 
-	// Go to closest value
-	nO := nextToken(data[offset:])
-	if nO == -1 {
-		return nil, NotExist, offset, -1, MalformedJsonError
+// GetWithParent is like `Get`, but also returns the object or array directly containing the value, e.g. `{"b":1}` for
+// the path `a.b` in `{"a":{"b":1}}`. The parent is looked up first and the last segment only within it, so this costs no
+// more than a single `Get`. For an empty path `parent` is nil.
+func GetWithParent(data []byte, keys ...string) (value []byte, parent []byte, dataType ValueType, err error) {
+	if len(keys) == 0 {
+		value, dataType, _, _, err = internalGet(data)
+		return value, nil, dataType, err
 	}
 
-	offset += nO
-	value, dataType, endOffset, err = getType(data, offset)
+	parent, parentType, _, _, err := internalGet(data, keys[:len(keys)-1]...)
 	if err != nil {
-		return value, dataType, offset, endOffset, err
+		return nil, nil, NotExist, err
+	}
+	if parentType != Object && parentType != Array {
+		return nil, nil, NotExist, KeyPathNotFoundError
 	}
 
-	// Strip quotes from string values
-	if dataType == String {
-		value = value[1 : len(value)-1]
+	value, dataType, _, _, err = internalGet(parent, keys[len(keys)-1])
+	if err != nil {
+		return nil, nil, dataType, err
 	}
+	return value, parent, dataType, nil
+}
 
-	return value[:len(value):len(value)], dataType, offset, endOffset, nil
+// GetRootStrict returns the root value of `data`, like `Get` without keys, and checks that only whitespace follows it,
+// returning TrailingDataError otherwise. This rejects payloads such as `{"a":1} garbage` or `1 2`, which the lookups ignore
+// past the value they need. The root value itself is not validated beyond what `Get` checks; use `ValidateIncremental`
+// for that.
+func GetRootStrict(data []byte) ([]byte, ValueType, error) {
+	value, dataType, offset, err := Get(data)
+	if err != nil {
+		return nil, dataType, err
+	}
+	if nextToken(data[offset:]) != -1 {
+		return nil, NotExist, TrailingDataError
+	}
+	return value, dataType, nil
 }
 
-// ArrayEach is used when iterating arrays, accepts a callback function with the same return arguments as `Get`.
-func ArrayEach(data []byte, cb func(value []byte, dataType ValueType, offset int, err error), keys ...string) (offset int, err error) {
-	if len(data) == 0 {
-		return -1, MalformedObjectError
+// TryGet is like `Get` for optional fields: instead of an error it reports whether the value was found, so a missing path
+// needs no comparison against `KeyPathNotFoundError`. Malformed data is reported as not found too; use `Get` where the
+// difference matters. When not found, `value` is nil and `dataType` is `NotExist`.
+func TryGet(data []byte, keys ...string) (value []byte, dataType ValueType, found bool) {
+	value, dataType, _, err := Get(data, keys...)
+	if err != nil {
+		return nil, NotExist, false
 	}
+	return value, dataType, true
+}
 
-	nT := nextToken(data)
-	if nT == -1 {
-		return -1, MalformedJsonError
+// Step looks up a single path segment in `data`, deciding by the type of `data` how to interpret it: as a key if `data` is an
+// object, or as an element index without brackets (e.g. `2`) if it is an array. This lets a generic tree walker descend
+// without tracking which kind of container it is in. The value is returned like `Get` returns it; a scalar `data` results
+// in PathTypeMismatchError.
+func Step(data []byte, segment string) (value []byte, dataType ValueType, err error) {
+	start := nextToken(data)
+	if start == -1 {
+		return nil, NotExist, EmptyInputError
+	}
+
+	switch data[start] {
+	case '{':
+		value, dataType, _, err = Get(data, segment)
+	case '[':
+		value, dataType, _, err = Get(data, "["+segment+"]")
+	default:
+		return nil, NotExist, PathTypeMismatchError
 	}
+	return value, dataType, err
+}
 
-	offset = nT + 1
+// HasKey reports whether the key path exists, whatever its value, e.g. for flags like `{"enabled":null}` where the mere
+// presence of the key matters. A path leading to a malformed value, or into malformed data, is reported as absent.
+func HasKey(data []byte, keys ...string) bool {
+	_, _, _, err := Get(data, keys...)
+	return err == nil
+}
 
-	if len(keys) > 0 {
-		if offset = searchKeys(data, keys...); offset == -1 {
-			return offset, KeyPathNotFoundError
-		}
+// SafeGet is like `Get`, but returns a copy of the value instead of a slice of `data`, so the result stays valid when `data`
+// is modified or reused (e.g. a pooled read buffer), and modifying it never corrupts `data`. The price is one allocation
+// per call; `Get` remains the zero-copy primitive for code which controls the lifetime of `data`.
+func SafeGet(data []byte, keys ...string) (value []byte, dataType ValueType, offset int, err error) {
+	value, dataType, offset, err = Get(data, keys...)
+	if value != nil {
+		value = append(make([]byte, 0, len(value)), value...)
+	}
+	return value, dataType, offset, err
+}
 
-		// Go to closest value
-		nO := nextToken(data[offset:])
-		if nO == -1 {
-			return offset, MalformedJsonError
-		}
+/*
 
-		offset += nO
+GetManyCopy - Receives data structure and several key paths, and returns a copy of the value at each path, like calling
+`SafeGet` once per path but with a single allocation for all the copied bytes.
 
-		if data[offset] != '[' {
-			return offset, MalformedArrayError
+All returned values are subslices of one shared buffer, which stays alive as long as any of them is referenced: retaining
+a single small value keeps the whole buffer from being collected. Each value is capped at its own length, so appending to
+it never overwrites its neighbours.
+
+Returns:
+`values` - Copy of the value at each path, in the order of `paths`, or nil for paths which don't exist
+`dataTypes` - Type of each value, `NotExist` for paths which don't exist
+`err` - The first error other than `KeyPathNotFoundError`, e.g. for malformed data; a missing path is not an error
+
+*/
+func GetManyCopy(data []byte, paths ...[]string) (values [][]byte, dataTypes []ValueType, err error) {
+	values = make([][]byte, len(paths))
+	dataTypes = make([]ValueType, len(paths))
+
+	size := 0
+	for i, path := range paths {
+		value, dataType, _, e := Get(data, path...)
+		if e != nil && e != KeyPathNotFoundError && e != NotTraversableError {
+			return nil, nil, e
 		}
+		values[i], dataTypes[i] = value, dataType
+		size += len(value)
+	}
 
-		offset++
+	buf := make([]byte, 0, size)
+	for i, value := range values {
+		if value != nil {
+			start := len(buf)
+			buf = append(buf, value...)
+			values[i] = buf[start:len(buf):len(buf)]
+		}
+	}
+	return values, dataTypes, nil
+}
+
+// GetLimited is like `Get`, but fails with `ValueTooLargeError` if the value is longer than `maxLen` bytes (for strings,
+// not counting the quotes), so that per-field memory stays bounded when handling untrusted input. The check happens
+// before the value is handed out, so an oversized value is never returned to be copied or processed further.
+func GetLimited(data []byte, maxLen int, keys ...string) ([]byte, ValueType, error) {
+	value, dataType, _, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, dataType, err
+	}
+	if len(value) > maxLen {
+		return nil, dataType, ValueTooLargeError
+	}
+	return value, dataType, nil
+}
+
+/*
+
+GetDetached - Receives data structure and key path like `Get`, and always returns a copy of the value, which shares no
+memory with `data`. It is meant for memory-mapped files, whose contents disappear when they are unmapped.
+
+Safety contract: `data` only has to be valid (mapped) while GetDetached runs. The returned value stays valid after `data`
+is unmapped, modified or reused, and modifying it never writes to `data`. Values returned by `Get` and the other
+zero-copy accessors give no such guarantee: reading one after unmapping the file crashes the program.
+
+*/
+func GetDetached(data []byte, keys ...string) ([]byte, ValueType, error) {
+	value, dataType, _, err := SafeGet(data, keys...)
+	return value, dataType, err
+}
+
+func internalGet(data []byte, keys ...string) (value []byte, dataType ValueType, offset, endOffset int, err error) {
+	if len(keys) > 0 {
+		if offset = searchKeys(data, keys...); offset == -1 {
+			if nextToken(data) == -1 {
+				return nil, NotExist, -1, -1, EmptyInputError
+			}
+			return nil, NotExist, -1, -1, KeyPathNotFoundError
+		}
+	}
+
+	// Go to closest value
+	nO := nextToken(data[offset:])
+	if nO == -1 {
+		if offset == 0 {
+			return nil, NotExist, offset, -1, EmptyInputError
+		}
+		return nil, NotExist, offset, -1, MalformedJsonError
+	}
+
+	offset += nO
+	value, dataType, endOffset, err = getType(data, offset)
+	if err != nil {
+		return value, dataType, offset, endOffset, err
+	}
+
+	// Strip quotes from string values
+	if dataType == String {
+		value = value[1 : len(value)-1]
+	}
+
+	return value[:len(value):len(value)], dataType, offset, endOffset, nil
+}
+
+// GetAt is like `Get`, but takes a path mixing object keys (`string`) and array indexes (`int`), e.g.
+// `GetAt(data, "users", 0, "name")` instead of `Get(data, "users", "[0]", "name")`. Other segment types are rejected.
+func GetAt(data []byte, path ...interface{}) ([]byte, ValueType, error) {
+	var keysBuf [8]string
+	keys := keysBuf[:0]
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			keys = append(keys, p)
+		case int:
+			keys = append(keys, "["+strconv.Itoa(p)+"]")
+		default:
+			return nil, NotExist, fmt.Errorf("Unsupported path segment type %T: use string keys and int indexes", p)
+		}
+	}
+
+	value, dataType, _, err := Get(data, keys...)
+	return value, dataType, err
+}
+
+// Raw is a JSON value exactly as it appears in the source document, including the quotes of string values.
+//
+// A Raw returned by `GetRaw` aliases the `data` it was extracted from: it is only valid as long as that buffer is neither
+// modified nor reused (e.g. returned to a pool or overwritten by the next read). Call `Clone` to obtain an independent copy
+// whenever the value has to outlive the source buffer.
+type Raw []byte
+
+// Clone returns a copy of the value which does not share memory with the source document
+func (r Raw) Clone() []byte {
+	if r == nil {
+		return nil
+	}
+	c := make([]byte, len(r))
+	copy(c, r)
+	return c
+}
+
+// Type classifies the value by its first byte
+func (r Raw) Type() ValueType {
+	if len(r) == 0 {
+		return NotExist
+	}
+	switch r[0] {
+	case '"':
+		return String
+	case '{':
+		return Object
+	case '[':
+		return Array
+	case 't', 'f':
+		return Boolean
+	case 'n':
+		return Null
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '-':
+		return Number
+	}
+	return Unknown
+}
+
+// GetRaw returns the value at the given key path, without stripping the quotes from strings.
+// The result aliases `data`; see `Raw` for the ownership rules.
+func GetRaw(data []byte, keys ...string) (Raw, error) {
+	_, _, offset, endOffset, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return Raw(data[offset:endOffset:endOffset]), nil
+}
+
+// GetBytesForKeyPath is like `Get`, but tells apart the reasons a key path can't be followed: it returns
+// `KeyPathNotFoundError` only if a key or array index doesn't exist, and `PathTypeMismatchError` if a value along the path
+// exists but can't be descended into with the next segment, e.g. `a.b` in `{"a":1}`, `a.[0]` in `{"a":{}}`, or any key
+// in a scalar root such as `5`.
+// Malformed JSON before the point of failure is reported as such. The extra checks only run when the lookup fails.
+func GetBytesForKeyPath(data []byte, keys ...string) (value []byte, dataType ValueType, err error) {
+	value, dataType, _, _, err = internalGet(data, keys...)
+	if err == KeyPathNotFoundError {
+		_, err = diagnoseKeyPath(data, keys)
+	}
+	return value, dataType, err
+}
+
+// GetExplain is like `GetBytesForKeyPath`, but also reports the index in `keys` of the segment which couldn't be resolved,
+// for error messages pointing at the wrong part of a path: for `a.[0].b` in `{"a":{"b":1}}` it returns 1 with
+// `PathTypeMismatchError`, as `a` is an object which can't be indexed. When the path is followed but its value is
+// malformed, the last segment is reported; `failedSegment` is -1 on success and when there are no keys.
+func GetExplain(data []byte, keys ...string) (value []byte, dataType ValueType, failedSegment int, err error) {
+	value, dataType, _, _, err = internalGet(data, keys...)
+	if err != nil {
+		var diagErr error
+		if failedSegment, diagErr = diagnoseKeyPath(data, keys); err == KeyPathNotFoundError {
+			err = diagErr
+		}
+		return value, dataType, failedSegment, err
+	}
+	return value, dataType, -1, nil
+}
+
+// diagnoseKeyPath finds out why `keys` can't be followed in `data` by looking up each prefix of the path in turn. It returns
+// the index of the first segment which can't be resolved, together with the reason.
+func diagnoseKeyPath(data []byte, keys []string) (int, error) {
+	for i := range keys {
+		_, t, _, _, err := internalGet(data, keys[:i]...)
+		if err != nil {
+			if i == 0 {
+				return 0, err
+			}
+			return i - 1, err
+		}
+
+		isIndex := len(keys[i]) > 0 && keys[i][0] == '['
+		if isIndex && t != Array || !isIndex && t != Object {
+			return i, PathTypeMismatchError
+		}
+	}
+	return len(keys) - 1, KeyPathNotFoundError
+}
+
+// ArrayEach is used when iterating arrays, accepts a callback function with the same return arguments as `Get`.
+func ArrayEach(data []byte, cb func(value []byte, dataType ValueType, offset int, err error), keys ...string) (offset int, err error) {
+	nT := nextToken(data)
+	if nT == -1 {
+		return -1, EmptyInputError
+	}
+
+	offset = nT + 1
+
+	if len(keys) > 0 {
+		if offset = searchKeys(data, keys...); offset == -1 {
+			return offset, KeyPathNotFoundError
+		}
+
+		// Go to closest value
+		nO := nextToken(data[offset:])
+		if nO == -1 {
+			return offset, MalformedJsonError
+		}
+
+		offset += nO
+
+		if data[offset] != '[' {
+			return offset, MalformedArrayError
+		}
+
+		offset++
 	}
 
 	nO := nextToken(data[offset:])
@@ -1069,114 +1866,1312 @@ func ArrayEach(data []byte, cb func(value []byte, dataType ValueType, offset int
 	return offset, nil
 }
 
-// ObjectEach iterates over the key-value pairs of a JSON object, invoking a given callback for each such entry
-func ObjectEach(data []byte, callback func(key []byte, value []byte, dataType ValueType, offset int) error, keys ...string) (err error) {
-	offset := 0
+// ArrayEachAbs is like `ArrayEach`, but the offset passed to `cb` is the position of the element's first byte in `data`
+// (the opening quote for strings), like with `ArrayEachFrom`, so it can be used to locate the element in the original
+// document however deeply the array is nested. It returns the offset of the array's closing bracket.
+func ArrayEachAbs(data []byte, cb func(value []byte, dataType ValueType, absOffset int, err error), keys ...string) (offset int, err error) {
+	_, t, start, end, err := internalGet(data, keys...)
+	if err != nil {
+		return start, err
+	}
+	if t != Array {
+		return start, MalformedArrayError
+	}
 
-	// Descend to the desired key, if requested
-	if len(keys) > 0 {
-		if off := searchKeys(data, keys...); off == -1 {
-			return KeyPathNotFoundError
-		} else {
-			offset = off
-		}
+	offset, err = ArrayEachFrom(data, start, func(value []byte, dataType ValueType, offset int) bool {
+		cb(value, dataType, offset, nil)
+		return true
+	})
+	if err != nil {
+		return offset, err
 	}
+	return end - 1, nil
+}
 
-	// Validate and skip past opening brace
-	if off := nextToken(data[offset:]); off == -1 {
-		return MalformedObjectError
-	} else if offset += off; data[offset] != '{' {
-		return MalformedObjectError
-	} else {
-		offset++
+// ArrayEachFrom iterates the JSON array in `data` like `ArrayEach`, but can be paused and resumed.
+// Iteration starts at `startOffset`, which must be either the offset of the array itself (e.g. 0) or an offset returned by a
+// previous call. When the callback returns false iteration stops right after that element, and the returned offset can be
+// passed back in to continue with the next element. Once the closing ']' has been consumed the returned offset points past it
+// and further calls with it are no-ops; when `data` holds just the array this means `nextOffset == len(data)`.
+// The offset passed to the callback is the position of the element's first byte in `data` (the opening quote for strings).
+func ArrayEachFrom(data []byte, startOffset int, cb func(value []byte, dataType ValueType, offset int) bool) (nextOffset int, err error) {
+	if startOffset < 0 || startOffset > len(data) {
+		return startOffset, MalformedArrayError
 	}
 
-	// Skip to the first token inside the object, or stop if we find the ending brace
-	if off := nextToken(data[offset:]); off == -1 {
-		return MalformedJsonError
-	} else if offset += off; data[offset] == '}' {
-		return nil
+	offset := startOffset
+	nT := nextToken(data[offset:])
+	if nT == -1 {
+		// Nothing left: the array was already consumed
+		return startOffset, nil
 	}
+	offset += nT
 
-	// Loop pre-condition: data[offset] points to what should be either the next entry's key, or the closing brace (if it's anything else, the JSON is malformed)
-	for offset < len(data) {
-		// Step 1: find the next key
-		var key []byte
+	switch data[offset] {
+	case '[':
+		offset++
+		nO := nextToken(data[offset:])
+		if nO == -1 {
+			return offset, MalformedArrayError
+		}
+		offset += nO
+		if data[offset] == ']' {
+			return offset + 1, nil
+		}
+	case ',':
+		offset++
+	default:
+		return startOffset, MalformedArrayError
+	}
+
+	for {
+		nO := nextToken(data[offset:])
+		if nO == -1 {
+			return offset, MalformedArrayError
+		}
+		offset += nO
+
+		v, t, o, e := Get(data[offset:])
+		if e != nil {
+			return offset, e
+		}
+
+		cont := cb(v, t, offset)
+		offset += o
+
+		skipToToken := nextToken(data[offset:])
+		if skipToToken == -1 {
+			return offset, MalformedArrayError
+		}
+		offset += skipToToken
 
-		// Check what the the next token is: start of string, end of object, or something else (error)
 		switch data[offset] {
-		case '"':
-			offset++ // accept as string and skip opening quote
-		case '}':
-			return nil // we found the end of the object; stop and return success
+		case ']':
+			return offset + 1, nil
+		case ',':
+			if !cont {
+				return offset, nil
+			}
+			offset++
 		default:
-			return MalformedObjectError
+			return offset, MalformedArrayError
+		}
+	}
+}
+
+// ArrayOffsets returns the offset of the first byte of every element of the array at the given key path (the opening quote
+// for strings). Offsets are relative to `data` itself, not to the array, so any element can later be read directly
+// with `Get(data[offsets[i]:])` without scanning the array again.
+func ArrayOffsets(data []byte, keys ...string) ([]int, error) {
+	_, t, offset, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if t != Array {
+		return nil, MalformedArrayError
+	}
+
+	offsets := []int{}
+	_, err = ArrayEachFrom(data, offset, func(value []byte, dataType ValueType, off int) bool {
+		offsets = append(offsets, off)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+// ArrayLen returns the number of elements of the array at the given key path, e.g. to preallocate a slice before
+// `ArrayEach`. Only the top-level commas are counted, skipping strings and nested objects and arrays as a whole, so it is
+// much cheaper than iterating, but the elements themselves are not validated.
+func ArrayLen(data []byte, keys ...string) (int, error) {
+	v, t, _, err := Get(data, keys...)
+	if err != nil {
+		return 0, err
+	}
+	if t != Array {
+		return 0, MalformedArrayError
+	}
+
+	inner := v[1 : len(v)-1]
+	if nextToken(inner) == -1 {
+		return 0, nil
+	}
+
+	n := 1
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '"':
+			se, _ := stringEnd(inner[i+1:])
+			if se == -1 {
+				return 0, MalformedStringError
+			}
+			i += se
+		case '[', '{':
+			closing := byte(']')
+			if inner[i] == '{' {
+				closing = '}'
+			}
+			be := blockEnd(inner[i:], inner[i], closing)
+			if be == -1 {
+				return 0, MalformedArrayError
+			}
+			i += be - 1
+		case ',':
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ConcatArrays returns a single array holding the elements of all the given arrays in order, e.g. to stitch together pages
+// of API results. Empty arrays contribute nothing, and no arguments give `[]`. Each argument must be a single array, with
+// nothing but whitespace around it; the elements are copied as they are, without being validated.
+func ConcatArrays(arrays ...[]byte) ([]byte, error) {
+	size := 2
+	for _, arr := range arrays {
+		size += len(arr) + 1
+	}
+
+	out := make([]byte, 0, size)
+	out = append(out, '[')
+	for i, arr := range arrays {
+		v, t, err := GetRootStrict(arr)
+		if err != nil {
+			return nil, err
+		}
+		if t != Array {
+			return nil, fmt.Errorf("Argument %d is not an array: %s", i, t)
+		}
+
+		elements := bytes.TrimSpace(v[1 : len(v)-1])
+		if len(elements) == 0 {
+			continue
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(out, elements...)
+	}
+	return append(out, ']'), nil
+}
+
+// StringArrayLen is like `ArrayLen`, but also checks that every element is a String, so that a subsequent extraction of
+// the strings can't fail on a type mismatch. Unlike `ArrayLen` it steps through every element, which validates them.
+func StringArrayLen(data []byte, keys ...string) (int, error) {
+	v, t, _, err := Get(data, keys...)
+	if err != nil {
+		return 0, err
+	}
+	if t != Array {
+		return 0, MalformedArrayError
+	}
+
+	n := 0
+	var typeErr error
+	_, err = ArrayEach(v, func(value []byte, dataType ValueType, offset int, e error) {
+		if typeErr == nil && dataType != String {
+			typeErr = fmt.Errorf("Array element %d is not a string: %s", n, value)
+		}
+		n++
+	})
+	if err == nil {
+		err = typeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+/*
+
+ArrayMap - Receives data structure, a function and a key path to an array, and returns a new document in which every
+element of that array is replaced by what `fn` returns for it, like `ObjectMap` does for objects. The replacement has the
+same form as the value passed to `fn`: string contents for String elements, raw JSON otherwise, so returning the value
+unchanged keeps the element.
+
+Returning nil drops the element, together with the separator before it (or after it, for the first element). An empty
+but non-nil result is not the same thing: for a String element it produces an empty string.
+
+*/
+func ArrayMap(data []byte, fn func(index int, value []byte, dataType ValueType) ([]byte, error), keys ...string) ([]byte, error) {
+	_, t, start, end, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if t != Array {
+		return nil, MalformedArrayError
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:start+1]...)
+
+	var index, kept, firstStart int
+	var fnErr error
+	prevEnd := start + 1 // end of the previous element, kept or not
+	next, err := ArrayEachFrom(data, start, func(value []byte, dataType ValueType, offset int) bool {
+		elemEnd := offset + len(value)
+		if dataType == String {
+			elemEnd += 2
+		}
+		if index == 0 {
+			firstStart = offset
+		}
+
+		res, e := fn(index, value, dataType)
+		if e != nil {
+			fnErr = e
+			return false
+		}
+		if res != nil {
+			// The first element kept takes the whitespace before the original first element, the others the separator
+			// which preceded them
+			if kept == 0 {
+				out = append(out, data[start+1:firstStart]...)
+			} else {
+				out = append(out, data[prevEnd:offset]...)
+			}
+			if dataType == String {
+				out = append(out, '"')
+				out = append(out, res...)
+				out = append(out, '"')
+			} else {
+				out = append(out, res...)
+			}
+			kept++
+		}
+
+		prevEnd = elemEnd
+		index++
+		return true
+	})
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if next != end {
+		// The elements don't end where the array does, so its nesting is broken
+		return nil, MalformedArrayError
+	}
+	return append(append(out, data[prevEnd:end]...), data[end:]...), nil
+}
+
+// ObjectEach iterates over the key-value pairs of a JSON object, invoking a given callback for each such entry
+func ObjectEach(data []byte, callback func(key []byte, value []byte, dataType ValueType, offset int) error, keys ...string) (err error) {
+	return objectEach(data, callback, false, nil, keys...)
+}
+
+// ObjectEachValidated is like `ObjectEach`, but additionally verifies that every (unescaped) key is valid UTF-8, stopping with
+// an error reporting the offset of the offending key otherwise. `ObjectEach` itself does not check key encoding.
+func ObjectEachValidated(data []byte, callback func(key []byte, value []byte, dataType ValueType, offset int) error, keys ...string) (err error) {
+	return objectEach(data, callback, true, nil, keys...)
+}
+
+// ObjectEachIndexed is like `ObjectEach`, but also passes the 0-based index of every entry in document order, e.g. to
+// correlate entries with positional metadata
+func ObjectEachIndexed(data []byte, callback func(index int, key []byte, value []byte, dataType ValueType) error, keys ...string) (err error) {
+	index := 0
+	return objectEach(data, func(key []byte, value []byte, dataType ValueType, offset int) error {
+		err := callback(index, key, value, dataType)
+		index++
+		return err
+	}, false, nil, keys...)
+}
+
+// ObjectEachRange is like `ObjectEach`, but instead of the value passes the range `[valueStart, valueEnd)` it occupies in
+// `data`, e.g. to map entries back to their position in the document. For strings the range includes the quotes.
+func ObjectEachRange(data []byte, callback func(key []byte, valueStart, valueEnd int, dataType ValueType) error, keys ...string) (err error) {
+	return objectEach(data, func(key []byte, value []byte, dataType ValueType, offset int) error {
+		start := offset - len(value)
+		if dataType == String {
+			start -= 2
+		}
+		return callback(key, start, offset, dataType)
+	}, false, nil, keys...)
+}
+
+// KeyValue is an object entry returned by `Entries`
+type KeyValue struct {
+	Key   string // unescaped
+	Value []byte // as passed by `ObjectEach`: a slice of the document, string contents without their quotes
+	Type  ValueType
+}
+
+// Entries returns the entries of the object at the given path in document order, for callers who prefer a slice over
+// the callback of `ObjectEach`. Keys are copied, values are not. Returns `MalformedObjectError` if the value isn't an
+// object.
+func Entries(data []byte, keys ...string) ([]KeyValue, error) {
+	var entries []KeyValue
+	err := objectEach(data, func(key []byte, value []byte, dataType ValueType, offset int) error {
+		entries = append(entries, KeyValue{Key: string(key), Value: value, Type: dataType})
+		return nil
+	}, false, nil, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// EntryRange is the position of an object entry in the document, see `ObjectRanges`
+type EntryRange struct {
+	KeyStart, KeyEnd     int // range of the key, including its quotes
+	ValueStart, ValueEnd int // range of the value, including the quotes of strings
+	Type                 ValueType
+}
+
+// ObjectRanges returns the position of every entry of the object at the given key path, in document order, as ranges
+// `[start, end)` into `data`, e.g. to build a map of a configuration file for an editor in one pass.
+func ObjectRanges(data []byte, keys ...string) ([]EntryRange, error) {
+	_, t, start, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if t != Object {
+		return nil, MalformedObjectError
+	}
+
+	var ranges []EntryRange
+	pos := start + 1 // where to look for the next key: after the opening brace, then after the previous value
+	err = ObjectEachRange(data[start:], func(key []byte, valueStart, valueEnd int, dataType ValueType) error {
+		// Entries are reported in order, so the key is the first string after the previous value and its comma
+		keyStart := pos + nextToken(data[pos:])
+		if data[keyStart] == ',' {
+			keyStart++
+			keyStart += nextToken(data[keyStart:])
+		}
+		keyLen, _ := stringEnd(data[keyStart+1:])
+
+		ranges = append(ranges, EntryRange{
+			KeyStart:   keyStart,
+			KeyEnd:     keyStart + 1 + keyLen,
+			ValueStart: start + valueStart,
+			ValueEnd:   start + valueEnd,
+			Type:       dataType,
+		})
+		pos = start + valueEnd
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// GetNextSibling returns the object entry following `afterOffset`, which is either the offset just past a value inside
+// an object, such as the offset returned by `Get`, or the offset just past the opening brace, for the first entry. The
+// key is unescaped like with `ObjectEach`, and `nextOffset` is the offset just past the value, to pass to the next call,
+// so an object can be walked entry by entry in a single pass. At the end of the object `KeyPathNotFoundError` is
+// returned.
+func GetNextSibling(data []byte, afterOffset int) (key []byte, value []byte, dataType ValueType, nextOffset int, err error) {
+	if afterOffset < 0 || afterOffset > len(data) {
+		return nil, nil, NotExist, -1, KeyPathNotFoundError
+	}
+
+	offset := afterOffset
+	if off := nextToken(data[offset:]); off == -1 {
+		return nil, nil, NotExist, -1, MalformedObjectError
+	} else {
+		offset += off
+	}
+	switch data[offset] {
+	case '}':
+		return nil, nil, NotExist, -1, KeyPathNotFoundError
+	case ',':
+		offset++
+		if off := nextToken(data[offset:]); off == -1 || data[offset+off] != '"' {
+			return nil, nil, NotExist, -1, MalformedObjectError
+		} else {
+			offset += off
+		}
+	case '"':
+		// Only the first entry isn't preceded by a comma
+		if prev := lastToken(data[:afterOffset]); prev == -1 || data[prev] != '{' {
+			return nil, nil, NotExist, -1, MalformedObjectError
+		}
+	default:
+		return nil, nil, NotExist, -1, MalformedObjectError
+	}
+
+	// Read the key
+	keyEnd, keyEscaped := stringEnd(data[offset+1:])
+	if keyEnd == -1 {
+		return nil, nil, NotExist, -1, MalformedStringError
+	}
+	key = data[offset+1 : offset+keyEnd]
+	if keyEscaped {
+		if key, err = Unescape(key, nil); err != nil {
+			return nil, nil, NotExist, -1, MalformedStringEscapeError
+		}
+	}
+	offset += keyEnd + 1
+
+	// Step over the colon
+	if off := nextToken(data[offset:]); off == -1 || data[offset+off] != ':' {
+		return nil, nil, NotExist, -1, MalformedObjectError
+	} else {
+		offset += off + 1
+	}
+
+	value, dataType, _, end, err := internalGet(data[offset:])
+	if err != nil {
+		return nil, nil, dataType, -1, err
+	}
+	return key, value, dataType, offset + end, nil
+}
+
+// ObjectEachKeys is like `ObjectEach`, but only invokes `cb` for the keys listed in `wanted`. The values of other keys are
+// skipped by finding their end only, without checking them, and their keys are compared as they appear in the document
+// unless they contain escape sequences.
+func ObjectEachKeys(data []byte, wanted []string, cb func(key, value []byte, dataType ValueType) error, keys ...string) error {
+	if wanted == nil {
+		wanted = []string{}
+	}
+	return objectEach(data, func(key []byte, value []byte, dataType ValueType, offset int) error {
+		return cb(key, value, dataType)
+	}, false, wanted, keys...)
+}
+
+/*
+
+ObjectMap - Receives data structure, a function and a key path to an object, and returns a new document in which every
+value of that object is replaced by what `fn` returns for it. `data` itself is never modified, so `fn` can safely look at
+values while the new document is being built.
+
+`fn` receives the value like `ObjectEach` passes it, and returns the replacement in the same form: the contents of a
+string (escaped, without the quotes) for String values, raw JSON for any other type. Returning the value unchanged
+therefore leaves it as is. Everything outside the replaced values, including whitespace, is copied verbatim.
+
+*/
+func ObjectMap(data []byte, fn func(key, value []byte, dataType ValueType) ([]byte, error), keys ...string) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	last := 0 // everything in data before this offset has been copied
+	err := ObjectEachRange(data, func(key []byte, valueStart, valueEnd int, dataType ValueType) error {
+		value := data[valueStart:valueEnd]
+		if dataType == String {
+			value = value[1 : len(value)-1]
+		}
+		res, err := fn(key, value[:len(value):len(value)], dataType)
+		if err != nil {
+			return err
+		}
+
+		out = append(out, data[last:valueStart]...)
+		if dataType == String {
+			out = append(out, '"')
+			out = append(out, res...)
+			out = append(out, '"')
+		} else {
+			out = append(out, res...)
+		}
+		last = valueEnd
+		return nil
+	}, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, data[last:]...), nil
+}
+
+// objectEach implements `ObjectEach` and its variants. Unless `wanted` is nil, only the keys it lists are passed to `callback`.
+func objectEach(data []byte, callback func(key []byte, value []byte, dataType ValueType, offset int) error, validateKeys bool, wanted []string, keys ...string) (err error) {
+	offset := 0
+
+	if nextToken(data) == -1 {
+		return EmptyInputError
+	}
+
+	// Descend to the desired key, if requested
+	if len(keys) > 0 {
+		if off := searchKeys(data, keys...); off == -1 {
+			return KeyPathNotFoundError
+		} else {
+			offset = off
+		}
+	}
+
+	// Validate and skip past opening brace
+	if off := nextToken(data[offset:]); off == -1 {
+		return MalformedObjectError
+	} else if offset += off; data[offset] != '{' {
+		return MalformedObjectError
+	} else {
+		offset++
+	}
+
+	// Skip to the first token inside the object, or stop if we find the ending brace
+	if off := nextToken(data[offset:]); off == -1 {
+		return MalformedJsonError
+	} else if offset += off; data[offset] == '}' {
+		return nil
+	}
+
+	// Loop pre-condition: data[offset] points to what should be either the next entry's key, or the closing brace (if it's anything else, the JSON is malformed)
+	for offset < len(data) {
+		// Step 1: find the next key
+		var key []byte
+
+		// Check what the the next token is: start of string, end of object, or something else (error)
+		switch data[offset] {
+		case '"':
+			offset++ // accept as string and skip opening quote
+		case '}':
+			return nil // we found the end of the object; stop and return success
+		default:
+			return MalformedObjectError
+		}
+
+		// Find the end of the key string
+		keyStart := offset - 1
+		var keyEscaped bool
+		if off, esc := stringEnd(data[offset:]); off == -1 {
+			return MalformedJsonError
+		} else {
+			key, keyEscaped = data[offset:offset+off-1], esc
+			offset += off
+		}
+
+		// Unescape the string if needed
+		if keyEscaped {
+			var stackbuf [unescapeStackBufSize]byte // stack-allocated array for allocation-free unescaping of small strings
+			if keyUnescaped, err := Unescape(key, stackbuf[:]); err != nil {
+				return MalformedStringEscapeError
+			} else {
+				key = keyUnescaped
+			}
+		}
+
+		if validateKeys && !utf8.Valid(key) {
+			return fmt.Errorf("Object key at offset %d is not valid UTF-8", keyStart)
+		}
+
+		// Step 2: skip the colon
+		if off := nextToken(data[offset:]); off == -1 {
+			return MalformedJsonError
+		} else if offset += off; data[offset] != ':' {
+			return MalformedJsonError
+		} else {
+			offset++
+		}
+
+		// Step 3: find the associated value, then invoke the callback
+		if wanted != nil && !containsKey(wanted, key) {
+			if off := skipValue(data[offset:]); off == -1 {
+				return MalformedJsonError
+			} else {
+				offset += off
+			}
+		} else if value, valueType, off, err := Get(data[offset:]); err != nil {
+			return err
+		} else if err := callback(key, value, valueType, offset+off); err != nil { // Invoke the callback here!
+			return err
+		} else {
+			offset += off
+		}
+
+		// Step 4: skip over the next comma to the following token, or stop if we hit the ending brace
+		if off := nextToken(data[offset:]); off == -1 {
+			return MalformedArrayError
+		} else {
+			offset += off
+			switch data[offset] {
+			case '}':
+				return nil // Stop if we hit the close brace
+			case ',':
+				offset++ // Ignore the comma
+			default:
+				return MalformedObjectError
+			}
+		}
+
+		// Skip to the next token after the comma
+		if off := nextToken(data[offset:]); off == -1 {
+			return MalformedArrayError
+		} else {
+			offset += off
+		}
+	}
+
+	return MalformedObjectError // we shouldn't get here; it's expected that we will return via finding the ending brace
+}
+
+// appendCompact appends `src` to `dst` with all insignificant whitespace (outside of strings) removed
+func appendCompact(dst, src []byte) []byte {
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; c {
+		case ' ', '\n', '\r', '\t':
+			continue
+		case '"':
+			se, _ := stringEnd(src[i+1:])
+			if se == -1 {
+				return append(dst, src[i:]...)
+			}
+			dst = append(dst, src[i:i+se+1]...)
+			i += se
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+// EqualValue reports whether the value at the given key path equals `expected`, ignoring insignificant whitespace.
+// Both sides are compared in their compacted form, so `{"c":"d" }` equals `{"c": "d"}`, while key order and number
+// formatting still matter. String values are compared including their quotes, so `expected` must be valid JSON.
+// This is mostly useful for assertions in tests.
+func EqualValue(data []byte, expected []byte, keys ...string) (bool, error) {
+	_, _, offset, endOffset, err := internalGet(data, keys...)
+	if err != nil {
+		return false, err
+	}
+
+	var stackbuf [unescapeStackBufSize]byte
+	a := appendCompact(stackbuf[:0], data[offset:endOffset])
+	b := appendCompact(nil, expected)
+	return bytes.Equal(a, b), nil
+}
+
+/*
+
+NormalizeNumbers - Receives a JSON document and rewrites every number in it to a canonical spelling, leaving all other
+bytes (including whitespace and key order) untouched. This makes documents comparable when producers only differ in
+how they format numbers; unlike a full canonicalization, the value of a number is never reformatted, so `100` and `1e2`
+stay different.
+
+The rules are:
+- leading zeros of the integer part are removed: `007` becomes `7`
+- trailing zeros of the fraction are removed, and so is the decimal point if nothing is left: `1.50` becomes `1.5`, `1.0` becomes `1`
+- the exponent is written with a lowercase `e`, without a `+` sign or leading zeros, and dropped if it is zero: `1E+05` becomes `1e5`, `2e-0` becomes `2`
+- zero is always written as `0`, whatever its sign, fraction or exponent: `-0.0e7` becomes `0`
+
+Returns:
+`value` - A new document with normalized numbers
+`err` - `MalformedValueError` if a number is malformed (e.g. `1.`, `-` or `1e`), or `MalformedStringError` for an unterminated string
+
+*/
+func NormalizeNumbers(data []byte) (value []byte, err error) {
+	value = make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '"':
+			se, _ := stringEnd(data[i+1:])
+			if se == -1 {
+				return nil, MalformedStringError
+			}
+			value = append(value, data[i:i+se+1]...)
+			i += se
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(data) && strings.IndexByte("0123456789+-.eE", data[end]) != -1 {
+				end++
+			}
+			if value, err = appendNormalizedNumber(value, data[i:end]); err != nil {
+				return nil, err
+			}
+			i = end - 1
+		default:
+			value = append(value, c)
+		}
+	}
+	return value, nil
+}
+
+// appendNormalizedNumber appends the canonical spelling of the number `b` to `dst`, see `NormalizeNumbers`
+func appendNormalizedNumber(dst []byte, b []byte) ([]byte, error) {
+	neg := len(b) > 0 && b[0] == '-'
+	if neg {
+		b = b[1:]
+	}
+
+	// Split into integer part, fraction and exponent
+	intEnd := 0
+	for intEnd < len(b) && b[intEnd] >= '0' && b[intEnd] <= '9' {
+		intEnd++
+	}
+	intPart, rest := b[:intEnd], b[intEnd:]
+	var frac, exp []byte
+	if len(rest) > 0 && rest[0] == '.' {
+		fracEnd := 1
+		for fracEnd < len(rest) && rest[fracEnd] >= '0' && rest[fracEnd] <= '9' {
+			fracEnd++
+		}
+		frac, rest = rest[1:fracEnd], rest[fracEnd:]
+		if len(frac) == 0 {
+			return nil, MalformedValueError
+		}
+	}
+	expNeg := false
+	if len(rest) > 0 && (rest[0] == 'e' || rest[0] == 'E') {
+		exp = rest[1:]
+		if len(exp) > 0 && (exp[0] == '+' || exp[0] == '-') {
+			expNeg = exp[0] == '-'
+			exp = exp[1:]
+		}
+		if len(exp) == 0 {
+			return nil, MalformedValueError
+		}
+		rest = nil
+	}
+	if len(intPart) == 0 || len(rest) > 0 {
+		return nil, MalformedValueError
+	}
+	for _, c := range exp {
+		if c < '0' || c > '9' {
+			return nil, MalformedValueError
+		}
+	}
+
+	intPart = bytes.TrimLeft(intPart, "0")
+	frac = bytes.TrimRight(frac, "0")
+	exp = bytes.TrimLeft(exp, "0")
+
+	if len(intPart) == 0 && len(frac) == 0 {
+		return append(dst, '0'), nil
+	}
+
+	if neg {
+		dst = append(dst, '-')
+	}
+	if len(intPart) == 0 {
+		dst = append(dst, '0')
+	} else {
+		dst = append(dst, intPart...)
+	}
+	if len(frac) > 0 {
+		dst = append(append(dst, '.'), frac...)
+	}
+	if len(exp) > 0 {
+		dst = append(dst, 'e')
+		if expNeg {
+			dst = append(dst, '-')
+		}
+		dst = append(dst, exp...)
+	}
+	return dst, nil
+}
+
+// FindAll searches the whole document for object keys equal to `key`, at any depth, invoking `cb` with the path to every
+// match (array elements appear as `[index]` segments) and its value. Matches are reported in document order; a match
+// nested inside the value of another match is reported after it. The path slice is reused between calls, so copy it if
+// it has to be kept.
+// Unlike `Get`, which only looks at the keys along one path, this traverses every object and array in the document.
+func FindAll(data []byte, key string, cb func(path []string, value []byte, dataType ValueType)) error {
+	value, dataType, _, err := Get(data)
+	if err != nil {
+		return err
+	}
+	return findAll(value, dataType, key, make([]string, 0, 8), func(path []string, value []byte, dataType ValueType) bool {
+		cb(path, value, dataType)
+		return false
+	})
+}
+
+/*
+
+GetDescendant - Receives data structure, a key and a key path, and returns the first value of an object member called `key`
+found at any depth below the value at the key path, like the `..key` operator of JSONPath. The search uses the same
+traversal as `FindAll` and stops at the first match in document order, so a match nested inside an earlier sibling wins
+over a shallower one which comes later.
+
+Returns:
+`value` - The value found, like `Get` returns it
+`dataType` - Type of `value`
+`err` - `KeyPathNotFoundError` if there is no such member, and any error `Get` returns for the key path
+
+*/
+func GetDescendant(data []byte, key string, keys ...string) (value []byte, dataType ValueType, err error) {
+	v, t, _, err := Get(data, keys...)
+	if err != nil {
+		return nil, NotExist, err
+	}
+
+	dataType = NotExist
+	err = findAll(v, t, key, make([]string, 0, 8), func(path []string, v []byte, t ValueType) bool {
+		value, dataType = v, t
+		return true
+	})
+	if err != nil && err != errFindStop {
+		return nil, NotExist, err
+	}
+	if dataType == NotExist {
+		return nil, NotExist, KeyPathNotFoundError
+	}
+	return value, dataType, nil
+}
+
+// errFindStop is returned by findAll when its callback asks to stop
+var errFindStop = errors.New("search stopped")
+
+// findAll implements `FindAll`; `cb` returns true to stop the search, which then fails with errFindStop.
+// It doesn't recurse, so that deeply nested input can't exhaust the goroutine stack: the values still to be visited are
+// kept on an explicit stack instead, in reverse document order.
+func findAll(data []byte, dataType ValueType, key string, path []string, cb func(path []string, value []byte, dataType ValueType) bool) error {
+	type pending struct {
+		value    []byte
+		dataType ValueType
+		depth    int    // length of the path to the value's parent, -1 for the root
+		segment  string // last segment of the path to the value
+		match    bool
+	}
+
+	base := len(path)
+	stack := []pending{{value: data, dataType: dataType, depth: -1}}
+	var children []pending
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// Values are visited depth-first, so the path to the parent is still at the beginning of `path`
+		if n.depth >= 0 {
+			path = append(path[:base+n.depth], n.segment)
+			if n.match && cb(path, n.value, n.dataType) {
+				return errFindStop
+			}
+		}
+		depth := len(path) - base
+		if depth >= MaxNestingDepth && (n.dataType == Object || n.dataType == Array) {
+			return MaxDepthExceededError
+		}
+
+		children = children[:0]
+		switch n.dataType {
+		case Object:
+			err := ObjectEach(n.value, func(k []byte, v []byte, vt ValueType, offset int) error {
+				if match := bytesToString(&k) == key; match || vt == Object || vt == Array {
+					children = append(children, pending{value: v, dataType: vt, depth: depth, segment: string(k), match: match})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case Array:
+			var i int
+			_, err := ArrayEach(n.value, func(v []byte, vt ValueType, offset int, err error) {
+				if vt == Object || vt == Array {
+					children = append(children, pending{value: v, dataType: vt, depth: depth, segment: "[" + strconv.Itoa(i) + "]"})
+				}
+				i++
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the value at the given key path is empty, for deciding whether to apply a default: `""`, `[]`,
+// `{}` (whitespace inside the brackets doesn't matter) and `null` are always empty. With `zeroIsEmpty`, so are `false` and
+// numbers equal to zero in any spelling, such as `0`, `-0.0` or `0e5`. Any other value is not empty. A path which doesn't
+// exist is an error, as with `Get`.
+func IsEmpty(data []byte, zeroIsEmpty bool, keys ...string) (bool, error) {
+	v, t, _, err := Get(data, keys...)
+	if err != nil {
+		return false, err
+	}
+
+	switch t {
+	case String:
+		return len(v) == 0, nil
+	case Object, Array:
+		return nextToken(v[1:len(v)-1]) == -1, nil
+	case Null:
+		return true, nil
+	case Boolean:
+		return zeroIsEmpty && v[0] == 'f', nil
+	case Number:
+		if !zeroIsEmpty {
+			return false, nil
+		}
+		for _, c := range v {
+			if c == 'e' || c == 'E' {
+				break
+			}
+			if c >= '1' && c <= '9' {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Depth returns the maximum nesting depth of the value at the given key path: scalars have depth 0, `{"a":1}` and `[1]`
+// have depth 1, `{"a":{"b":1}}` and `[[1]]` have depth 2 and so on. Objects and arrays count the same.
+// It makes a single non-recursive pass, so it's safe to use on untrusted input before processing it further.
+func Depth(data []byte, keys ...string) (int, error) {
+	v, t, _, err := Get(data, keys...)
+	if err != nil {
+		return 0, err
+	}
+	if t != Object && t != Array {
+		return 0, nil
+	}
+
+	var level, maxLevel int
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '"':
+			se, _ := stringEnd(v[i+1:])
+			if se == -1 {
+				return 0, MalformedStringError
+			}
+			i += se
+		case '{', '[':
+			level++
+			if level > maxLevel {
+				maxLevel = level
+			}
+		case '}', ']':
+			level--
+		}
+	}
+
+	return maxLevel, nil
+}
+
+// ParseStats describes the composition of a document, see `Stats`
+type ParseStats struct {
+	Objects, Arrays int
+	Keys            int // object keys, which don't count as Strings
+	Strings         int
+	Numbers         int
+	Booleans        int
+	Nulls           int
+	MaxDepth        int // nesting depth, as returned by `Depth`
+}
+
+// Stats counts the values of every type in the document and measures its depth in a single pass without allocating,
+// e.g. to characterize incoming payloads before setting limits. Strings must be terminated and brackets balanced, but
+// the document isn't validated otherwise; use `ValidateIncremental` for that.
+func Stats(data []byte) (ParseStats, error) {
+	var st ParseStats
+	if nextToken(data) == -1 {
+		return st, EmptyInputError
+	}
+
+	var level int
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			se, _ := stringEnd(data[i+1:])
+			if se == -1 {
+				return ParseStats{}, MalformedStringError
+			}
+			i += se
+			if next := nextToken(data[i+1:]); next != -1 && data[i+1+next] == ':' {
+				st.Keys++
+			} else {
+				st.Strings++
+			}
+		case '{', '[':
+			if data[i] == '{' {
+				st.Objects++
+			} else {
+				st.Arrays++
+			}
+			level++
+			if level > st.MaxDepth {
+				st.MaxDepth = level
+			}
+		case '}', ']':
+			if level--; level < 0 {
+				return ParseStats{}, MalformedJsonError
+			}
+		case 't', 'f':
+			st.Booleans++
+			i += tokenEnd(data[i:]) - 1
+		case 'n':
+			st.Nulls++
+			i += tokenEnd(data[i:]) - 1
+		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			st.Numbers++
+			i += tokenEnd(data[i:]) - 1
+		}
+	}
+	if level != 0 {
+		return ParseStats{}, MalformedJsonError
+	}
+	return st, nil
+}
+
+// GetNth returns the value of the n-th (0-based) occurrence of the last key in `keys` within its enclosing object.
+// JSON objects should not contain duplicate keys, but some producers emit them, e.g. `{"a":1,"a":2,"a":3}`; `Get` always
+// returns the first one. If there are not enough occurrences, `KeyPathNotFoundError` is returned.
+func GetNth(data []byte, n int, keys ...string) (value []byte, dataType ValueType, err error) {
+	if len(keys) == 0 || n < 0 {
+		return nil, NotExist, KeyPathNotFoundError
+	}
+
+	last := keys[len(keys)-1]
+	if len(last) > 0 && last[0] == '[' {
+		// Array elements can't repeat
+		if n > 0 {
+			return nil, NotExist, KeyPathNotFoundError
+		}
+		value, dataType, _, err = Get(data, keys...)
+		return value, dataType, err
+	}
+
+	var seen int
+	err = ObjectEach(data, func(key []byte, v []byte, vt ValueType, offset int) error {
+		if bytesToString(&key) != last {
+			return nil
+		}
+		if seen == n {
+			value, dataType = v, vt
+			return stopIteration
+		}
+		seen++
+		return nil
+	}, keys[:len(keys)-1]...)
+
+	if err == stopIteration {
+		return value, dataType, nil
+	}
+	if err != nil {
+		return nil, NotExist, err
+	}
+	return nil, NotExist, KeyPathNotFoundError
+}
+
+// GetAnyKey looks up several alternative keys in the object at `keys` in a single pass, for heterogeneous records which
+// name the same field differently, e.g. `id` or `uuid`. It returns the value of the first of `candidateKeys` present in
+// the object, together with that key; earlier candidates take precedence whatever their order in the document. If none
+// is present, `KeyPathNotFoundError` is returned.
+func GetAnyKey(data []byte, candidateKeys []string, keys ...string) (matchedKey string, value []byte, dataType ValueType, err error) {
+	best := len(candidateKeys)
+	err = ObjectEachKeys(data, candidateKeys, func(key []byte, v []byte, vt ValueType) error {
+		for i, c := range candidateKeys[:best] {
+			if bytesToString(&key) == c {
+				best, value, dataType = i, v, vt
+				break
+			}
+		}
+		if best == 0 {
+			return stopIteration
+		}
+		return nil
+	}, keys...)
+
+	if err != nil && err != stopIteration {
+		return "", nil, NotExist, err
+	}
+	if best == len(candidateKeys) {
+		return "", nil, NotExist, KeyPathNotFoundError
+	}
+	return candidateKeys[best], value, dataType, nil
+}
+
+// unionStep is a value reached while following one of the paths of `GetUnion`
+type unionStep struct {
+	value    []byte
+	dataType ValueType
+}
+
+// GetUnion tries several alternative key paths in turn, e.g. `a.b.c` and `a.x.c`, and returns the value of the first
+// one found together with its index in `alternatives`. Values found along the paths already tried are reused, so a
+// prefix shared by several alternatives is only looked up once, and an alternative whose shared prefix already failed
+// is skipped. If none is found, `KeyPathNotFoundError` is returned with `matched` set to -1.
+func GetUnion(data []byte, alternatives [][]string) (value []byte, dataType ValueType, matched int, err error) {
+	root, rootType, _, _, err := internalGet(data)
+	if err != nil {
+		return nil, NotExist, -1, err
+	}
+
+	// resolved[k] lists the values found along alternatives[k], after 0, 1, ... segments, up to the one which failed
+	resolved := make([][]unionStep, len(alternatives))
+	for k, path := range alternatives {
+		steps := []unionStep{{root, rootType}}
+		for j, prev := range alternatives[:k] {
+			if resolved[j] == nil {
+				continue // skipped, because of an earlier alternative which is compared too
+			}
+			n := 0
+			for n < len(path) && n < len(prev) && path[n] == prev[n] {
+				n++
+			}
+			if n >= len(resolved[j]) {
+				// the shared prefix includes the segment which wasn't found
+				steps = nil
+				break
+			}
+			if n+1 > len(steps) {
+				steps = resolved[j][:n+1 : n+1]
+			}
+		}
+		if steps == nil {
+			continue
+		}
+
+		for i := len(steps) - 1; i < len(path); i++ {
+			last := steps[len(steps)-1]
+			if last.dataType != Object && last.dataType != Array {
+				break
+			}
+			v, t, _, _, e := internalGet(last.value, path[i])
+			if e == KeyPathNotFoundError {
+				break
+			} else if e != nil {
+				return nil, NotExist, -1, e
+			}
+			steps = append(steps, unionStep{v, t})
+		}
+
+		if len(steps) == len(path)+1 {
+			last := steps[len(path)]
+			return last.value, last.dataType, k, nil
+		}
+		resolved[k] = steps
+	}
+	return nil, NotExist, -1, KeyPathNotFoundError
+}
+
+// LastKey returns the last key-value pair, in document order, of the object found at `keys`. The key is unescaped and
+// copied, while the value points into `data` as with `Get`. `KeyPathNotFoundError` is returned for an empty object.
+func LastKey(data []byte, keys ...string) (key []byte, value []byte, dataType ValueType, err error) {
+	found := false
+	err = ObjectEach(data, func(k []byte, v []byte, vt ValueType, offset int) error {
+		key, value, dataType, found = append(key[:0], k...), v, vt, true
+		return nil
+	}, keys...)
+
+	if err != nil {
+		return nil, nil, NotExist, err
+	}
+	if !found {
+		return nil, nil, NotExist, KeyPathNotFoundError
+	}
+	return key, value, dataType, nil
+}
+
+// ArrayElementType returns the type of the element at `index` of the array found at `keys`, without extracting it.
+// Preceding elements are skipped over and the element itself is classified from its first byte only, which is cheaper than
+// `Get(data, "[index]")` for large elements, but also means the element is not validated. `KeyPathNotFoundError` is
+// returned if the array has no such element.
+func ArrayElementType(data []byte, index int, keys ...string) (ValueType, error) {
+	offset, err := arrayElementOffset(data, index, keys)
+	if err != nil {
+		return NotExist, err
+	}
+	if t := Raw(data[offset:]).Type(); t != Unknown {
+		return t, nil
+	}
+	return Unknown, UnknownValueTypeError
+}
+
+// ArrayElement returns the element at `index` of the array found at `keys` together with its type, like
+// `Get(data, "[index]")` without formatting the index segment. Preceding elements are skipped over without being
+// extracted. `KeyPathNotFoundError` is returned if the array has no such element.
+func ArrayElement(data []byte, index int, keys ...string) (value []byte, dataType ValueType, err error) {
+	offset, err := arrayElementOffset(data, index, keys)
+	if err != nil {
+		return nil, NotExist, err
+	}
+	value, dataType, _, _, err = internalGet(data[offset:])
+	return value, dataType, err
+}
+
+// arrayElementOffset returns the offset of the first byte of the element at `index` of the array found at `keys`
+func arrayElementOffset(data []byte, index int, keys []string) (int, error) {
+	if nextToken(data) == -1 {
+		return -1, EmptyInputError
+	}
+
+	offset := 0
+	if len(keys) > 0 {
+		if offset = searchKeys(data, keys...); offset == -1 {
+			return -1, KeyPathNotFoundError
 		}
+	}
 
-		// Find the end of the key string
-		var keyEscaped bool
-		if off, esc := stringEnd(data[offset:]); off == -1 {
-			return MalformedJsonError
+	if off := nextToken(data[offset:]); off == -1 {
+		return -1, MalformedJsonError
+	} else if offset += off; data[offset] != '[' {
+		return -1, MalformedArrayError
+	} else {
+		offset++
+	}
+
+	for n := 0; ; n++ {
+		if off := nextToken(data[offset:]); off == -1 {
+			return -1, MalformedArrayError
 		} else {
-			key, keyEscaped = data[offset:offset+off-1], esc
 			offset += off
 		}
 
-		// Unescape the string if needed
-		if keyEscaped {
-			var stackbuf [unescapeStackBufSize]byte // stack-allocated array for allocation-free unescaping of small strings
-			if keyUnescaped, err := Unescape(key, stackbuf[:]); err != nil {
-				return MalformedStringEscapeError
-			} else {
-				key = keyUnescaped
-			}
-		}
-
-		// Step 2: skip the colon
-		if off := nextToken(data[offset:]); off == -1 {
-			return MalformedJsonError
-		} else if offset += off; data[offset] != ':' {
-			return MalformedJsonError
-		} else {
-			offset++
+		if data[offset] == ']' || index < 0 {
+			return -1, KeyPathNotFoundError
 		}
 
-		// Step 3: find the associated value, then invoke the callback
-		if value, valueType, off, err := Get(data[offset:]); err != nil {
-			return err
-		} else if err := callback(key, value, valueType, offset+off); err != nil { // Invoke the callback here!
-			return err
-		} else {
-			offset += off
+		if n == index {
+			return offset, nil
 		}
 
-		// Step 4: skip over the next comma to the following token, or stop if we hit the ending brace
-		if off := nextToken(data[offset:]); off == -1 {
-			return MalformedArrayError
-		} else {
-			offset += off
-			switch data[offset] {
-			case '}':
-				return nil // Stop if we hit the close brace
-			case ',':
-				offset++ // Ignore the comma
-			default:
-				return MalformedObjectError
+		// Skip over the element
+		var end int
+		switch data[offset] {
+		case '"':
+			end, _ = stringEnd(data[offset+1:])
+			if end != -1 {
+				end++
 			}
+		case '[':
+			end = blockEnd(data[offset:], '[', ']')
+		case '{':
+			end = blockEnd(data[offset:], '{', '}')
+		default:
+			end = tokenEnd(data[offset:])
 		}
+		if end == -1 {
+			return -1, MalformedArrayError
+		}
+		offset += end
 
-		// Skip to the next token after the comma
 		if off := nextToken(data[offset:]); off == -1 {
-			return MalformedArrayError
-		} else {
-			offset += off
+			return -1, MalformedArrayError
+		} else if offset += off; data[offset] == ']' {
+			return -1, KeyPathNotFoundError
+		} else if data[offset] != ',' {
+			return -1, MalformedArrayError
 		}
+		offset++
 	}
+}
 
-	return MalformedObjectError // we shouldn't get here; it's expected that we will return via finding the ending brace
+// GetUnsafeBytes returns the value retrieved by `Get` together with its type, without any copying or conversion.
+// Like `Get` (and `GetUnsafeString`), the surrounding quotes of string values are stripped, but escape sequences are left as is.
+// The result aliases `data`, so it's only valid as long as `data` isn't modified.
+func GetUnsafeBytes(data []byte, keys ...string) ([]byte, ValueType, error) {
+	v, t, _, e := Get(data, keys...)
+	return v, t, e
+}
+
+// GetChecked is like `Get`, but also checks that the value has the type `expectedLeaf`, returning an error naming both
+// types otherwise, e.g. "Value at a.b has type string, expected number". String values are returned without their quotes.
+func GetChecked(data []byte, expectedLeaf ValueType, keys ...string) ([]byte, error) {
+	v, t, _, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if t != expectedLeaf {
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("Root value has type %s, expected %s", t, expectedLeaf)
+		}
+		return nil, fmt.Errorf("Value at %s has type %s, expected %s", PathString(keys), t, expectedLeaf)
+	}
+	return v, nil
 }
 
 // GetUnsafeString returns the value retrieved by `Get`, use creates string without memory allocation by mapping string to slice memory. It does not handle escape symbols.
+// As with `Get`, the surrounding quotes of string values are stripped; other value types are returned as they appear in `data`.
 func GetUnsafeString(data []byte, keys ...string) (val string, err error) {
 	v, _, _, e := Get(data, keys...)
 
@@ -1211,6 +3206,161 @@ func GetString(data []byte, keys ...string) (val string, err error) {
 	return ParseString(v)
 }
 
+// GetStringInfo returns the raw content of the string found at `keys`, between the quotes and still escaped, together
+// with whether it contains any escape sequence, as found while looking for its end. Strings without escapes can be
+// forwarded as is, skipping an unescape and re-escape round trip. The value must be a String.
+func GetStringInfo(data []byte, keys ...string) (raw []byte, wasEscaped bool, err error) {
+	offset := 0
+	if len(keys) > 0 {
+		offset = searchKeys(data, keys...)
+	}
+	if offset != -1 {
+		if nO := nextToken(data[offset:]); nO != -1 && data[offset+nO] == '"' {
+			start := offset + nO + 1
+			if end, escaped := stringEnd(data[start:]); end != -1 {
+				end += start - 1 // closing quote
+				return data[start:end:end], escaped, nil
+			}
+		}
+	}
+
+	// Not a string: let Get report what was found instead
+	v, t, _, _, err := internalGet(data, keys...)
+	if err != nil {
+		return nil, false, err
+	}
+	if t == Null {
+		return nil, false, NullValueError
+	}
+	return nil, false, fmt.Errorf("Value is not a string: %s", string(v))
+}
+
+// GetStringArray returns the array retrieved by `Get` as a slice of strings, unescaping each element like `GetString`
+// (including \uXXXX sequences and surrogate pairs). Every element must be a string.
+func GetStringArray(data []byte, keys ...string) (val []string, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if t != Array {
+		if t == Null {
+			return nil, NullValueError
+		}
+		return nil, fmt.Errorf("Value is not an array: %s", string(v))
+	}
+
+	val = []string{}
+	_, e = ArrayEach(v, func(value []byte, dataType ValueType, offset int, e error) {
+		if e != nil || err != nil {
+			return
+		}
+		if dataType != String {
+			err = fmt.Errorf("Array element is not a string: %s", string(value))
+			return
+		}
+		if s, e := ParseString(value); e != nil {
+			err = e
+		} else {
+			val = append(val, s)
+		}
+	})
+	if e != nil {
+		return nil, e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// GetStringTrimmed is like `GetString`, but removes leading and trailing ASCII whitespace (space, \t, \n, \v, \f and \r)
+// from the unescaped value. Whitespace inside the string is preserved.
+func GetStringTrimmed(data []byte, keys ...string) (val string, err error) {
+	if val, err = GetString(data, keys...); err != nil {
+		return "", err
+	}
+	return strings.Trim(val, " \t\n\v\f\r"), nil
+}
+
+// GetStringTruncated is like `GetString`, but keeps at most `maxRunes` runes of the unescaped value, e.g. to cap the
+// length of log fields, and reports whether anything was cut off. Runes are counted rather than bytes so that
+// multi-byte characters are never split, with an escape sequence counting as the one character it stands for. Only the
+// kept part of the value is unescaped, so a huge string is never materialized in full.
+func GetStringTruncated(data []byte, maxRunes int, keys ...string) (val string, truncated bool, err error) {
+	v, t, _, e := Get(data, keys...)
+	if e != nil {
+		return "", false, e
+	}
+	if t != String {
+		if t == Null {
+			return "", false, NullValueError
+		}
+		return "", false, fmt.Errorf("Value is not a string: %s", string(v))
+	}
+
+	i := 0
+	for runes := 0; i < len(v); runes++ {
+		if runes >= maxRunes {
+			truncated = true
+			break
+		}
+		switch {
+		case v[i] != '\\':
+			_, size := utf8.DecodeRune(v[i:])
+			i += size
+		case i+1 < len(v) && v[i+1] == 'u':
+			_, size := decodeUnicodeEscape(v[i:])
+			if size == -1 {
+				return "", false, MalformedValueError
+			}
+			i += size
+		default:
+			i += 2
+		}
+	}
+	if i > len(v) {
+		return "", false, MalformedValueError
+	}
+
+	if bytes.IndexByte(v[:i], '\\') == -1 {
+		return string(v[:i]), truncated, nil
+	}
+	val, err = ParseString(v[:i])
+	return val, truncated, err
+}
+
+// GetStringSafe is like `GetString` for rendering contexts, where a missing or broken field should just show a
+// placeholder: it never fails, returning `def` whatever the problem is, be it a missing key, null, another type, a
+// malformed escape sequence or malformed JSON. Use `GetString` to tell these cases apart.
+func GetStringSafe(data []byte, def string, keys ...string) string {
+	if val, err := GetString(data, keys...); err == nil {
+		return val
+	}
+	return def
+}
+
+// GetScalarString is for fields which some producers write as a string and others as a number, e.g. `"42"` and `42`: it
+// returns the unescaped value of a String and the token of a Number as is, so both yield "42". Other types are an error.
+func GetScalarString(data []byte, keys ...string) (val string, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return "", e
+	}
+
+	switch t {
+	case String:
+		return ParseString(v)
+	case Number:
+		return string(v), nil
+	case Null:
+		return "", NullValueError
+	}
+	return "", fmt.Errorf("Value is not a string or number: %s", string(v))
+}
+
 // GetFloat returns the value retrieved by `Get`, cast to a float64 if possible.
 // The offset is the same as in `Get`.
 // If key data type do not match, it will return an error.
@@ -1231,6 +3381,98 @@ func GetFloat(data []byte, keys ...string) (val float64, err error) {
 	return ParseFloat(v)
 }
 
+// GetFloatOptional is like `GetFloat` for optional number fields, as produced from form data: null and the empty string
+// mean the field has no value, reported as `present == false` with no error. A number, or a string holding a JSON number
+// such as "1.5", gives its value; any other string or type is an error, as is a missing key (KeyPathNotFoundError).
+func GetFloatOptional(data []byte, keys ...string) (val float64, present bool, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return 0, false, e
+	}
+
+	switch t {
+	case Null:
+		return 0, false, nil
+	case Number:
+		val, err = ParseFloat(v)
+	case String:
+		if len(v) == 0 {
+			return 0, false, nil
+		}
+		s, e := ParseString(v)
+		if e != nil {
+			return 0, false, e
+		}
+		if val, err = ParseFloatStrict([]byte(s)); err != nil {
+			return 0, false, fmt.Errorf("Value is not a number: %q", s)
+		}
+	default:
+		return 0, false, fmt.Errorf("Value is not a number: %s", string(v))
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+	return val, true, nil
+}
+
+// GetFloat2DArray returns the array of arrays of numbers retrieved by `Get`, such as a matrix or GeoJSON coordinates
+// `[[1,2],[3,4]]`, as a [][]float64. Inner arrays may have different lengths. Every element of the outer array must be
+// an array, and every element of those a number.
+func GetFloat2DArray(data []byte, keys ...string) (val [][]float64, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if t != Array {
+		if t == Null {
+			return nil, NullValueError
+		}
+		return nil, fmt.Errorf("Value is not an array: %s", string(v))
+	}
+
+	val = [][]float64{}
+	_, e = ArrayEach(v, func(row []byte, dataType ValueType, offset int, e error) {
+		if e != nil || err != nil {
+			return
+		}
+		if dataType != Array {
+			err = fmt.Errorf("Array element is not an array: %s", string(row))
+			return
+		}
+
+		floats := []float64{}
+		_, e = ArrayEach(row, func(value []byte, dataType ValueType, offset int, e error) {
+			if e != nil || err != nil {
+				return
+			}
+			if dataType != Number {
+				err = fmt.Errorf("Array element is not a number: %s", string(value))
+				return
+			}
+			if f, e := ParseFloat(value); e != nil {
+				err = e
+			} else {
+				floats = append(floats, f)
+			}
+		})
+		if e != nil && err == nil {
+			err = e
+		}
+		val = append(val, floats)
+	})
+	if e != nil {
+		return nil, e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
 // GetInt returns the value retrieved by `Get`, cast to a int64 if possible.
 // If key data type do not match, it will return an error.
 func GetInt(data []byte, keys ...string) (val int64, err error) {
@@ -1250,6 +3492,85 @@ func GetInt(data []byte, keys ...string) (val int64, err error) {
 	return ParseInt(v)
 }
 
+// GetBigInt returns the value retrieved by `Get` as an arbitrary-precision integer, for numbers which don't fit in an int64.
+// Only integer Number values are accepted: a fraction or an exponent results in an error, even if the value is integral.
+func GetBigInt(data []byte, keys ...string) (val *big.Int, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if t != Number {
+		if t == Null {
+			return nil, NullValueError
+		}
+		return nil, fmt.Errorf("Value is not a number: %s", string(v))
+	}
+
+	return ParseBigInt(v)
+}
+
+// GetBigFloat returns the value retrieved by `Get` as an arbitrary-precision float, for decimals which lose precision as
+// a float64. See `ParseBigFloat` for the precision used.
+func GetBigFloat(data []byte, keys ...string) (val *big.Float, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if t != Number {
+		if t == Null {
+			return nil, NullValueError
+		}
+		return nil, fmt.Errorf("Value is not a number: %s", string(v))
+	}
+
+	return ParseBigFloat(v)
+}
+
+// GetIntFlexible is a lenient variant of `GetInt` for feeds which send integers as strings with digit grouping,
+// like `"1_000_000"` or `"-1,000"`. Each character of `separators` is accepted between two digits of a String value;
+// anything else besides a leading '-', as well as a leading, trailing or doubled separator, results in an error.
+// Plain Number values are parsed like `GetInt`.
+func GetIntFlexible(data []byte, separators string, keys ...string) (val int64, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return 0, e
+	}
+
+	switch t {
+	case Number:
+		return ParseInt(v)
+	case String:
+		var stackbuf [32]byte
+		digits := stackbuf[:0]
+		afterDigit := false
+		for i, c := range v {
+			switch {
+			case c >= '0' && c <= '9':
+				digits = append(digits, c)
+				afterDigit = true
+			case c == '-' && i == 0:
+				digits = append(digits, c)
+			case afterDigit && strings.IndexByte(separators, c) != -1:
+				afterDigit = false
+			default:
+				return 0, MalformedValueError
+			}
+		}
+		if !afterDigit { // no digits at all, or a trailing separator
+			return 0, MalformedValueError
+		}
+		return ParseInt(digits)
+	case Null:
+		return 0, NullValueError
+	}
+	return 0, fmt.Errorf("Value is not a number: %s", string(v))
+}
+
 // GetBoolean returns the value retrieved by `Get`, cast to a bool if possible.
 // The offset is the same as in `Get`.
 // If key data type do not match, it will return error.
@@ -1270,6 +3591,36 @@ func GetBoolean(data []byte, keys ...string) (val bool, err error) {
 	return ParseBoolean(v)
 }
 
+// GetBooleanNumeric is a lenient variant of `GetBoolean` for feeds which encode booleans as numbers: besides `true` and
+// `false`, it accepts the Number tokens `0` (false) and `1` (true). Any other number is an error, including other
+// spellings of zero and one such as `1.0`, `-0` or `1e0`.
+func GetBooleanNumeric(data []byte, keys ...string) (val bool, err error) {
+	v, t, _, e := Get(data, keys...)
+
+	if e != nil {
+		return false, e
+	}
+
+	if t == Number {
+		switch {
+		case len(v) == 1 && v[0] == '0':
+			return false, nil
+		case len(v) == 1 && v[0] == '1':
+			return true, nil
+		}
+		return false, fmt.Errorf("Value is not a boolean: %s", string(v))
+	}
+
+	if t != Boolean {
+		if t == Null {
+			return false, NullValueError
+		}
+		return false, fmt.Errorf("Value is not a boolean: %s", string(v))
+	}
+
+	return ParseBoolean(v)
+}
+
 // ParseBoolean parses a Boolean ValueType into a Go bool (not particularly useful, but here for completeness)
 func ParseBoolean(b []byte) (bool, error) {
 	switch {
@@ -1301,6 +3652,41 @@ func ParseFloat(b []byte) (float64, error) {
 	}
 }
 
+// ParseFloatStrict is like `ParseFloat`, but only accepts numbers conforming to the JSON grammar (RFC 7159), rejecting
+// e.g. a leading '+', leading zeros, a bare '.5' or '5.', and hexadecimal or special values accepted by strconv.ParseFloat.
+func ParseFloatStrict(b []byte) (float64, error) {
+	if !isNumber(b) {
+		return 0, MalformedValueError
+	}
+	return ParseFloat(b)
+}
+
+// ParseBigInt parses an integer Number ValueType into a *big.Int
+func ParseBigInt(b []byte) (*big.Int, error) {
+	if !isNumber(b) || bytes.IndexAny(b, ".eE") != -1 {
+		return nil, MalformedValueError
+	}
+	v, _ := new(big.Int).SetString(bytesToString(&b), 10)
+	return v, nil
+}
+
+// ParseBigFloat parses a Number ValueType into a *big.Float. The precision grows with the number of digits (4 bits per byte
+// of input, at least 64), which is enough for the value to be formatted back to the same decimal digits.
+func ParseBigFloat(b []byte) (*big.Float, error) {
+	if !isNumber(b) {
+		return nil, MalformedValueError
+	}
+	prec := uint(len(b)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	v, _, err := big.ParseFloat(bytesToString(&b), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, MalformedValueError
+	}
+	return v, nil
+}
+
 // ParseInt parses a Number ValueType into a Go int64
 func ParseInt(b []byte) (int64, error) {
 	if v, ok, overflow := parseInt(b); !ok {
@@ -2,8 +2,16 @@ package jsonparser
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 var testPaths = [][]string{
@@ -178,3 +186,398 @@ func TestArrayOutOfBounds(t *testing.T) {
 		return
 	}, keys...)
 }
+
+// panicCorpus holds inputs which crashed, hung or read out of bounds in the past, followed by documents whose every
+// prefix is replayed too, since truncated input is where bounds checks are usually missing
+var panicCorpus = []string{
+	`{"test":`,
+	`{{{"":`,                        // Issue #178: crash in searchKeys
+	"\x1f\xef\xbf\xbd\x03\x01\x00[", // Issue #188: infinite loop in Delete
+	"\x1f\xef\xbf\xbd\x03\x01\x00{", // Issue #188
+	`{"test":0}some":[{"these":[{"keys":"some"}]}]}some"}]}],"please":"some"}`,
+	`{"some":0}some":[{"some":[{"some":"some"}]}]}some"}]}],"some":"some"}`,
+	`{"test":1,"":`, // Delete reading past the end of a truncated value
+	`{"test"`,
+	`{"test":"\`,
+	`{"test":"\u12`,
+	`[,]`,
+	`{,}`,
+	`{"a":1{`,
+	`"\`,
+	`-`,
+	`1e`,
+	`tru`,
+	`]`,
+	`}`,
+	`:`,
+}
+
+var panicCorpusSeeds = []string{
+	`{"test": "in\"put", "a": {"b": [1, 2.5e-3, {"c": null}]}, "d": [true, false], "eé": "é\n"}`,
+	`[{"test": [[]], "x": {}}, -0.5, "s"]`,
+}
+
+// corpusMutationBytes replace or are inserted at every position of the seeds, to break their structure in as many ways
+// as possible
+const corpusMutationBytes = "{}[]\":,\\"
+
+// replayCorpus returns the inputs of TestCorpusReplayNoPanic: the panic corpus, every prefix of the seeds, the seeds with
+// one byte deleted, replaced or inserted, and the files in the directory named by JSONPARSER_FUZZ_CORPUS, if set, e.g. a
+// checkout of go-fuzz-corpus/json/corpus, which oss-fuzz-build.sh uses to seed the fuzzers
+func replayCorpus(t *testing.T) []string {
+	corpus := append([]string{}, panicCorpus...)
+	for _, seed := range panicCorpusSeeds {
+		for i := 0; i <= len(seed); i++ {
+			corpus = append(corpus, seed[:i])
+			if i < len(seed) {
+				corpus = append(corpus, seed[:i]+seed[i+1:])
+			}
+			for _, c := range []byte(corpusMutationBytes) {
+				corpus = append(corpus, seed[:i]+string(c)+seed[i:])
+				if i < len(seed) {
+					corpus = append(corpus, seed[:i]+string(c)+seed[i+1:])
+				}
+			}
+		}
+	}
+
+	if dir := os.Getenv("JSONPARSER_FUZZ_CORPUS"); dir != "" {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			corpus = append(corpus, string(data))
+		}
+	}
+	return corpus
+}
+
+// corpusEntryPoints calls every exported function and method, keyed by name, with `data` as input
+var corpusEntryPoints = map[string]func(data []byte){
+	"AppendLine": func(data []byte) { AppendLine(nil, data) },
+	"ArrayEach": func(data []byte) {
+		ArrayEach(data, func([]byte, ValueType, int, error) {})
+		ArrayEach(data, func([]byte, ValueType, int, error) {}, "a", "b")
+	},
+	"ArrayEachAbs": func(data []byte) { ArrayEachAbs(data, func([]byte, ValueType, int, error) {}, "a", "b") },
+	"ArrayEachFrom": func(data []byte) {
+		// Resume after every element until the array is consumed
+		for offset := 0; ; {
+			next, err := ArrayEachFrom(data, offset, func([]byte, ValueType, int) bool { return false })
+			if err != nil || next == offset {
+				break
+			}
+			offset = next
+		}
+		ArrayEachFrom(data, len(data)/2, func([]byte, ValueType, int) bool { return true })
+	},
+	"ArrayElement":     func(data []byte) { ArrayElement(data, 1, "a", "b") },
+	"ArrayElementType": func(data []byte) { ArrayElementType(data, 2); ArrayElementType(data, 0, "d") },
+	"ArrayLen":         func(data []byte) { ArrayLen(data); ArrayLen(data, "a", "b") },
+	"ArrayMap": func(data []byte) {
+		ArrayMap(data, func(i int, v []byte, t ValueType) ([]byte, error) {
+			if i == 1 {
+				return nil, nil
+			}
+			return v, nil
+		})
+	},
+	"ArrayOffsets": func(data []byte) { ArrayOffsets(data); ArrayOffsets(data, "d") },
+	"BigFloatParser.ParseNumber": func(data []byte) {
+		BigFloatParser{}.ParseNumber(data)
+		(&Parser{Numbers: BigFloatParser{}}).GetInt(data, "a", "b", "[1]")
+	},
+	"CompilePath":      func(data []byte) { CompilePath(string(data), "[0]") },
+	"CompilePlan":      func(data []byte) { CompilePlan(data) },
+	"CompiledPath.Get": func(data []byte) { corpusCompiledPath.Get(data) },
+	"ConcatArrays":     func(data []byte) { ConcatArrays(data, []byte(`[1]`), data) },
+	"DecodeToUTF8":     func(data []byte) { DecodeToUTF8(data) },
+	"Delete":           func(data []byte) { Delete(data, "test"); Delete(data, "a", "b", "[0]"); Delete(data, "") },
+	"Depth":            func(data []byte) { Depth(data) },
+	"DetectEncoding":   func(data []byte) { DetectEncoding(data) },
+	"EachKey":          func(data []byte) { EachKey(data, func(int, []byte, ValueType, error) {}, corpusPaths...) },
+	"EachKeyIndexed":   func(data []byte) { EachKeyIndexed(data, func(int, int, []byte, ValueType, error) {}, corpusPaths...) },
+	"EachKeyLast":      func(data []byte) { EachKeyLast(data, func(int, []byte, ValueType, error) {}, corpusPaths...) },
+	"EachKeyWithKey": func(data []byte) {
+		EachKeyWithKey(data, func(int, []byte, []byte, ValueType, error) {}, corpusPaths...)
+	},
+	"Entries":                   func(data []byte) { Entries(data); Entries(data, "a") },
+	"EqualValue":                func(data []byte) { EqualValue(data, data); EqualValue(data, []byte(`{"b": []}`), "a") },
+	"FindAll":                   func(data []byte) { FindAll(data, "c", func([]string, []byte, ValueType) {}) },
+	"Float64Parser.ParseNumber": func(data []byte) { Float64Parser{}.ParseNumber(data) },
+	"FuzzDelete":                func(data []byte) { FuzzDelete(data) },
+	"FuzzEachKey":               func(data []byte) { FuzzEachKey(data) },
+	"FuzzGetBoolean":            func(data []byte) { FuzzGetBoolean(data) },
+	"FuzzGetFloat":              func(data []byte) { FuzzGetFloat(data) },
+	"FuzzGetInt":                func(data []byte) { FuzzGetInt(data) },
+	"FuzzGetString":             func(data []byte) { FuzzGetString(data) },
+	"FuzzGetUnsafeString":       func(data []byte) { FuzzGetUnsafeString(data) },
+	"FuzzObjectEach":            func(data []byte) { FuzzObjectEach(data) },
+	"FuzzParseBool":             func(data []byte) { FuzzParseBool(data) },
+	"FuzzParseFloat":            func(data []byte) { FuzzParseFloat(data) },
+	"FuzzParseInt":              func(data []byte) { FuzzParseInt(data) },
+	"FuzzParseString":           func(data []byte) { FuzzParseString(data) },
+	"FuzzSet":                   func(data []byte) { FuzzSet(data) },
+	"FuzzTokenStart":            func(data []byte) { FuzzTokenStart(data) },
+	"Get": func(data []byte) {
+		Get(data)
+		Get(data, "test")
+		Get(data, "a", "b", "[2]", "c")
+		Get(data, "[0]", "test", "[0]")
+	},
+	"GetAnyKey": func(data []byte) {
+		GetAnyKey(data, []string{"x", "test"})
+		GetAnyKey(data, []string{"c"}, "a", "b", "[2]")
+	},
+	"GetAt":              func(data []byte) { GetAt(data, "a", "b", 2, "c"); GetAt(data, 0, "test") },
+	"GetBigFloat":        func(data []byte) { GetBigFloat(data, "a", "b", "[1]") },
+	"GetBigInt":          func(data []byte) { GetBigInt(data, "a", "b", "[0]") },
+	"GetBoolean":         func(data []byte) { GetBoolean(data, "test") },
+	"GetBooleanNumeric":  func(data []byte) { GetBooleanNumeric(data, "d", "[0]") },
+	"GetBytesForKeyPath": func(data []byte) { GetBytesForKeyPath(data, "a", "b", "[2]", "c", "x") },
+	"GetChecked":         func(data []byte) { GetChecked(data, Number, "a", "b", "[0]") },
+	"GetDescendant":      func(data []byte) { GetDescendant(data, "c"); GetDescendant(data, "test", "[0]") },
+	"GetDetached":        func(data []byte) { GetDetached(data, "test") },
+	"GetExplain":         func(data []byte) { GetExplain(data, "a", "b", "[5]", "c") },
+	"GetFloat":           func(data []byte) { GetFloat(data, "test") },
+	"GetFloat2DArray":    func(data []byte) { GetFloat2DArray(data, "test"); GetFloat2DArray(data) },
+	"GetFloatOptional":   func(data []byte) { GetFloatOptional(data, "a", "b", "[1]") },
+	"GetInt":             func(data []byte) { GetInt(data, "test") },
+	"GetIntFlexible":     func(data []byte) { GetIntFlexible(data, "_,", "test") },
+	"GetLimited":         func(data []byte) { GetLimited(data, 4, "test"); GetLimited(data, 0, "a") },
+	"GetManyCopy":        func(data []byte) { GetManyCopy(data, corpusPaths...) },
+	"GetNextSibling": func(data []byte) {
+		// Walk the root object entry by entry
+		for offset := 1; offset <= len(data); {
+			_, _, _, next, err := GetNextSibling(data, offset)
+			if err != nil || next <= offset {
+				break
+			}
+			offset = next
+		}
+		GetNextSibling(data, len(data)/2)
+	},
+	"GetNth":             func(data []byte) { GetNth(data, 1); GetNth(data, 0, "a", "b") },
+	"GetPath":            func(data []byte) { GetPath(data, "a.b[1]"); GetPath(data, string(data)) },
+	"GetRaw":             func(data []byte) { GetRaw(data, "a") },
+	"GetResolved":        func(data []byte) { GetResolved(data, "a", "b") },
+	"GetRootStrict":      func(data []byte) { GetRootStrict(data) },
+	"GetScalarString":    func(data []byte) { GetScalarString(data, "test"); GetScalarString(data, "d", "[0]") },
+	"GetString":          func(data []byte) { GetString(data, "test") },
+	"GetStringArray":     func(data []byte) { GetStringArray(data, "test"); GetStringArray(data) },
+	"GetStringInfo":      func(data []byte) { GetStringInfo(data, "test") },
+	"GetStringSafe":      func(data []byte) { GetStringSafe(data, "default", "test") },
+	"GetStringTrimmed":   func(data []byte) { GetStringTrimmed(data, "test") },
+	"GetStringTruncated": func(data []byte) { GetStringTruncated(data, 2, "test") },
+	"GetUnion":           func(data []byte) { GetUnion(data, [][]string{{"x", "y"}, {"a", "b", "[2]", "c"}, {"test"}}) },
+	"GetUnsafeBytes":     func(data []byte) { GetUnsafeBytes(data, "test") },
+	"GetUnsafeString":    func(data []byte) { GetUnsafeString(data, "test") },
+	"GetWithKey":         func(data []byte) { GetWithKey(data, "a", "b"); GetWithKey(data, "test") },
+	"GetWithParent":      func(data []byte) { GetWithParent(data, "a", "b", "[2]") },
+	"HasKey":             func(data []byte) { HasKey(data, "a", "b") },
+	"IsEmpty":            func(data []byte) { IsEmpty(data, true, "test"); IsEmpty(data, false) },
+	"JSONNumberParser.ParseNumber": func(data []byte) {
+		JSONNumberParser{}.ParseNumber(data)
+		(&Parser{Numbers: JSONNumberParser{}}).GetAny(data)
+	},
+	"KeyIndex":                 func(data []byte) { KeyIndex(data, corpusPaths...) },
+	"LastKey":                  func(data []byte) { LastKey(data); LastKey(data, "a") },
+	"Marshal":                  func(data []byte) { Marshal(map[string]interface{}{string(data): []interface{}{string(data), data}}) },
+	"NormalizeNumbers":         func(data []byte) { NormalizeNumbers(data) },
+	"ObjectBuilder.Build":      func(data []byte) { corpusBuilder(data).Build() },
+	"ObjectBuilder.Len":        func(data []byte) { corpusBuilder(data).Len() },
+	"ObjectBuilder.Order":      func(data []byte) { corpusBuilder(data).Order(string(data), "n").Build() },
+	"ObjectBuilder.Set":        func(data []byte) { corpusBuilder(data).Set(string(data), data).Build() },
+	"ObjectBuilder.SetBoolean": func(data []byte) { corpusBuilder(data).SetBoolean(string(data), true).Build() },
+	"ObjectBuilder.SetFloat":   func(data []byte) { corpusBuilder(data).SetFloat(string(data), 0.5).Build() },
+	"ObjectBuilder.SetInt":     func(data []byte) { corpusBuilder(data).SetInt(string(data), -1).Build() },
+	"ObjectBuilder.SetNull":    func(data []byte) { corpusBuilder(data).SetNull(string(data)).Build() },
+	"ObjectBuilder.SetString":  func(data []byte) { corpusBuilder(data).SetString(string(data), string(data)).Build() },
+	"ObjectEach": func(data []byte) {
+		ObjectEach(data, func([]byte, []byte, ValueType, int) error { return nil })
+		ObjectEach(data, func([]byte, []byte, ValueType, int) error { return nil }, "a")
+	},
+	"ObjectEachIndexed": func(data []byte) {
+		ObjectEachIndexed(data, func(int, []byte, []byte, ValueType) error { return nil })
+	},
+	"ObjectEachKeys": func(data []byte) {
+		ObjectEachKeys(data, []string{"test", "d"}, func([]byte, []byte, ValueType) error { return nil })
+	},
+	"ObjectEachRange": func(data []byte) {
+		ObjectEachRange(data, func([]byte, int, int, ValueType) error { return nil })
+	},
+	"ObjectEachValidated": func(data []byte) {
+		ObjectEachValidated(data, func([]byte, []byte, ValueType, int) error { return nil })
+	},
+	"ObjectMap": func(data []byte) {
+		ObjectMap(data, func(k, v []byte, t ValueType) ([]byte, error) {
+			if len(k) == 1 {
+				return nil, nil
+			}
+			return v, nil
+		})
+	},
+	"ObjectRanges":       func(data []byte) { ObjectRanges(data) },
+	"ParseBigFloat":      func(data []byte) { ParseBigFloat(data) },
+	"ParseBigInt":        func(data []byte) { ParseBigInt(data) },
+	"ParseBoolean":       func(data []byte) { ParseBoolean(data) },
+	"ParseFloat":         func(data []byte) { ParseFloat(data) },
+	"ParseFloatStrict":   func(data []byte) { ParseFloatStrict(data) },
+	"ParseInt":           func(data []byte) { ParseInt(data) },
+	"ParseString":        func(data []byte) { ParseString(data) },
+	"ParseValueType":     func(data []byte) { ParseValueType(string(data)) },
+	"Parser.Get":         func(data []byte) { corpusParser.Get(data, "test"); corpusParser.Get(data, "a", "b", "[1]") },
+	"Parser.GetAny":      func(data []byte) { corpusParser.GetAny(data) },
+	"Parser.GetFloat":    func(data []byte) { corpusParser.GetFloat(data, "test") },
+	"Parser.GetInt":      func(data []byte) { corpusParser.GetInt(data, "test") },
+	"Parser.GetString":   func(data []byte) { corpusParser.GetString(data, "test") },
+	"Parser.ParseFloat":  func(data []byte) { corpusParser.ParseFloat(data) },
+	"Parser.ParseInt":    func(data []byte) { corpusParser.ParseInt(data) },
+	"Parser.ParseString": func(data []byte) { corpusParser.ParseString(data) },
+	"Patch":              func(data []byte) { Patch(data, []byte(panicCorpusSeeds[0])); Patch([]byte(panicCorpusSeeds[1]), data) },
+	"PathString":         func(data []byte) { PathString([]string{string(data), "[0]"}) },
+	"PathsConflict":      func(data []byte) { PathsConflict([]string{string(data)}, []string{string(data), "[*]"}) },
+	"Plan.Unmarshal": func(data []byte) {
+		var v corpusPlanTarget
+		corpusPlan.Unmarshal(data, &v)
+	},
+	"Query":             func(data []byte) { Query(data, "$..b[?(@.c == null)]", func([]byte, ValueType) {}) },
+	"Raw.Clone":         func(data []byte) { Raw(data).Clone() },
+	"Raw.Type":          func(data []byte) { Raw(data).Type() },
+	"Resolve":           func(data []byte) { Resolve(data, []string{"a.b[2].c", "test", "", "x.y"}) },
+	"SafeGet":           func(data []byte) { SafeGet(data, "a", "b") },
+	"SafeParse":         func(data []byte) { SafeParse(data) },
+	"Set":               func(data []byte) { Set(data, []byte(`"new value"`), "test"); Set(data, []byte(`1`), "a", "b", "[5]") },
+	"SetAfter":          func(data []byte) { SetAfter(data, []byte(`1`), "test", "new") },
+	"SetArray":          func(data []byte) { SetArray(data, []byte(`[1]`), "d") },
+	"SetArrayElementBy": func(data []byte) { SetArrayElementBy(data, []string{"c"}, []byte(`null`), []byte(`1`), "a", "b") },
+	"SetAuto":           func(data []byte) { SetAuto(data, string(data), "test") },
+	"SetBefore":         func(data []byte) { SetBefore(data, []byte(`1`), "a", "new") },
+	"SetBuf":            func(data []byte) { SetBuf(nil, data, []byte(`true`), "a", "b", "[1]") },
+	"SetFloat":          func(data []byte) { SetFloat(data, 1.5, "test") },
+	"SetFloatFmt":       func(data []byte) { SetFloatFmt(data, 1.5, 'e', 2, "a", "x") },
+	"SetFold":           func(data []byte) { SetFold(data, []byte(`2`), "TEST") },
+	"SetObject":         func(data []byte) { SetObject(data, []byte(`{"x": 1}`), "a") },
+	"Slice.ArrayEach":   func(data []byte) { Slice{Data: data, Base: 3}.ArrayEach(func([]byte, ValueType, int, error) {}) },
+	"Slice.Get":         func(data []byte) { Slice{Data: data, Base: 3}.Get("a", "b") },
+	"Slice.GetSlice":    func(data []byte) { Slice{Data: data, Base: 3}.GetSlice("a") },
+	"Slice.Sub":         func(data []byte) { Slice{Data: data}.Sub(0, len(data)/2).Get("test") },
+	"Stats":             func(data []byte) { Stats(data) },
+	"Step":              func(data []byte) { Step(data, "a"); Step(data, "[0]") },
+	"StringArrayLen":    func(data []byte) { StringArrayLen(data); StringArrayLen(data, "test") },
+	"StringToBytes":     func(data []byte) { StringToBytes(string(data)) },
+	"Transform": func(data []byte) {
+		keep := func(v []byte, t ValueType) []byte { return nil }
+		Transform(ioutil.Discard, data, map[string]func([]byte, ValueType) []byte{"test": keep, "c": keep})
+	},
+	"TryGet":   func(data []byte) { TryGet(data, "a", "b", "[0]") },
+	"Unescape": func(data []byte) { Unescape(data, nil); Unescape(data, make([]byte, 0, 4)) },
+	"ValidateIncremental.Done": func(data []byte) {
+		var v ValidateIncremental
+		v.Write(data)
+		v.Done()
+	},
+	"ValidateIncremental.Write": func(data []byte) {
+		// Byte by byte, so that every state sees a chunk boundary
+		var v ValidateIncremental
+		for i := range data {
+			v.Write(data[i : i+1])
+		}
+	},
+	"ValueType.String": func(data []byte) { _ = ValueType(len(data)).String() },
+	"WriteToBuffer":    func(data []byte) { WriteToBuffer(make([]byte, len(data)), string(data)) },
+}
+
+var (
+	corpusPaths           = [][]string{{"test"}, {"a", "b", "[1]"}, {"a", "b", "[*]", "c"}, {"d", "[0]"}, {"arr", "["}}
+	corpusParser          = Parser{AllowNonFiniteNumbers: true, AllowSingleQuotes: true, AllowJSON5Numbers: true}
+	corpusCompiledPath, _ = CompilePath("a", "b", "[2]", "c")
+	corpusPlan            = CompilePlan(&corpusPlanTarget{})
+)
+
+type corpusPlanTarget struct {
+	Test string `json:"test"`
+	A    struct {
+		B *float64 `json:"b"`
+	} `json:"a"`
+	D     []byte `json:"d"`
+	Other bool
+}
+
+func corpusBuilder(data []byte) *ObjectBuilder {
+	var b ObjectBuilder
+	return b.Set("raw", data).SetString("s", string(data)).SetNull("n")
+}
+
+// TestCorpusReplayNoPanic feeds the corpus through every exported function and method, none of which may panic or hang
+// whatever the input
+func TestCorpusReplayNoPanic(t *testing.T) {
+	// Make sure no exported function or method was left out
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || !fn.Name.IsExported() {
+					continue
+				}
+				name := fn.Name.Name
+				if fn.Recv != nil {
+					recv := fn.Recv.List[0].Type
+					if star, ok := recv.(*ast.StarExpr); ok {
+						recv = star.X
+					}
+					if !ast.IsExported(recv.(*ast.Ident).Name) {
+						continue
+					}
+					name = recv.(*ast.Ident).Name + "." + name
+				}
+				if corpusEntryPoints[name] == nil {
+					t.Errorf("%s is not replayed against the corpus", name)
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(corpusEntryPoints))
+	for name := range corpusEntryPoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const timeout = 5 * time.Second
+	corpus := replayCorpus(t)
+	for _, name := range names {
+		fn := corpusEntryPoints[name]
+		for _, input := range corpus {
+			done := make(chan interface{}, 1)
+			go func(data []byte) {
+				defer func() { done <- recover() }()
+				fn(data)
+			}([]byte(input))
+
+			timer := time.NewTimer(timeout)
+			select {
+			case r := <-done:
+				if r != nil {
+					t.Errorf("%s(%q) panicked: %v", name, input, r)
+				}
+			case <-timer.C:
+				t.Fatalf("%s(%q) did not return within %v", name, input, timeout)
+			}
+			timer.Stop()
+		}
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	_ "fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -335,12 +340,11 @@ var setTests = []SetTest{
 		data:    `{"foo":{"bar":"null"}}`,
 	},
 	{
-		desc:    "set in empty string - not found",
+		desc:    "set in empty string - empty input",
 		json:    ``,
-		isFound: false,
+		isErr:   true,
 		path:    []string{"foo"},
 		setData: `"null"`,
-		data:    ``,
 	},
 	{
 		desc:    "set in Number - not found",
@@ -635,6 +639,20 @@ var getTests = []GetTest{
 		isFound: true,
 		data:    `1`,
 	},
+	{
+		desc:    `escaped key one byte longer than the key it stands for`,
+		json:    `{"a\"":1}`,
+		path:    []string{`a"`},
+		isFound: true,
+		data:    `1`,
+	},
+	{
+		desc:    `escaped key as long as the key looked for`,
+		json:    `{"a\nb":1, "a\\nb":2}`,
+		path:    []string{`a\nb`},
+		isFound: true,
+		data:    `2`,
+	},
 
 	{ // This test returns a match instead of a parse error, as checking for the malformed JSON would reduce performance
 		desc:    `malformed with trailing whitespace`,
@@ -653,10 +671,10 @@ var getTests = []GetTest{
 
 	// Not found key tests
 	{
-		desc:    `empty input`,
-		json:    ``,
-		path:    []string{"a"},
-		isFound: false,
+		desc:  `empty input`,
+		json:  ``,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
 		desc:    "non-existent key 1",
@@ -1298,6 +1316,240 @@ func TestSet(t *testing.T) {
 	)
 }
 
+func TestSetBuf(t *testing.T) {
+	buf := make([]byte, 0, 16)
+	runSetTests(t, "SetBuf()", setTests,
+		func(test SetTest) (value interface{}, dataType ValueType, err error) {
+			buf, err = SetBuf(buf, []byte(test.json), []byte(test.setData), test.path...)
+			return buf, NotExist, err
+		},
+		func(test SetTest, value interface{}) (bool, interface{}) {
+			expected := []byte(test.data.(string))
+			return bytes.Equal(expected, value.([]byte)), expected
+		},
+	)
+
+	// The buffer is reused once it is large enough
+	buf = make([]byte, 0, 64)
+	out, err := SetBuf(buf, []byte(`{"a": 1, "b": 2}`), []byte(`"x"`), "b")
+	if err != nil || string(out) != `{"a": 1, "b": "x"}` || &out[0] != &buf[:1][0] {
+		t.Errorf("SetBuf expected to write into the given buffer, obtained %s (error %v)", out, err)
+	}
+}
+
+// Set splices the new value in place of the old one, so sibling numbers keep their formatting and whitespace outside the
+// edited span is preserved, which keeps edits to config files diff-friendly
+func TestSetPreservesFormatting(t *testing.T) {
+	tests := []struct {
+		json     string
+		path     []string
+		expected string
+	}{
+		{json: `{"a": 1.200, "b": 2}`, path: []string{"b"}, expected: `{"a": 1.200, "b": "new"}`},
+		{json: "{\n  \"a\": 1.200,\n  \"b\" :  2 ,\n  \"c\": 1e3\n}", path: []string{"b"}, expected: "{\n  \"a\": 1.200,\n  \"b\" :  \"new\" ,\n  \"c\": 1e3\n}"},
+		{json: `{"a": 1.200, "b": {"x": -0.10E+2}}`, path: []string{"b", "x"}, expected: `{"a": 1.200, "b": {"x": "new"}}`},
+		{json: `{"a": 1.200, "b": [1.0, 2.0 ]}`, path: []string{"b", "[1]"}, expected: `{"a": 1.200, "b": [1.0, "new" ]}`},
+		{json: `{"a": 1.200, "b": 2}`, path: []string{"c"}, expected: `{"a": 1.200, "b": 2,"c":"new"}`},
+	}
+
+	for _, test := range tests {
+		out, err := Set([]byte(test.json), []byte(`"new"`), test.path...)
+		if err != nil || string(out) != test.expected {
+			t.Errorf("Set(%s, %v) expected %s, obtained %s (error %v)", test.json, test.path, test.expected, out, err)
+		}
+	}
+}
+
+func TestSetFloatFmt(t *testing.T) {
+	tests := []struct {
+		value  float64
+		format byte
+		prec   int
+		out    string
+	}{
+		{value: 0.0001, format: 'g', prec: -1, out: `{"a":0.0001}`},
+		{value: 0.00001, format: 'g', prec: -1, out: `{"a":1e-05}`},
+		{value: 0.00001, format: 'f', prec: -1, out: `{"a":0.00001}`},
+		{value: 1234.5678, format: 'f', prec: 2, out: `{"a":1234.57}`},
+		{value: 1e21, format: 'f', prec: -1, out: `{"a":1000000000000000000000}`},
+		{value: 1e21, format: 'g', prec: -1, out: `{"a":1e+21}`},
+		{value: 0.0001, format: 'e', prec: -1, out: `{"a":1e-04}`},
+		{value: 1.5, format: 'E', prec: 3, out: `{"a":1.500E+00}`},
+		{value: -2, format: 'G', prec: -1, out: `{"a":-2}`},
+	}
+
+	for _, test := range tests {
+		out, err := SetFloatFmt([]byte(`{"a":1}`), test.value, test.format, test.prec, "a")
+		if err != nil || string(out) != test.out {
+			t.Errorf("SetFloatFmt(%v, %q, %d) expected %s, obtained %s (err %v)", test.value, test.format, test.prec, test.out, out, err)
+		}
+	}
+
+	if out, err := SetFloat([]byte(`{}`), 0.25, "b"); err != nil || string(out) != `{"b":0.25}` {
+		t.Errorf("SetFloat returned unexpected %s, %v", out, err)
+	}
+	if _, err := SetFloatFmt([]byte(`{}`), 1, 'x', -1, "a"); err == nil {
+		t.Error("SetFloatFmt should reject the hexadecimal format")
+	}
+	if _, err := SetFloat([]byte(`{}`), math.Inf(1), "a"); err == nil {
+		t.Error("SetFloat should reject infinity")
+	}
+}
+
+func TestSetObjectAndArray(t *testing.T) {
+	data := []byte(`{"config":{"old":true},"list":[1]}`)
+
+	if out, err := SetObject(data, []byte(`{"new": [1, {"x": null}]}`), "config"); err != nil || string(out) != `{"config":{"new": [1, {"x": null}]},"list":[1]}` {
+		t.Errorf("SetObject returned unexpected %s, %v", out, err)
+	}
+	if out, err := SetArray(data, []byte(`[]`), "list"); err != nil || string(out) != `{"config":{"old":true},"list":[]}` {
+		t.Errorf("SetArray returned unexpected %s, %v", out, err)
+	}
+	if out, err := SetArray(data, []byte(`["a"]`), "added"); err != nil || string(out) != `{"config":{"old":true},"list":[1],"added":["a"]}` {
+		t.Errorf("SetArray of a new key returned unexpected %s, %v", out, err)
+	}
+
+	invalid := []struct {
+		setArray bool
+		value    string
+	}{
+		{value: `1`},
+		{value: `[1]`},
+		{value: `"{}"`},
+		{value: `{"a":}`},
+		{value: `{"a":1`},
+		{value: `{} {}`},
+		{value: ``},
+		{setArray: true, value: `{}`},
+		{setArray: true, value: `[1,]`},
+		{setArray: true, value: `null`},
+	}
+	for _, test := range invalid {
+		var err error
+		if test.setArray {
+			_, err = SetArray(data, []byte(test.value), "list")
+		} else {
+			_, err = SetObject(data, []byte(test.value), "config")
+		}
+		if err == nil {
+			t.Errorf("SetObject/SetArray (array %t) should reject %s", test.setArray, test.value)
+		}
+	}
+}
+
+func TestSetBeforeAfter(t *testing.T) {
+	data := `{"a": 1, "b": {"x": 1, "z": 3}, "c": "s"}`
+	tests := []struct {
+		desc     string
+		after    bool
+		sibling  string
+		path     []string
+		expected string
+		err      error
+	}{
+		{desc: "before first", sibling: "a", path: []string{"n"}, expected: `{"n":0,"a": 1, "b": {"x": 1, "z": 3}, "c": "s"}`},
+		{desc: "before middle", sibling: "b", path: []string{"n"}, expected: `{"a": 1, "n":0,"b": {"x": 1, "z": 3}, "c": "s"}`},
+		{desc: "after last", after: true, sibling: "c", path: []string{"n"}, expected: `{"a": 1, "b": {"x": 1, "z": 3}, "c": "s","n":0}`},
+		{desc: "after object", after: true, sibling: "b", path: []string{"n"}, expected: `{"a": 1, "b": {"x": 1, "z": 3},"n":0, "c": "s"}`},
+		{desc: "nested", sibling: "z", path: []string{"b", "y"}, expected: `{"a": 1, "b": {"x": 1, "y":0,"z": 3}, "c": "s"}`},
+		{desc: "nested after", after: true, sibling: "x", path: []string{"b", "y"}, expected: `{"a": 1, "b": {"x": 1,"y":0, "z": 3}, "c": "s"}`},
+		{desc: "existing key replaced in place", sibling: "a", path: []string{"c"}, expected: `{"a": 1, "b": {"x": 1, "z": 3}, "c": 0}`},
+		{desc: "missing sibling appends", sibling: "q", path: []string{"n"}, expected: `{"a": 1, "b": {"x": 1, "z": 3}, "c": "s","n":0}`},
+		{desc: "missing parent is created", sibling: "q", path: []string{"p", "n"}, expected: `{"a": 1, "b": {"x": 1, "z": 3}, "c": "s","p":{"n":0}}`},
+		{desc: "parent not an object", sibling: "q", path: []string{"a", "n"}, err: MalformedObjectError},
+		{desc: "no keys", sibling: "a", err: KeyPathNotFoundError},
+	}
+
+	for _, test := range tests {
+		set := SetBefore
+		if test.after {
+			set = SetAfter
+		}
+		out, err := set([]byte(data), []byte(`0`), test.sibling, test.path...)
+		if err != test.err {
+			t.Errorf("SetBefore/SetAfter test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+		} else if err == nil && string(out) != test.expected {
+			t.Errorf("SetBefore/SetAfter test '%s' expected %s, obtained %s", test.desc, test.expected, out)
+		}
+	}
+
+	// The resulting key order
+	out, _ := SetBefore([]byte(`{"id": 1, "name": "x"}`), []byte(`"v1"`), "id", "version")
+	var order []string
+	ObjectEach(out, func(key []byte, value []byte, dataType ValueType, offset int) error {
+		order = append(order, string(key))
+		return nil
+	})
+	if !reflect.DeepEqual(order, []string{"version", "id", "name"}) {
+		t.Errorf("SetBefore expected keys version, id, name, obtained %v", order)
+	}
+}
+
+func TestSetFold(t *testing.T) {
+	tests := []struct {
+		json     string
+		path     []string
+		expected string
+	}{
+		{json: `{"userid": 1, "name": "x"}`, path: []string{"userId"}, expected: `{"userid": 2, "name": "x"}`},
+		{json: `{"USERID": 1}`, path: []string{"userId"}, expected: `{"USERID": 2}`},
+		{json: `{"userId": 1}`, path: []string{"userId"}, expected: `{"userId": 2}`},
+		{json: `{"a": {"UserID": 1, "userid": 3}}`, path: []string{"a", "userId"}, expected: `{"a": {"UserID": 2, "userid": 3}}`},
+		{json: `{"Straße": 1}`, path: []string{"STRASSE"}, expected: `{"Straße": 1,"STRASSE":2}`},
+		{json: `{"ÉTÉ": 1}`, path: []string{"été"}, expected: `{"ÉTÉ": 2}`},
+		{json: `{"user\u0049d": 1}`, path: []string{"userid"}, expected: `{"user\u0049d": 2}`},
+		{json: `{"name": "x"}`, path: []string{"userId"}, expected: `{"name": "x","userId":2}`},
+		{json: `{"A": {"userid": 1}}`, path: []string{"a", "userId"}, expected: `{"A": {"userid": 1},"a":{"userId":2}}`},
+	}
+
+	for _, test := range tests {
+		out, err := SetFold([]byte(test.json), []byte(`2`), test.path...)
+		if err != nil || string(out) != test.expected {
+			t.Errorf("SetFold(%s, %v) expected %s, obtained %s (error %v)", test.json, test.path, test.expected, out, err)
+		}
+	}
+
+	if _, err := SetFold([]byte(`{"a": 1}`), []byte(`2`)); err != KeyPathNotFoundError {
+		t.Errorf("SetFold without keys expected KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestSetArrayElementBy(t *testing.T) {
+	data := []byte(`{"users": [{"id": 1, "name": "a"}, {"id": 42, "name": "b", "tags": ["x"]}, {"id": 42}, "s"], "n": 1}`)
+
+	out, err := SetArrayElementBy(data, []string{"id"}, []byte(`42`), []byte(`{"id": 42, "name": "B"}`), "users")
+	if expected := `{"users": [{"id": 1, "name": "a"}, {"id": 42, "name": "B"}, {"id": 42}, "s"], "n": 1}`; err != nil || string(out) != expected {
+		t.Errorf("SetArrayElementBy of a middle element expected %s, obtained %s, %v", expected, out, err)
+	}
+	if string(data) != `{"users": [{"id": 1, "name": "a"}, {"id": 42, "name": "b", "tags": ["x"]}, {"id": 42}, "s"], "n": 1}` {
+		t.Errorf("SetArrayElementBy modified its input: %s", data)
+	}
+
+	// Strings are matched with their quotes, and an empty child path matches the element itself
+	if out, err := SetArrayElementBy(data, []string{"name"}, []byte(` "a" `), []byte(`null`), "users"); err != nil ||
+		string(out) != `{"users": [null, {"id": 42, "name": "b", "tags": ["x"]}, {"id": 42}, "s"], "n": 1}` {
+		t.Errorf("SetArrayElementBy matching a string returned unexpected %s, %v", out, err)
+	}
+	if out, err := SetArrayElementBy(data, nil, []byte(`"s"`), []byte(`"t"`), "users"); err != nil ||
+		string(out) != `{"users": [{"id": 1, "name": "a"}, {"id": 42, "name": "b", "tags": ["x"]}, {"id": 42}, "t"], "n": 1}` {
+		t.Errorf("SetArrayElementBy matching a whole element returned unexpected %s, %v", out, err)
+	}
+	if out, err := SetArrayElementBy(data, []string{"tags", "[0]"}, []byte(`"x"`), []byte(`{}`), "users"); err != nil ||
+		string(out) != `{"users": [{"id": 1, "name": "a"}, {}, {"id": 42}, "s"], "n": 1}` {
+		t.Errorf("SetArrayElementBy with a nested child path returned unexpected %s, %v", out, err)
+	}
+
+	if _, err := SetArrayElementBy(data, []string{"id"}, []byte(`7`), []byte(`{}`), "users"); err != KeyPathNotFoundError {
+		t.Errorf("SetArrayElementBy without a match should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, err := SetArrayElementBy(data, []string{"name"}, []byte(`b`), []byte(`{}`), "users"); err != KeyPathNotFoundError {
+		t.Errorf("SetArrayElementBy with an unquoted string should not match, obtained %v", err)
+	}
+	if _, err := SetArrayElementBy(data, []string{"id"}, []byte(`1`), []byte(`{}`), "n"); err != MalformedArrayError {
+		t.Errorf("SetArrayElementBy of a number should fail with MalformedArrayError, obtained %v", err)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	runDeleteTests(t, "Delete()", deleteTests,
 		func(test DeleteTest) (interface{}, []byte) {
@@ -1337,10 +1589,180 @@ func TestGetString(t *testing.T) {
 	)
 }
 
-func TestGetUnsafeString(t *testing.T) {
-	runGetTests(t, "GetUnsafeString()", getUnsafeStringTests,
+func TestGetStringArray(t *testing.T) {
+	data := []byte(`{"a": ["plain", "line\nbreak", "\u00e9t\u00E9", "\ud83d\ude00", "q\"uote", ""], "b": [], "c": ["x", 1], "d": "x"}`)
+
+	val, err := GetStringArray(data, "a")
+	expected := []string{"plain", "line\nbreak", "été", "😀", `q"uote`, ""}
+	if err != nil || !reflect.DeepEqual(val, expected) {
+		t.Errorf("GetStringArray expected %q, obtained %q (err %v)", expected, val, err)
+	}
+
+	if val, err := GetStringArray(data, "b"); err != nil || val == nil || len(val) != 0 {
+		t.Errorf("GetStringArray of an empty array expected an empty slice, obtained %#v (err %v)", val, err)
+	}
+	if _, err := GetStringArray(data, "c"); err == nil {
+		t.Error("GetStringArray should reject non-string elements")
+	}
+	if _, err := GetStringArray(data, "d"); err == nil {
+		t.Error("GetStringArray should reject non-array values")
+	}
+	if _, err := GetStringArray(data, "e"); err != KeyPathNotFoundError {
+		t.Errorf("GetStringArray expected KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+var getStringTrimmedTests = []GetTest{
+	{
+		desc:    `trim surrounding spaces`,
+		json:    `{"a": "  hello  world  "}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    `hello  world`,
+	},
+	{
+		desc:    `trim escaped whitespace`,
+		json:    `{"a": "\t\r\nhello\n "}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    `hello`,
+	},
+	{
+		desc:    `keep non-ASCII whitespace`,
+		json:    `{"a": "\u00a0hello "}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    "\u00a0hello",
+	},
+	{
+		desc:    `only whitespace`,
+		json:    `{"a": "   "}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    ``,
+	},
+	{
+		desc:  `not a string`,
+		json:  `{"a": 1}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:    `missing key`,
+		json:    `{"a": " x "}`,
+		path:    []string{"b"},
+		isFound: false,
+	},
+}
+
+func TestGetStringInfo(t *testing.T) {
+	data := []byte(`{"plain": "hello world", "esc": "a\"b\\n", "uni": "caf\u00e9", "long": "` + strings.Repeat("x", 100) + `\t", "empty": "", "num": 1, "null": null, "arr": ["x\/y"]}`)
+	tests := []struct {
+		path    []string
+		raw     string
+		escaped bool
+		err     bool
+	}{
+		{path: []string{"plain"}, raw: `hello world`},
+		{path: []string{"esc"}, raw: `a\"b\\n`, escaped: true},
+		{path: []string{"uni"}, raw: `caf\u00e9`, escaped: true},
+		{path: []string{"long"}, raw: strings.Repeat("x", 100) + `\t`, escaped: true},
+		{path: []string{"empty"}, raw: ``},
+		{path: []string{"arr", "[0]"}, raw: `x\/y`, escaped: true},
+		{path: []string{"num"}, err: true},
+		{path: []string{"null"}, err: true},
+		{path: []string{"missing"}, err: true},
+	}
+
+	for _, test := range tests {
+		raw, escaped, err := GetStringInfo(data, test.path...)
+		if (err != nil) != test.err {
+			t.Errorf("GetStringInfo(%v) expected error %t, obtained %v", test.path, test.err, err)
+		} else if err == nil && (string(raw) != test.raw || escaped != test.escaped) {
+			t.Errorf("GetStringInfo(%v) expected %s, %t, obtained %s, %t", test.path, test.raw, test.escaped, raw, escaped)
+		}
+	}
+
+	if _, _, err := GetStringInfo([]byte(`{"a": "x`), "a"); err != MalformedStringError {
+		t.Errorf("GetStringInfo of an unterminated string expected MalformedStringError, obtained %v", err)
+	}
+	if _, _, err := GetStringInfo([]byte(`{"a": null}`), "a"); err != NullValueError {
+		t.Errorf("GetStringInfo of null expected NullValueError, obtained %v", err)
+	}
+}
+
+func TestGetStringTruncated(t *testing.T) {
+	tests := []struct {
+		json      string
+		maxRunes  int
+		expected  string
+		truncated bool
+		err       error
+	}{
+		{json: `{"a": "hello"}`, maxRunes: 10, expected: "hello"},
+		{json: `{"a": "hello"}`, maxRunes: 5, expected: "hello"},
+		{json: `{"a": "hello"}`, maxRunes: 3, expected: "hel", truncated: true},
+		{json: `{"a": "héllo"}`, maxRunes: 2, expected: "hé", truncated: true},
+		{json: `{"a": "日本語"}`, maxRunes: 1, expected: "日", truncated: true},
+		{json: `{"a": "日本語"}`, maxRunes: 3, expected: "日本語"},
+		{json: `{"a": "a😀b"}`, maxRunes: 2, expected: "a😀", truncated: true},
+		{json: `{"a": "a\u00e9b"}`, maxRunes: 2, expected: "aé", truncated: true},
+		{json: `{"a": "a\ud83d\ude00b"}`, maxRunes: 2, expected: "a😀", truncated: true},
+		{json: `{"a": "a\ud83d\ude00b"}`, maxRunes: 1, expected: "a", truncated: true},
+		{json: `{"a": "\"q\"\n"}`, maxRunes: 2, expected: `"q`, truncated: true},
+		{json: `{"a": "ok\x"}`, maxRunes: 2, expected: "ok", truncated: true},
+		{json: `{"a": ""}`, maxRunes: 0, expected: ""},
+		{json: `{"a": "x"}`, maxRunes: 0, expected: "", truncated: true},
+		{json: `{"a": "ok\x"}`, maxRunes: 5, err: MalformedValueError},
+		{json: `{"a": "\u12"}`, maxRunes: 5, err: MalformedValueError},
+		{json: `{"a": null}`, maxRunes: 5, err: NullValueError},
+		{json: `{"b": "x"}`, maxRunes: 5, err: KeyPathNotFoundError},
+	}
+
+	for _, test := range tests {
+		val, truncated, err := GetStringTruncated([]byte(test.json), test.maxRunes, "a")
+		if err != test.err {
+			t.Errorf("GetStringTruncated(%s, %d) expected error %v, obtained %v", test.json, test.maxRunes, test.err, err)
+		} else if err == nil && (val != test.expected || truncated != test.truncated) {
+			t.Errorf("GetStringTruncated(%s, %d) expected %q, %t, obtained %q, %t", test.json, test.maxRunes, test.expected, test.truncated, val, truncated)
+		}
+	}
+
+	if _, _, err := GetStringTruncated([]byte(`{"a": 1}`), 5, "a"); err == nil {
+		t.Errorf("GetStringTruncated of a number expected an error")
+	}
+}
+
+func TestGetStringSafe(t *testing.T) {
+	data := []byte(`{"name": "a\u00e9", "empty": "", "num": 1, "null": null, "bad": "\x"}`)
+
+	tests := []struct {
+		json     []byte
+		path     []string
+		expected string
+	}{
+		{json: data, path: []string{"name"}, expected: "aé"},
+		{json: data, path: []string{"empty"}, expected: ""},
+		{json: data, path: []string{"missing"}, expected: "n/a"},
+		{json: data, path: []string{"num"}, expected: "n/a"},
+		{json: data, path: []string{"null"}, expected: "n/a"},
+		{json: data, path: []string{"bad"}, expected: "n/a"},
+		{json: []byte(`{"name": "x`), path: []string{"name"}, expected: "n/a"},
+		{json: []byte(`{"name" "x"}`), path: []string{"name"}, expected: "n/a"},
+		{json: []byte(`]]`), path: []string{"name"}, expected: "n/a"},
+		{json: nil, path: []string{"name"}, expected: "n/a"},
+	}
+	for _, test := range tests {
+		if v := GetStringSafe(test.json, "n/a", test.path...); v != test.expected {
+			t.Errorf("GetStringSafe(%s, %q) expected %q, obtained %q", test.json, test.path, test.expected, v)
+		}
+	}
+}
+
+func TestGetStringTrimmed(t *testing.T) {
+	runGetTests(t, "GetStringTrimmed()", getStringTrimmedTests,
 		func(test GetTest) (value interface{}, dataType ValueType, err error) {
-			value, err = GetUnsafeString([]byte(test.json), test.path...)
+			value, err = GetStringTrimmed([]byte(test.json), test.path...)
 			return value, String, err
 		},
 		func(test GetTest, value interface{}) (bool, interface{}) {
@@ -1350,630 +1772,3258 @@ func TestGetUnsafeString(t *testing.T) {
 	)
 }
 
-func TestGetInt(t *testing.T) {
-	runGetTests(t, "GetInt()", getIntTests,
+var getScalarStringTests = []GetTest{
+	{
+		desc:    `string`,
+		json:    `{"a": "42"}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    `42`,
+	},
+	{
+		desc:    `number`,
+		json:    `{"a": 42}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    `42`,
+	},
+	{
+		desc:    `number spelling is kept`,
+		json:    `{"a": [-4.20e1]}`,
+		path:    []string{"a", "[0]"},
+		isFound: true,
+		data:    `-4.20e1`,
+	},
+	{
+		desc:    `escaped string`,
+		json:    `{"a": "4\u0032"}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    `42`,
+	},
+	{
+		desc:  `object`,
+		json:  `{"a": {"b": 42}}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `array`,
+		json:  `{"a": [42]}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `boolean`,
+		json:  `{"a": true}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `null`,
+		json:  `{"a": null}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:    `missing`,
+		json:    `{"a": 42}`,
+		path:    []string{"b"},
+		isFound: false,
+	},
+}
+
+func TestGetFloat2DArray(t *testing.T) {
+	tests := []struct {
+		json     string
+		path     []string
+		expected [][]float64
+		err      bool
+	}{
+		{json: `{"m": [[1, 2], [3, 4]]}`, path: []string{"m"}, expected: [][]float64{{1, 2}, {3, 4}}},
+		{json: `{"m": [[1.5], [], [-2, 3e2, 0]]}`, path: []string{"m"}, expected: [][]float64{{1.5}, {}, {-2, 300, 0}}},
+		{json: `{"type": "LineString", "coordinates": [[102.0, 0.5], [103.0, 1.0]]}`, path: []string{"coordinates"}, expected: [][]float64{{102, 0.5}, {103, 1}}},
+		{json: `[]`, expected: [][]float64{}},
+		{json: `{"m": [[1, 2], 3]}`, path: []string{"m"}, err: true},
+		{json: `{"m": [[1, "2"]]}`, path: []string{"m"}, err: true},
+		{json: `{"m": [[1, 2]`, path: []string{"m"}, err: true},
+		{json: `{"m": {"a": [1]}}`, path: []string{"m"}, err: true},
+		{json: `{"m": null}`, path: []string{"m"}, err: true},
+		{json: `{"m": []}`, path: []string{"x"}, err: true},
+	}
+
+	for _, test := range tests {
+		val, err := GetFloat2DArray([]byte(test.json), test.path...)
+		if (err != nil) != test.err {
+			t.Errorf("GetFloat2DArray(%s) expected error %t, obtained %v", test.json, test.err, err)
+		} else if err == nil && !reflect.DeepEqual(val, test.expected) {
+			t.Errorf("GetFloat2DArray(%s) expected %v, obtained %v", test.json, test.expected, val)
+		}
+	}
+}
+
+func TestGetFloatOptional(t *testing.T) {
+	data := []byte(`{"empty": "", "null": null, "num": -1.5, "str": "2.5e1", "bad": "abc", "sp": " 1", "hex": "0x10", "obj": {}}`)
+
+	tests := []struct {
+		key     string
+		val     float64
+		present bool
+		isErr   bool
+	}{
+		{key: "empty"},
+		{key: "null"},
+		{key: "num", val: -1.5, present: true},
+		{key: "str", val: 25, present: true},
+		{key: "bad", isErr: true},
+		{key: "sp", isErr: true},
+		{key: "hex", isErr: true},
+		{key: "obj", isErr: true},
+		{key: "missing", isErr: true},
+	}
+	for _, test := range tests {
+		val, present, err := GetFloatOptional(data, test.key)
+		if (err != nil) != test.isErr || val != test.val || present != test.present {
+			t.Errorf("GetFloatOptional(%s) expected %v, %t, error %t, obtained %v, %t, %v", test.key, test.val, test.present, test.isErr, val, present, err)
+		}
+	}
+
+	if _, _, err := GetFloatOptional(data, "missing"); err != KeyPathNotFoundError {
+		t.Errorf("GetFloatOptional of a missing key should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestGetScalarString(t *testing.T) {
+	runGetTests(t, "GetScalarString()", getScalarStringTests,
 		func(test GetTest) (value interface{}, dataType ValueType, err error) {
-			value, err = GetInt([]byte(test.json), test.path...)
-			return value, Number, err
+			value, err = GetScalarString([]byte(test.json), test.path...)
+			return value, String, err
 		},
 		func(test GetTest, value interface{}) (bool, interface{}) {
-			expected := test.data.(int64)
-			return expected == value.(int64), expected
+			expected := test.data.(string)
+			return expected == value.(string), expected
 		},
 	)
 }
 
-func TestGetFloat(t *testing.T) {
-	runGetTests(t, "GetFloat()", getFloatTests,
+func TestGetUnsafeString(t *testing.T) {
+	runGetTests(t, "GetUnsafeString()", getUnsafeStringTests,
 		func(test GetTest) (value interface{}, dataType ValueType, err error) {
-			value, err = GetFloat([]byte(test.json), test.path...)
-			return value, Number, err
+			value, err = GetUnsafeString([]byte(test.json), test.path...)
+			return value, String, err
 		},
 		func(test GetTest, value interface{}) (bool, interface{}) {
-			expected := test.data.(float64)
-			return expected == value.(float64), expected
+			expected := test.data.(string)
+			return expected == value.(string), expected
 		},
 	)
 }
 
-func TestGetBoolean(t *testing.T) {
-	runGetTests(t, "GetBoolean()", getBoolTests,
-		func(test GetTest) (value interface{}, dataType ValueType, err error) {
-			value, err = GetBoolean([]byte(test.json), test.path...)
-			return value, Boolean, err
-		},
-		func(test GetTest, value interface{}) (bool, interface{}) {
-			expected := test.data.(bool)
-			return expected == value.(bool), expected
-		},
-	)
+func TestGetChecked(t *testing.T) {
+	data := []byte(`{"a": {"b": "x", "n": 1.5, "o": {}, "l": [], "t": true, "z": null}}`)
+	tests := []struct {
+		path     []string
+		expected ValueType
+		value    string
+		err      string
+	}{
+		{path: []string{"a", "b"}, expected: String, value: "x"},
+		{path: []string{"a", "n"}, expected: Number, value: "1.5"},
+		{path: []string{"a", "o"}, expected: Object, value: "{}"},
+		{path: []string{"a", "l"}, expected: Array, value: "[]"},
+		{path: []string{"a", "t"}, expected: Boolean, value: "true"},
+		{path: []string{"a", "z"}, expected: Null, value: "null"},
+		{path: []string{"a", "b"}, expected: Number, err: "Value at a.b has type string, expected number"},
+		{path: []string{"a", "z"}, expected: String, err: "Value at a.z has type null, expected string"},
+		{path: []string{"a"}, expected: Array, err: "Value at a has type object, expected array"},
+		{expected: String, err: "Root value has type object, expected string"},
+		{expected: Object, value: string(data)},
+		{path: []string{"a", "q"}, expected: String, err: KeyPathNotFoundError.Error()},
+	}
+
+	for _, test := range tests {
+		value, err := GetChecked(data, test.expected, test.path...)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("GetChecked(%v, %s) expected error %q, obtained %v", test.path, test.expected, test.err, err)
+			}
+		} else if err != nil || string(value) != test.value {
+			t.Errorf("GetChecked(%v, %s) expected %s, obtained %s (error %v)", test.path, test.expected, test.value, value, err)
+		}
+	}
 }
 
-func TestGetSlice(t *testing.T) {
-	runGetTests(t, "Get()-for-arrays", getArrayTests,
+func TestGetUnsafeBytes(t *testing.T) {
+	runGetTests(t, "GetUnsafeBytes()", getUnsafeStringTests,
 		func(test GetTest) (value interface{}, dataType ValueType, err error) {
-			value, dataType, _, err = Get([]byte(test.json), test.path...)
+			value, dataType, err = GetUnsafeBytes([]byte(test.json), test.path...)
 			return
 		},
 		func(test GetTest, value interface{}) (bool, interface{}) {
-			expected := test.data.([]string)
-			return reflect.DeepEqual(expected, toStringArray(value.([]byte))), expected
+			expected := []byte(test.data.(string))
+			return bytes.Equal(expected, value.([]byte)), expected
 		},
 	)
-}
-
-func TestArrayEach(t *testing.T) {
-	mock := []byte(`{"a": { "b":[{"x": 1} ,{"x":2},{ "x":3}, {"x":4} ]}}`)
-	count := 0
-
-	ArrayEach(mock, func(value []byte, dataType ValueType, offset int, err error) {
-		count++
-
-		switch count {
-		case 1:
-			if string(value) != `{"x": 1}` {
-				t.Errorf("Wrong first item: %s", string(value))
-			}
-		case 2:
-			if string(value) != `{"x":2}` {
-				t.Errorf("Wrong second item: %s", string(value))
-			}
-		case 3:
-			if string(value) != `{ "x":3}` {
-				t.Errorf("Wrong third item: %s", string(value))
-			}
-		case 4:
-			if string(value) != `{"x":4}` {
-				t.Errorf("Wrong forth item: %s", string(value))
-			}
-		default:
-			t.Errorf("Should process only 4 items")
-		}
-	}, "a", "b")
-}
-
-func TestArrayEachWithWhiteSpace(t *testing.T) {
-	// Issue #159
-	count := 0
-	funcError := func([]byte, ValueType, int, error) { t.Errorf("Run func not allow") }
-	funcSuccess := func(value []byte, dataType ValueType, index int, err error) {
-		count++
-
-		switch count {
-		case 1:
-			if string(value) != `AAA` {
-				t.Errorf("Wrong first item: %s", string(value))
-			}
-		case 2:
-			if string(value) != `BBB` {
-				t.Errorf("Wrong second item: %s", string(value))
-			}
-		case 3:
-			if string(value) != `CCC` {
-				t.Errorf("Wrong third item: %s", string(value))
-			}
-		default:
-			t.Errorf("Should process only 3 items")
-		}
-	}
-
-	type args struct {
-		data []byte
-		cb   func(value []byte, dataType ValueType, offset int, err error)
-		keys []string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		{"Array with white space", args{[]byte(`    ["AAA", "BBB", "CCC"]`), funcSuccess, []string{}}, false},
-		{"Array with only one character after white space", args{[]byte(`    1`), funcError, []string{}}, true},
-		{"Only white space", args{[]byte(`    `), funcError, []string{}}, true},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			_, err := ArrayEach(tt.args.data, tt.args.cb, tt.args.keys...)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ArrayEach() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-		})
-	}
-}
-
-func TestArrayEachEmpty(t *testing.T) {
-	funcError := func([]byte, ValueType, int, error) { t.Errorf("Run func not allow") }
 
-	type args struct {
-		data []byte
-		cb   func(value []byte, dataType ValueType, offset int, err error)
-		keys []string
-	}
-	tests := []struct {
-		name       string
-		args       args
-		wantOffset int
-		wantErr    bool
-	}{
-		{"Empty array", args{[]byte("[]"), funcError, []string{}}, 1, false},
-		{"Empty array with space", args{[]byte("[ ]"), funcError, []string{}}, 2, false},
-		{"Empty array with \n", args{[]byte("[\n]"), funcError, []string{}}, 2, false},
-		{"Empty field array", args{[]byte("{\"data\": []}"), funcError, []string{"data"}}, 10, false},
-		{"Empty field array with space", args{[]byte("{\"data\": [ ]}"), funcError, []string{"data"}}, 11, false},
-		{"Empty field array with \n", args{[]byte("{\"data\": [\n]}"), funcError, []string{"data"}}, 11, false},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotOffset, err := ArrayEach(tt.args.data, tt.args.cb, tt.args.keys...)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("ArrayEach() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if gotOffset != tt.wantOffset {
-				t.Errorf("ArrayEach() = %v, want %v", gotOffset, tt.wantOffset)
-			}
-		})
+	if v, dt, err := GetUnsafeBytes([]byte(`{"a": [1, 2]}`), "a"); err != nil || dt != Array || string(v) != `[1, 2]` {
+		t.Errorf("GetUnsafeBytes expected [1, 2] (array), obtained %s (%s), %v", string(v), dt, err)
 	}
 }
 
-type keyValueEntry struct {
-	key       string
-	value     string
-	valueType ValueType
-}
-
-func (kv keyValueEntry) String() string {
-	return fmt.Sprintf("[%s: %s (%s)]", kv.key, kv.value, kv.valueType)
-}
-
-type ObjectEachTest struct {
-	desc string
-	json string
-
-	isErr   bool
-	entries []keyValueEntry
-}
-
-var objectEachTests = []ObjectEachTest{
+var getIntFlexibleTests = []GetTest{
 	{
-		desc:    "empty object",
-		json:    `{}`,
-		entries: []keyValueEntry{},
+		desc:    `read underscore separated string`,
+		json:    `{"a": "1_000_000"}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    int64(1000000),
 	},
 	{
-		desc: "single key-value object",
-		json: `{"key": "value"}`,
-		entries: []keyValueEntry{
-			{"key", "value", String},
-		},
+		desc:    `read comma separated negative string`,
+		json:    `{"a": "-1,000"}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    int64(-1000),
 	},
 	{
-		desc: "multiple key-value object with many value types",
-		json: `{
-		  "key1": null,
-		  "key2": true,
-		  "key3": 1.23,
-		  "key4": "string value",
-		  "key5": [1,2,3],
-		  "key6": {"a":"b"}
-		}`,
-		entries: []keyValueEntry{
-			{"key1", "null", Null},
-			{"key2", "true", Boolean},
-			{"key3", "1.23", Number},
-			{"key4", "string value", String},
-			{"key5", "[1,2,3]", Array},
-			{"key6", `{"a":"b"}`, Object},
-		},
+		desc:    `read plain number`,
+		json:    `{"a": 42}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    int64(42),
 	},
 	{
-		desc: "escaped key",
-		json: `{"key\"\\\/\b\f\n\r\t\u00B0": "value"}`,
-		entries: []keyValueEntry{
-			{"key\"\\/\b\f\n\r\t\u00B0", "value", String},
-		},
+		desc:  `reject letters`,
+		json:  `{"a": "1_000k"}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
-	// Error cases
 	{
-		desc:  "no object present",
-		json:  ` \t\n\r`,
+		desc:  `reject spaces`,
+		json:  `{"a": "1 000"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "unmatched braces 1",
-		json:  `{`,
+		desc:  `reject only separators`,
+		json:  `{"a": "_,_"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "unmatched braces 2",
-		json:  `}`,
+		desc:  `reject a lone minus sign`,
+		json:  `{"a": "-"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "unmatched braces 3",
-		json:  `}{}`,
+		desc:  `reject separators around a minus sign`,
+		json:  `{"a": "_-_"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad key (number)",
-		json:  `{123: "value"}`,
+		desc:  `reject leading separator`,
+		json:  `{"a": ",10"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad key (unclosed quote)",
-		json:  `{"key: 123}`,
+		desc:  `reject trailing separator`,
+		json:  `{"a": "10_"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad value (no value)",
-		json:  `{"key":}`,
+		desc:  `reject doubled separators`,
+		json:  `{"a": "1,,0"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad value (bogus value)",
-		json:  `{"key": notavalue}`,
+		desc:  `reject mixed misplaced separators`,
+		json:  `{"a": ",1,,0_"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad entry (missing colon)",
-		json:  `{"key" "value"}`,
+		desc:  `reject empty string`,
+		json:  `{"a": ""}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad entry (no trailing comma)",
-		json:  `{"key": "value" "key2": "value2"}`,
+		desc:  `reject fractions`,
+		json:  `{"a": "1,000.5"}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
 	{
-		desc:  "bad entry (two commas)",
-		json:  `{"key": "value",, "key2": "value2"}`,
+		desc:  `reject booleans`,
+		json:  `{"a": true}`,
+		path:  []string{"a"},
 		isErr: true,
 	},
+	{
+		desc:    `missing key`,
+		json:    `{"a": "1"}`,
+		path:    []string{"b"},
+		isFound: false,
+	},
 }
 
-func TestObjectEach(t *testing.T) {
-	for _, test := range objectEachTests {
-		if activeTest != "" && test.desc != activeTest {
-			continue
-		}
-
-		// Execute ObjectEach and capture all of the entries visited, in order
-		var entries []keyValueEntry
-		err := ObjectEach([]byte(test.json), func(key, value []byte, valueType ValueType, off int) error {
-			entries = append(entries, keyValueEntry{
-				key:       string(key),
-				value:     string(value),
-				valueType: valueType,
-			})
-			return nil
-		})
-
-		// Check the correctness of the result
-		isErr := (err != nil)
-		if test.isErr != isErr {
-			// If the call didn't match the error expectation, fail
-			t.Errorf("ObjectEach test '%s' isErr mismatch: expected %t, obtained %t (err %v)", test.desc, test.isErr, isErr, err)
-		} else if isErr {
-			// Else, if there was an expected error, don't fail and don't check anything further
-		} else if len(test.entries) != len(entries) {
-			t.Errorf("ObjectEach test '%s' mismatch in number of key-value entries: expected %d, obtained %d (entries found: %s)", test.desc, len(test.entries), len(entries), entries)
-		} else {
-			for i, entry := range entries {
-				expectedEntry := test.entries[i]
-				if expectedEntry.key != entry.key {
-					t.Errorf("ObjectEach test '%s' key mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.key, entry.key)
-					break
-				} else if expectedEntry.value != entry.value {
-					t.Errorf("ObjectEach test '%s' value mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.value, entry.value)
-					break
-				} else if expectedEntry.valueType != entry.valueType {
-					t.Errorf("ObjectEach test '%s' value type mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.valueType, entry.valueType)
-					break
-				} else {
-					// Success for this entry
-				}
-			}
-		}
-	}
-}
-
-var testJson = []byte(`{
-	"name": "Name", 
-	"order": "Order", 
-	"sum": 100, 
-	"len": 12, 
-	"isPaid": true, 
-	"nested": {"a":"test", "b":2, "nested3":{"a":"test3","b":4}, "c": "unknown"}, 
-	"nested2": {
-		"a":"test2", 
-		"b":3
-	}, 
-	"arr": [
-		{
-			"a":"zxc", 
-			"b": 1
-		}, 
-		{
-			"a":"123", 
-			"b":2
-		}
-	], 
-	"arrInt": [1,2,3,4], 
-	"intPtr": 10, 
-	"a\n":{
-		"b\n":99
-	}
-}`)
-
-func TestEachKey(t *testing.T) {
-	paths := [][]string{
-		{"name"},
-		{"order"},
-		{"nested", "a"},
-		{"nested", "b"},
-		{"nested2", "a"},
-		{"nested", "nested3", "b"},
-		{"arr", "[1]", "b"},
-		{"arrInt", "[3]"},
-		{"arrInt", "[5]"}, // Should not find last key
-		{"nested"},
-		{"arr", "["},    // issue#177 Invalid arguments
-		{"a\n", "b\n"},  // issue#165
-		{"nested", "b"}, // Should find repeated key
-	}
-
-	keysFound := 0
-
-	EachKey(testJson, func(idx int, value []byte, vt ValueType, err error) {
-		keysFound++
-
-		switch idx {
-		case 0:
-			if string(value) != "Name" {
-				t.Error("Should find 1 key", string(value))
-			}
-		case 1:
-			if string(value) != "Order" {
-				t.Errorf("Should find 2 key")
-			}
-		case 2:
-			if string(value) != "test" {
-				t.Errorf("Should find 3 key")
-			}
-		case 3:
-			if string(value) != "2" {
-				t.Errorf("Should find 4 key")
-			}
-		case 4:
-			if string(value) != "test2" {
-				t.Error("Should find 5 key", string(value))
-			}
-		case 5:
-			if string(value) != "4" {
-				t.Errorf("Should find 6 key")
-			}
-		case 6:
-			if string(value) != "2" {
-				t.Errorf("Should find 7 key")
-			}
-		case 7:
-			if string(value) != "4" {
-				t.Error("Should find 8 key", string(value))
-			}
-		case 8:
-			t.Errorf("Found key #8 that should not be found")
-		case 9:
-			if string(value) != `{"a":"test", "b":2, "nested3":{"a":"test3","b":4}, "c": "unknown"}` {
-				t.Error("Should find 9 key", string(value))
-			}
-		case 10:
-			t.Errorf("Found key #10 that should not be found")
-		case 11:
-			if string(value) != "99" {
-				t.Error("Should find 10 key", string(value))
-			}
-		case 12:
-			if string(value) != "2" {
-				t.Errorf("Should find 11 key")
-			}
-		default:
-			t.Errorf("Should find only 10 keys, got %v key", idx)
-		}
-	}, paths...)
-
-	if keysFound != 11 {
-		t.Errorf("Should find 11 keys: %d", keysFound)
-	}
-}
-
-type ParseTest struct {
-	in     string
-	intype ValueType
-	out    interface{}
-	isErr  bool
-}
-
-var parseBoolTests = []ParseTest{
-	{
-		in:     "true",
-		intype: Boolean,
-		out:    true,
-	},
-	{
-		in:     "false",
-		intype: Boolean,
-		out:    false,
-	},
-	{
-		in:     "foo",
-		intype: Boolean,
-		isErr:  true,
-	},
+var getBigIntTests = []GetTest{
 	{
-		in:     "trux",
-		intype: Boolean,
-		isErr:  true,
+		desc:    `read 40-digit integer`,
+		json:    `{"a": 1234567890123456789012345678901234567890}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    "1234567890123456789012345678901234567890",
 	},
 	{
-		in:     "truex",
-		intype: Boolean,
-		isErr:  true,
+		desc:    `read negative 40-digit integer`,
+		json:    `{"a": [-9999999999999999999999999999999999999999]}`,
+		path:    []string{"a", "[0]"},
+		isFound: true,
+		data:    "-9999999999999999999999999999999999999999",
 	},
 	{
-		in:     "",
-		intype: Boolean,
-		isErr:  true,
+		desc:    `read small integer`,
+		json:    `{"a": 0}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    "0",
 	},
-}
-
-var parseFloatTest = []ParseTest{
 	{
-		in:     "0",
-		intype: Number,
-		out:    float64(0),
+		desc:  `reject fraction`,
+		json:  `{"a": 1.5}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "0.0",
-		intype: Number,
-		out:    float64(0.0),
+		desc:  `reject integral fraction`,
+		json:  `{"a": 10.0}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "1",
-		intype: Number,
-		out:    float64(1),
+		desc:  `reject exponent`,
+		json:  `{"a": 1e40}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "1.234",
-		intype: Number,
-		out:    float64(1.234),
+		desc:  `reject leading zero`,
+		json:  `{"a": 0123}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "1.234e5",
-		intype: Number,
-		out:    float64(1.234e5),
+		desc:  `reject string`,
+		json:  `{"a": "1"}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
+}
+
+func TestGetBigInt(t *testing.T) {
+	runGetTests(t, "GetBigInt()", getBigIntTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetBigInt([]byte(test.json), test.path...)
+			return value, Number, err
+		},
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(string)
+			return expected == value.(*big.Int).String(), expected
+		},
+	)
+}
+
+var getBigFloatTests = []GetTest{
 	{
-		in:     "-1.234e5",
-		intype: Number,
-		out:    float64(-1.234e5),
+		desc:    `read decimal beyond float64 precision`,
+		json:    `{"a": 12345678901234567890.123456789}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    "12345678901234567890.123456789",
 	},
 	{
-		in:     "+1.234e5", // Note: + sign not allowed under RFC7159, but our parser accepts it since it uses strconv.ParseFloat
-		intype: Number,
-		out:    float64(1.234e5),
+		desc:    `read negative fraction`,
+		json:    `{"a": [-0.000000000000000000012345678901234567]}`,
+		path:    []string{"a", "[0]"},
+		isFound: true,
+		data:    "-0.000000000000000000012345678901234567",
 	},
 	{
-		in:     "1.2.3",
-		intype: Number,
-		isErr:  true,
+		desc:    `read exponent`,
+		json:    `{"a": 1.5e3}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    "1500",
 	},
 	{
-		in:     "1..1",
-		intype: Number,
-		isErr:  true,
+		desc:  `reject string`,
+		json:  `{"a": "1.5"}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "1a",
-		intype: Number,
-		isErr:  true,
+		desc:  `reject null`,
+		json:  `{"a": null}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 	{
-		in:     "",
-		intype: Number,
-		isErr:  true,
+		desc:  `reject malformed number`,
+		json:  `{"a": 1.5.5}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 }
 
-// parseTestCheckNoError checks the error return from Parse*() against the test case expectations.
-// Returns true the test should proceed to checking the actual data returned from Parse*(), or false if the test is finished.
-func parseTestCheckNoError(t *testing.T, testKind string, test ParseTest, value interface{}, err error) bool {
-	if isErr := (err != nil); test.isErr != isErr {
-		// If the call didn't match the error expectation, fail
-		t.Errorf("%s test '%s' isErr mismatch: expected %t, obtained %t (err %v). Obtained value: %v", testKind, test.in, test.isErr, isErr, err, value)
-		return false
-	} else if isErr {
-		// Else, if there was an error, don't fail and don't check isFound or the value
-		return false
-	} else {
-		// Else, there was no error and a value was found, so check the value
-		return true
+func TestGetBigFloat(t *testing.T) {
+	runGetTests(t, "GetBigFloat()", getBigFloatTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetBigFloat([]byte(test.json), test.path...)
+			return value, Number, err
+		},
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(string)
+			prec := 0
+			if dot := strings.IndexByte(expected, '.'); dot != -1 {
+				prec = len(expected) - dot - 1
+			}
+			return expected == value.(*big.Float).Text('f', prec), expected
+		},
+	)
+
+	// The same value doesn't survive float64
+	data := []byte(`{"a": 12345678901234567890.123456789}`)
+	if f, _ := GetFloat(data, "a"); strconv.FormatFloat(f, 'f', 9, 64) == "12345678901234567890.123456789" {
+		t.Error("expected float64 to lose precision")
 	}
 }
 
-func runParseTests(t *testing.T, testKind string, tests []ParseTest, runner func(ParseTest) (interface{}, error), resultChecker func(ParseTest, interface{}) (bool, interface{})) {
-	for _, test := range tests {
-		value, err := runner(test)
-
-		if parseTestCheckNoError(t, testKind, test, value, err) {
-			if test.out == nil {
-				t.Errorf("MALFORMED TEST: %v", test)
-				continue
-			}
+func TestGetIntFlexible(t *testing.T) {
+	runGetTests(t, "GetIntFlexible()", getIntFlexibleTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetIntFlexible([]byte(test.json), "_,", test.path...)
+			return value, Number, err
+		},
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(int64)
+			return expected == value.(int64), expected
+		},
+	)
 
-			if ok, expected := resultChecker(test, value); !ok {
-				if expectedBytes, ok := expected.([]byte); ok {
-					expected = string(expectedBytes)
-				}
-				if valueBytes, ok := value.([]byte); ok {
-					value = string(valueBytes)
-				}
-				t.Errorf("%s test '%s' expected to return value %v, but did returned %v instead", testKind, test.in, expected, value)
-			}
-		}
+	// Only the given separators are accepted
+	if v, err := GetIntFlexible([]byte(`{"a": "1'234'567"}`), "'", "a"); err != nil || v != 1234567 {
+		t.Errorf("GetIntFlexible with an apostrophe separator expected 1234567, obtained %d, %v", v, err)
+	}
+	if v, err := GetIntFlexible([]byte(`{"a": "1_000"}`), "", "a"); err == nil {
+		t.Errorf("GetIntFlexible without separators should reject 1_000, obtained %d", v)
 	}
 }
 
-func TestParseBoolean(t *testing.T) {
-	runParseTests(t, "ParseBoolean()", parseBoolTests,
-		func(test ParseTest) (value interface{}, err error) {
-			return ParseBoolean([]byte(test.in))
+func TestGetInt(t *testing.T) {
+	runGetTests(t, "GetInt()", getIntTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetInt([]byte(test.json), test.path...)
+			return value, Number, err
 		},
-		func(test ParseTest, obtained interface{}) (bool, interface{}) {
-			expected := test.out.(bool)
-			return obtained.(bool) == expected, expected
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(int64)
+			return expected == value.(int64), expected
 		},
 	)
 }
 
-func TestParseFloat(t *testing.T) {
-	runParseTests(t, "ParseFloat()", parseFloatTest,
-		func(test ParseTest) (value interface{}, err error) {
-			return ParseFloat([]byte(test.in))
+func TestGetFloat(t *testing.T) {
+	runGetTests(t, "GetFloat()", getFloatTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetFloat([]byte(test.json), test.path...)
+			return value, Number, err
 		},
-		func(test ParseTest, obtained interface{}) (bool, interface{}) {
-			expected := test.out.(float64)
-			return obtained.(float64) == expected, expected
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(float64)
+			return expected == value.(float64), expected
 		},
 	)
 }
 
-var parseStringTest = []ParseTest{
+func TestGetBoolean(t *testing.T) {
+	runGetTests(t, "GetBoolean()", getBoolTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetBoolean([]byte(test.json), test.path...)
+			return value, Boolean, err
+		},
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(bool)
+			return expected == value.(bool), expected
+		},
+	)
+}
+
+var getBooleanNumericTests = []GetTest{
 	{
-		in:     `\uFF11`,
-		intype: String,
-		out:    "\uFF11",
+		desc:    `read 0 as false`,
+		json:    `{"a": 0}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    false,
 	},
 	{
-		in:     `\uFFFF`,
-		intype: String,
-		out:    "\uFFFF",
+		desc:    `read 1 as true`,
+		json:    `{"a": [1]}`,
+		path:    []string{"a", "[0]"},
+		isFound: true,
+		data:    true,
 	},
 	{
-		in:     `\uDF00`,
-		intype: String,
-		isErr:  true,
+		desc:    `read boolean`,
+		json:    `{"a": false}`,
+		path:    []string{"a"},
+		isFound: true,
+		data:    false,
+	},
+	{
+		desc:  `reject 2`,
+		json:  `{"a": 2}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `reject 1.0`,
+		json:  `{"a": 1.0}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `reject -0`,
+		json:  `{"a": -0}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `reject string`,
+		json:  `{"a": "1"}`,
+		path:  []string{"a"},
+		isErr: true,
+	},
+	{
+		desc:  `reject null`,
+		json:  `{"a": null}`,
+		path:  []string{"a"},
+		isErr: true,
 	},
 }
 
-func TestParseString(t *testing.T) {
-	runParseTests(t, "ParseString()", parseStringTest,
-		func(test ParseTest) (value interface{}, err error) {
-			return ParseString([]byte(test.in))
+func TestGetBooleanNumeric(t *testing.T) {
+	runGetTests(t, "GetBooleanNumeric()", getBooleanNumericTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, err = GetBooleanNumeric([]byte(test.json), test.path...)
+			return value, Boolean, err
 		},
-		func(test ParseTest, obtained interface{}) (bool, interface{}) {
-			expected := test.out.(string)
-			return obtained.(string) == expected, expected
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.(bool)
+			return expected == value.(bool), expected
+		},
+	)
+
+	// GetBoolean stays strict
+	if _, err := GetBoolean([]byte(`{"a": 1}`), "a"); err == nil {
+		t.Error("GetBoolean should not accept numbers")
+	}
+}
+
+func TestGetSlice(t *testing.T) {
+	runGetTests(t, "Get()-for-arrays", getArrayTests,
+		func(test GetTest) (value interface{}, dataType ValueType, err error) {
+			value, dataType, _, err = Get([]byte(test.json), test.path...)
+			return
+		},
+		func(test GetTest, value interface{}) (bool, interface{}) {
+			expected := test.data.([]string)
+			return reflect.DeepEqual(expected, toStringArray(value.([]byte))), expected
 		},
 	)
 }
+
+func TestArrayEach(t *testing.T) {
+	mock := []byte(`{"a": { "b":[{"x": 1} ,{"x":2},{ "x":3}, {"x":4} ]}}`)
+	count := 0
+
+	ArrayEach(mock, func(value []byte, dataType ValueType, offset int, err error) {
+		count++
+
+		switch count {
+		case 1:
+			if string(value) != `{"x": 1}` {
+				t.Errorf("Wrong first item: %s", string(value))
+			}
+		case 2:
+			if string(value) != `{"x":2}` {
+				t.Errorf("Wrong second item: %s", string(value))
+			}
+		case 3:
+			if string(value) != `{ "x":3}` {
+				t.Errorf("Wrong third item: %s", string(value))
+			}
+		case 4:
+			if string(value) != `{"x":4}` {
+				t.Errorf("Wrong forth item: %s", string(value))
+			}
+		default:
+			t.Errorf("Should process only 4 items")
+		}
+	}, "a", "b")
+}
+
+func TestArrayEachWithWhiteSpace(t *testing.T) {
+	// Issue #159
+	count := 0
+	funcError := func([]byte, ValueType, int, error) { t.Errorf("Run func not allow") }
+	funcSuccess := func(value []byte, dataType ValueType, index int, err error) {
+		count++
+
+		switch count {
+		case 1:
+			if string(value) != `AAA` {
+				t.Errorf("Wrong first item: %s", string(value))
+			}
+		case 2:
+			if string(value) != `BBB` {
+				t.Errorf("Wrong second item: %s", string(value))
+			}
+		case 3:
+			if string(value) != `CCC` {
+				t.Errorf("Wrong third item: %s", string(value))
+			}
+		default:
+			t.Errorf("Should process only 3 items")
+		}
+	}
+
+	type args struct {
+		data []byte
+		cb   func(value []byte, dataType ValueType, offset int, err error)
+		keys []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{"Array with white space", args{[]byte(`    ["AAA", "BBB", "CCC"]`), funcSuccess, []string{}}, false},
+		{"Array with only one character after white space", args{[]byte(`    1`), funcError, []string{}}, true},
+		{"Only white space", args{[]byte(`    `), funcError, []string{}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ArrayEach(tt.args.data, tt.args.cb, tt.args.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ArrayEach() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func TestArrayEachEmpty(t *testing.T) {
+	funcError := func([]byte, ValueType, int, error) { t.Errorf("Run func not allow") }
+
+	type args struct {
+		data []byte
+		cb   func(value []byte, dataType ValueType, offset int, err error)
+		keys []string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantOffset int
+		wantErr    bool
+	}{
+		{"Empty array", args{[]byte("[]"), funcError, []string{}}, 1, false},
+		{"Empty array with space", args{[]byte("[ ]"), funcError, []string{}}, 2, false},
+		{"Empty array with \n", args{[]byte("[\n]"), funcError, []string{}}, 2, false},
+		{"Empty field array", args{[]byte("{\"data\": []}"), funcError, []string{"data"}}, 10, false},
+		{"Empty field array with space", args{[]byte("{\"data\": [ ]}"), funcError, []string{"data"}}, 11, false},
+		{"Empty field array with \n", args{[]byte("{\"data\": [\n]}"), funcError, []string{"data"}}, 11, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOffset, err := ArrayEach(tt.args.data, tt.args.cb, tt.args.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ArrayEach() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotOffset != tt.wantOffset {
+				t.Errorf("ArrayEach() = %v, want %v", gotOffset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+type keyValueEntry struct {
+	key       string
+	value     string
+	valueType ValueType
+}
+
+func (kv keyValueEntry) String() string {
+	return fmt.Sprintf("[%s: %s (%s)]", kv.key, kv.value, kv.valueType)
+}
+
+type ObjectEachTest struct {
+	desc string
+	json string
+
+	isErr   bool
+	entries []keyValueEntry
+}
+
+var objectEachTests = []ObjectEachTest{
+	{
+		desc:    "empty object",
+		json:    `{}`,
+		entries: []keyValueEntry{},
+	},
+	{
+		desc: "single key-value object",
+		json: `{"key": "value"}`,
+		entries: []keyValueEntry{
+			{"key", "value", String},
+		},
+	},
+	{
+		desc: "multiple key-value object with many value types",
+		json: `{
+		  "key1": null,
+		  "key2": true,
+		  "key3": 1.23,
+		  "key4": "string value",
+		  "key5": [1,2,3],
+		  "key6": {"a":"b"}
+		}`,
+		entries: []keyValueEntry{
+			{"key1", "null", Null},
+			{"key2", "true", Boolean},
+			{"key3", "1.23", Number},
+			{"key4", "string value", String},
+			{"key5", "[1,2,3]", Array},
+			{"key6", `{"a":"b"}`, Object},
+		},
+	},
+	{
+		desc: "escaped key",
+		json: `{"key\"\\\/\b\f\n\r\t\u00B0": "value"}`,
+		entries: []keyValueEntry{
+			{"key\"\\/\b\f\n\r\t\u00B0", "value", String},
+		},
+	},
+	// Error cases
+	{
+		desc:  "no object present",
+		json:  ` \t\n\r`,
+		isErr: true,
+	},
+	{
+		desc:  "unmatched braces 1",
+		json:  `{`,
+		isErr: true,
+	},
+	{
+		desc:  "unmatched braces 2",
+		json:  `}`,
+		isErr: true,
+	},
+	{
+		desc:  "unmatched braces 3",
+		json:  `}{}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad key (number)",
+		json:  `{123: "value"}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad key (unclosed quote)",
+		json:  `{"key: 123}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad value (no value)",
+		json:  `{"key":}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad value (bogus value)",
+		json:  `{"key": notavalue}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad entry (missing colon)",
+		json:  `{"key" "value"}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad entry (no trailing comma)",
+		json:  `{"key": "value" "key2": "value2"}`,
+		isErr: true,
+	},
+	{
+		desc:  "bad entry (two commas)",
+		json:  `{"key": "value",, "key2": "value2"}`,
+		isErr: true,
+	},
+}
+
+func TestObjectEach(t *testing.T) {
+	for _, test := range objectEachTests {
+		if activeTest != "" && test.desc != activeTest {
+			continue
+		}
+
+		// Execute ObjectEach and capture all of the entries visited, in order
+		var entries []keyValueEntry
+		err := ObjectEach([]byte(test.json), func(key, value []byte, valueType ValueType, off int) error {
+			entries = append(entries, keyValueEntry{
+				key:       string(key),
+				value:     string(value),
+				valueType: valueType,
+			})
+			return nil
+		})
+
+		// Check the correctness of the result
+		isErr := (err != nil)
+		if test.isErr != isErr {
+			// If the call didn't match the error expectation, fail
+			t.Errorf("ObjectEach test '%s' isErr mismatch: expected %t, obtained %t (err %v)", test.desc, test.isErr, isErr, err)
+		} else if isErr {
+			// Else, if there was an expected error, don't fail and don't check anything further
+		} else if len(test.entries) != len(entries) {
+			t.Errorf("ObjectEach test '%s' mismatch in number of key-value entries: expected %d, obtained %d (entries found: %s)", test.desc, len(test.entries), len(entries), entries)
+		} else {
+			for i, entry := range entries {
+				expectedEntry := test.entries[i]
+				if expectedEntry.key != entry.key {
+					t.Errorf("ObjectEach test '%s' key mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.key, entry.key)
+					break
+				} else if expectedEntry.value != entry.value {
+					t.Errorf("ObjectEach test '%s' value mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.value, entry.value)
+					break
+				} else if expectedEntry.valueType != entry.valueType {
+					t.Errorf("ObjectEach test '%s' value type mismatch at entry %d: expected %s, obtained %s", test.desc, i, expectedEntry.valueType, entry.valueType)
+					break
+				} else {
+					// Success for this entry
+				}
+			}
+		}
+	}
+}
+
+var testJson = []byte(`{
+	"name": "Name", 
+	"order": "Order", 
+	"sum": 100, 
+	"len": 12, 
+	"isPaid": true, 
+	"nested": {"a":"test", "b":2, "nested3":{"a":"test3","b":4}, "c": "unknown"}, 
+	"nested2": {
+		"a":"test2", 
+		"b":3
+	}, 
+	"arr": [
+		{
+			"a":"zxc", 
+			"b": 1
+		}, 
+		{
+			"a":"123", 
+			"b":2
+		}
+	], 
+	"arrInt": [1,2,3,4], 
+	"intPtr": 10, 
+	"a\n":{
+		"b\n":99
+	}
+}`)
+
+func TestEachKey(t *testing.T) {
+	paths := [][]string{
+		{"name"},
+		{"order"},
+		{"nested", "a"},
+		{"nested", "b"},
+		{"nested2", "a"},
+		{"nested", "nested3", "b"},
+		{"arr", "[1]", "b"},
+		{"arrInt", "[3]"},
+		{"arrInt", "[5]"}, // Should not find last key
+		{"nested"},
+		{"arr", "["},    // issue#177 Invalid arguments
+		{"a\n", "b\n"},  // issue#165
+		{"nested", "b"}, // Should find repeated key
+	}
+
+	keysFound := 0
+
+	EachKey(testJson, func(idx int, value []byte, vt ValueType, err error) {
+		keysFound++
+
+		switch idx {
+		case 0:
+			if string(value) != "Name" {
+				t.Error("Should find 1 key", string(value))
+			}
+		case 1:
+			if string(value) != "Order" {
+				t.Errorf("Should find 2 key")
+			}
+		case 2:
+			if string(value) != "test" {
+				t.Errorf("Should find 3 key")
+			}
+		case 3:
+			if string(value) != "2" {
+				t.Errorf("Should find 4 key")
+			}
+		case 4:
+			if string(value) != "test2" {
+				t.Error("Should find 5 key", string(value))
+			}
+		case 5:
+			if string(value) != "4" {
+				t.Errorf("Should find 6 key")
+			}
+		case 6:
+			if string(value) != "2" {
+				t.Errorf("Should find 7 key")
+			}
+		case 7:
+			if string(value) != "4" {
+				t.Error("Should find 8 key", string(value))
+			}
+		case 8:
+			t.Errorf("Found key #8 that should not be found")
+		case 9:
+			if string(value) != `{"a":"test", "b":2, "nested3":{"a":"test3","b":4}, "c": "unknown"}` {
+				t.Error("Should find 9 key", string(value))
+			}
+		case 10:
+			t.Errorf("Found key #10 that should not be found")
+		case 11:
+			if string(value) != "99" {
+				t.Error("Should find 10 key", string(value))
+			}
+		case 12:
+			if string(value) != "2" {
+				t.Errorf("Should find 11 key")
+			}
+		default:
+			t.Errorf("Should find only 10 keys, got %v key", idx)
+		}
+	}, paths...)
+
+	if keysFound != 11 {
+		t.Errorf("Should find 11 keys: %d", keysFound)
+	}
+}
+
+type ParseTest struct {
+	in     string
+	intype ValueType
+	out    interface{}
+	isErr  bool
+}
+
+var parseBoolTests = []ParseTest{
+	{
+		in:     "true",
+		intype: Boolean,
+		out:    true,
+	},
+	{
+		in:     "false",
+		intype: Boolean,
+		out:    false,
+	},
+	{
+		in:     "foo",
+		intype: Boolean,
+		isErr:  true,
+	},
+	{
+		in:     "trux",
+		intype: Boolean,
+		isErr:  true,
+	},
+	{
+		in:     "truex",
+		intype: Boolean,
+		isErr:  true,
+	},
+	{
+		in:     "",
+		intype: Boolean,
+		isErr:  true,
+	},
+}
+
+var parseFloatTest = []ParseTest{
+	{
+		in:     "0",
+		intype: Number,
+		out:    float64(0),
+	},
+	{
+		in:     "0.0",
+		intype: Number,
+		out:    float64(0.0),
+	},
+	{
+		in:     "1",
+		intype: Number,
+		out:    float64(1),
+	},
+	{
+		in:     "1.234",
+		intype: Number,
+		out:    float64(1.234),
+	},
+	{
+		in:     "1.234e5",
+		intype: Number,
+		out:    float64(1.234e5),
+	},
+	{
+		in:     "-1.234e5",
+		intype: Number,
+		out:    float64(-1.234e5),
+	},
+	{
+		in:     "+1.234e5", // Note: + sign not allowed under RFC7159, but our parser accepts it since it uses strconv.ParseFloat
+		intype: Number,
+		out:    float64(1.234e5),
+	},
+	{
+		in:     "1.2.3",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "1..1",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "1a",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "",
+		intype: Number,
+		isErr:  true,
+	},
+}
+
+// parseTestCheckNoError checks the error return from Parse*() against the test case expectations.
+// Returns true the test should proceed to checking the actual data returned from Parse*(), or false if the test is finished.
+func parseTestCheckNoError(t *testing.T, testKind string, test ParseTest, value interface{}, err error) bool {
+	if isErr := (err != nil); test.isErr != isErr {
+		// If the call didn't match the error expectation, fail
+		t.Errorf("%s test '%s' isErr mismatch: expected %t, obtained %t (err %v). Obtained value: %v", testKind, test.in, test.isErr, isErr, err, value)
+		return false
+	} else if isErr {
+		// Else, if there was an error, don't fail and don't check isFound or the value
+		return false
+	} else {
+		// Else, there was no error and a value was found, so check the value
+		return true
+	}
+}
+
+func runParseTests(t *testing.T, testKind string, tests []ParseTest, runner func(ParseTest) (interface{}, error), resultChecker func(ParseTest, interface{}) (bool, interface{})) {
+	for _, test := range tests {
+		value, err := runner(test)
+
+		if parseTestCheckNoError(t, testKind, test, value, err) {
+			if test.out == nil {
+				t.Errorf("MALFORMED TEST: %v", test)
+				continue
+			}
+
+			if ok, expected := resultChecker(test, value); !ok {
+				if expectedBytes, ok := expected.([]byte); ok {
+					expected = string(expectedBytes)
+				}
+				if valueBytes, ok := value.([]byte); ok {
+					value = string(valueBytes)
+				}
+				t.Errorf("%s test '%s' expected to return value %v, but did returned %v instead", testKind, test.in, expected, value)
+			}
+		}
+	}
+}
+
+func TestParseBoolean(t *testing.T) {
+	runParseTests(t, "ParseBoolean()", parseBoolTests,
+		func(test ParseTest) (value interface{}, err error) {
+			return ParseBoolean([]byte(test.in))
+		},
+		func(test ParseTest, obtained interface{}) (bool, interface{}) {
+			expected := test.out.(bool)
+			return obtained.(bool) == expected, expected
+		},
+	)
+}
+
+func TestParseFloat(t *testing.T) {
+	runParseTests(t, "ParseFloat()", parseFloatTest,
+		func(test ParseTest) (value interface{}, err error) {
+			return ParseFloat([]byte(test.in))
+		},
+		func(test ParseTest, obtained interface{}) (bool, interface{}) {
+			expected := test.out.(float64)
+			return obtained.(float64) == expected, expected
+		},
+	)
+}
+
+var parseFloatStrictTest = []ParseTest{
+	{
+		in:     "0",
+		intype: Number,
+		out:    float64(0),
+	},
+	{
+		in:     "0.0",
+		intype: Number,
+		out:    float64(0.0),
+	},
+	{
+		in:     "1.234",
+		intype: Number,
+		out:    float64(1.234),
+	},
+	{
+		in:     "-1.234e5",
+		intype: Number,
+		out:    float64(-1.234e5),
+	},
+	{
+		in:     "1E-2",
+		intype: Number,
+		out:    float64(1e-2),
+	},
+	{
+		in:     "+1.234e5", // Not allowed under RFC7159
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "01",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "-01.5",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     ".5",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "5.",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "1e",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "0x1p-2",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "Inf",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "-",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "1.2.3",
+		intype: Number,
+		isErr:  true,
+	},
+	{
+		in:     "",
+		intype: Number,
+		isErr:  true,
+	},
+}
+
+func TestParseFloatStrict(t *testing.T) {
+	runParseTests(t, "ParseFloatStrict()", parseFloatStrictTest,
+		func(test ParseTest) (value interface{}, err error) {
+			return ParseFloatStrict([]byte(test.in))
+		},
+		func(test ParseTest, obtained interface{}) (bool, interface{}) {
+			expected := test.out.(float64)
+			return obtained.(float64) == expected, expected
+		},
+	)
+}
+
+var parseStringTest = []ParseTest{
+	{
+		in:     `\uFF11`,
+		intype: String,
+		out:    "\uFF11",
+	},
+	{
+		in:     `\uFFFF`,
+		intype: String,
+		out:    "\uFFFF",
+	},
+	{
+		in:     `\uDF00`,
+		intype: String,
+		isErr:  true,
+	},
+}
+
+func TestParseString(t *testing.T) {
+	runParseTests(t, "ParseString()", parseStringTest,
+		func(test ParseTest) (value interface{}, err error) {
+			return ParseString([]byte(test.in))
+		},
+		func(test ParseTest, obtained interface{}) (bool, interface{}) {
+			expected := test.out.(string)
+			return obtained.(string) == expected, expected
+		},
+	)
+}
+
+func TestGetBytesForKeyPath(t *testing.T) {
+	tests := []struct {
+		desc    string
+		json    string
+		path    []string
+		value   string
+		err     error
+		segment int // failed segment reported by GetExplain
+	}{
+		{desc: "found", json: `{"a":{"b":[1,2]}}`, path: []string{"a", "b", "[1]"}, value: "2", segment: -1},
+		{desc: "missing key", json: `{"a":{"b":1}}`, path: []string{"a", "c"}, err: KeyPathNotFoundError, segment: 1},
+		{desc: "missing intermediate key", json: `{"a":{"b":1}}`, path: []string{"x", "b"}, err: KeyPathNotFoundError, segment: 0},
+		{desc: "index out of range", json: `{"a":[1]}`, path: []string{"a", "[1]"}, err: KeyPathNotFoundError, segment: 1},
+		{desc: "key in number", json: `{"a":1}`, path: []string{"a", "b"}, err: PathTypeMismatchError, segment: 1},
+		{desc: "key in string", json: `{"a":{"b":"c"}}`, path: []string{"a", "b", "c", "d"}, err: PathTypeMismatchError, segment: 2},
+		{desc: "key in null", json: `{"a":null}`, path: []string{"a", "b"}, err: PathTypeMismatchError, segment: 1},
+		{desc: "key in array", json: `{"a":[{"b":1}]}`, path: []string{"a", "b"}, err: PathTypeMismatchError, segment: 1},
+		{desc: "index in object", json: `{"a":{"b":1}}`, path: []string{"a", "[0]"}, err: PathTypeMismatchError, segment: 1},
+		{desc: "key in scalar root", json: `true`, path: []string{"a"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "key in number root", json: ` 5 `, path: []string{"a"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "nested key in number root", json: `5`, path: []string{"a", "b"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "key in string root", json: `"a"`, path: []string{"a"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "key in boolean root", json: `false`, path: []string{"a"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "index in number root", json: `5`, path: []string{"[0]"}, err: PathTypeMismatchError, segment: 0},
+		{desc: "no keys in scalar root", json: `5`, value: "5", segment: -1},
+		{desc: "scalar in array element", json: `{"a":[1,2]}`, path: []string{"a", "[0]", "b"}, err: PathTypeMismatchError, segment: 2},
+	}
+
+	for _, test := range tests {
+		value, _, err := GetBytesForKeyPath([]byte(test.json), test.path...)
+		if err != test.err {
+			t.Errorf("GetBytesForKeyPath test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+		} else if err == nil && string(value) != test.value {
+			t.Errorf("GetBytesForKeyPath test '%s' expected %s, obtained %s", test.desc, test.value, value)
+		}
+
+		if value, _, segment, err := GetExplain([]byte(test.json), test.path...); err != test.err || segment != test.segment || err == nil && string(value) != test.value {
+			t.Errorf("GetExplain test '%s' expected %s, %d, %v, obtained %s, %d, %v", test.desc, test.value, test.segment, test.err, value, segment, err)
+		}
+
+		// Get itself only tells apart a scalar root, and keeps reporting a scalar deeper in the path as missing
+		getErr := KeyPathNotFoundError
+		if root := strings.TrimSpace(test.json); root[0] != '{' && root[0] != '[' {
+			getErr = NotTraversableError
+		}
+		if _, _, _, err := Get([]byte(test.json), test.path...); test.err != nil && err != getErr {
+			t.Errorf("Get test '%s' expected %v, obtained %v", test.desc, getErr, err)
+		}
+	}
+}
+
+func TestGetNotTraversable(t *testing.T) {
+	for _, root := range []string{`5`, ` -1.5e3 `, `"a"`, `"{\"a\":1}"`, `true`, `false`, `null`} {
+		if value, dataType, _, err := Get([]byte(root), "a"); err != NotTraversableError || dataType != NotExist {
+			t.Errorf("Get(%s, \"a\") expected NotTraversableError, obtained %s, %s, %v", root, value, dataType, err)
+		}
+		if _, err := GetInt([]byte(root), "a", "[0]"); err != NotTraversableError {
+			t.Errorf("GetInt(%s, \"a\", \"[0]\") expected NotTraversableError, obtained %v", root, err)
+		}
+	}
+
+	// Containers and empty input are unaffected
+	for _, test := range []struct {
+		json string
+		err  error
+	}{
+		{json: `{"b": 1}`, err: KeyPathNotFoundError},
+		{json: `[1]`, err: KeyPathNotFoundError},
+		{json: `{"a": 5}`, err: nil},
+		{json: ``, err: EmptyInputError},
+	} {
+		if _, _, _, err := Get([]byte(test.json), "a"); err != test.err {
+			t.Errorf("Get(%s, \"a\") expected %v, obtained %v", test.json, test.err, err)
+		}
+	}
+}
+
+func TestEmptyInput(t *testing.T) {
+	for _, in := range []string{"", " \n\t\r "} {
+		data := []byte(in)
+		noop := func([]byte, []byte, ValueType, int) error { return nil }
+
+		if _, _, _, err := Get(data); err != EmptyInputError {
+			t.Errorf("Get(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, _, _, err := Get(data, "a", "[0]"); err != EmptyInputError {
+			t.Errorf("Get(%q, path) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, err := GetString(data, "a"); err != EmptyInputError {
+			t.Errorf("GetString(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, err := ArrayEach(data, func([]byte, ValueType, int, error) {}); err != EmptyInputError {
+			t.Errorf("ArrayEach(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, err := ArrayEach(data, func([]byte, ValueType, int, error) {}, "a"); err != EmptyInputError {
+			t.Errorf("ArrayEach(%q, path) expected EmptyInputError, obtained %v", in, err)
+		}
+		if err := ObjectEach(data, noop); err != EmptyInputError {
+			t.Errorf("ObjectEach(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+		if err := ObjectEach(data, noop, "a"); err != EmptyInputError {
+			t.Errorf("ObjectEach(%q, path) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, err := Set(data, []byte("1"), "a"); err != EmptyInputError {
+			t.Errorf("Set(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+		if _, err := ArrayElementType(data, 0); err != EmptyInputError {
+			t.Errorf("ArrayElementType(%q) expected EmptyInputError, obtained %v", in, err)
+		}
+	}
+
+	// Missing values in non-empty documents are unaffected
+	if _, _, _, err := Get([]byte(`{}`), "a"); err != KeyPathNotFoundError {
+		t.Errorf("Get({}) expected KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, _, _, err := Get([]byte(`{"a": `), "a"); err != MalformedJsonError {
+		t.Errorf("Get of a truncated value expected MalformedJsonError, obtained %v", err)
+	}
+}
+
+func TestGetWithKey(t *testing.T) {
+	tests := []struct {
+		json   string
+		path   []string
+		rawKey string // "" when nil is expected
+		value  string
+		err    error
+	}{
+		{json: `{"some\u00B0key": 1}`, path: []string{"some°key"}, rawKey: `some\u00B0key`, value: `1`},
+		{json: `{"a\"b" : "x"}`, path: []string{`a"b`}, rawKey: `a\"b`, value: `x`},
+		{json: `{"a\\": {"b\/c":[true]}}`, path: []string{`a\`, "b/c"}, rawKey: `b\/c`, value: `[true]`},
+		{json: `{"a": [1, {"b" :` + "\n\t" + `2}]}`, path: []string{"a", "[1]", "b"}, rawKey: `b`, value: `2`},
+		{json: `{"a": [1, 2]}`, path: []string{"a", "[1]"}, value: `2`},
+		{json: `{"a": 1}`, value: `{"a": 1}`},
+		{json: `{"a": 1}`, path: []string{"b"}, err: KeyPathNotFoundError},
+	}
+
+	for _, test := range tests {
+		rawKey, value, _, err := GetWithKey([]byte(test.json), test.path...)
+		if err != test.err {
+			t.Errorf("GetWithKey(%s, %v) expected error %v, obtained %v", test.json, test.path, test.err, err)
+		} else if err == nil && string(value) != test.value {
+			t.Errorf("GetWithKey(%s, %v) expected value %s, obtained %s", test.json, test.path, test.value, value)
+		} else if err == nil && (string(rawKey) != test.rawKey || (test.rawKey == "") != (rawKey == nil)) {
+			t.Errorf("GetWithKey(%s, %v) expected key %q, obtained %q", test.json, test.path, test.rawKey, rawKey)
+		}
+	}
+}
+
+func TestGetWithParent(t *testing.T) {
+	tests := []struct {
+		json   string
+		path   []string
+		value  string
+		parent string
+		err    error
+	}{
+		{json: `{"a": {"b": {"c": 1}, "d": 2}}`, path: []string{"a", "b", "c"}, value: `1`, parent: `{"c": 1}`},
+		{json: `{"a": {"b": {"c": 1}, "d": 2}}`, path: []string{"a", "d"}, value: `2`, parent: `{"b": {"c": 1}, "d": 2}`},
+		{json: `{"a": [1, {"b": "x"}]}`, path: []string{"a", "[1]", "b"}, value: `x`, parent: `{"b": "x"}`},
+		{json: `{"a": [1, {"b": "x"}]}`, path: []string{"a", "[0]"}, value: `1`, parent: `[1, {"b": "x"}]`},
+		{json: ` {"a": 1} `, path: []string{"a"}, value: `1`, parent: `{"a": 1}`},
+		{json: `{"a": 1}`, value: `{"a": 1}`},
+		{json: `{"a": 1}`, path: []string{"a", "b"}, err: KeyPathNotFoundError},
+		{json: `{"a": {}}`, path: []string{"x", "b"}, err: KeyPathNotFoundError},
+		{json: `{"a": {}}`, path: []string{"a", "b"}, err: KeyPathNotFoundError},
+	}
+
+	for _, test := range tests {
+		value, parent, _, err := GetWithParent([]byte(test.json), test.path...)
+		if err != test.err {
+			t.Errorf("GetWithParent(%s, %v) expected error %v, obtained %v", test.json, test.path, test.err, err)
+		} else if err == nil && (string(value) != test.value || string(parent) != test.parent) {
+			t.Errorf("GetWithParent(%s, %v) expected %s in %s, obtained %s in %s", test.json, test.path, test.value, test.parent, value, parent)
+		}
+	}
+}
+
+func TestGetRootStrict(t *testing.T) {
+	tests := []struct {
+		json     string
+		value    string
+		dataType ValueType
+		err      error
+	}{
+		{json: ` {"a": 1} `, value: `{"a": 1}`, dataType: Object},
+		{json: "[1, 2]\n", value: `[1, 2]`, dataType: Array},
+		{json: `"x"`, value: `x`, dataType: String},
+		{json: ` 12 `, value: `12`, dataType: Number},
+		{json: `{"a":1} garbage`, err: TrailingDataError},
+		{json: `{"a":1}}`, err: TrailingDataError},
+		{json: `[1] [2]`, err: TrailingDataError},
+		{json: `[1],`, err: TrailingDataError},
+		{json: `1 2`, err: TrailingDataError},
+		{json: `true false`, err: TrailingDataError},
+		{json: `"x""y"`, err: TrailingDataError},
+		{json: `  `, err: EmptyInputError},
+	}
+
+	for _, test := range tests {
+		value, dataType, err := GetRootStrict([]byte(test.json))
+		if err != test.err {
+			t.Errorf("GetRootStrict(%s) expected error %v, obtained %v", test.json, test.err, err)
+		} else if err == nil && (string(value) != test.value || dataType != test.dataType) {
+			t.Errorf("GetRootStrict(%s) expected %s, %s, obtained %s, %s", test.json, test.value, test.dataType, value, dataType)
+		}
+	}
+}
+
+func TestTryGet(t *testing.T) {
+	tests := []struct {
+		json     string
+		path     []string
+		value    string
+		dataType ValueType
+		found    bool
+	}{
+		{json: `{"a": {"b": 1}}`, path: []string{"a", "b"}, value: "1", dataType: Number, found: true},
+		{json: `{"a": null}`, path: []string{"a"}, value: "null", dataType: Null, found: true},
+		{json: `{"a": ""}`, path: []string{"a"}, value: "", dataType: String, found: true},
+		{json: `{"a": {"b": 1}}`, path: []string{"a", "c"}, dataType: NotExist},
+		{json: `{"a": [1]}`, path: []string{"a", "[1]"}, dataType: NotExist},
+		{json: `{"a": "unterminated}`, path: []string{"a"}, dataType: NotExist},
+		{json: `{"a": tru}`, path: []string{"a"}, dataType: NotExist},
+		{json: ``, path: []string{"a"}, dataType: NotExist},
+	}
+	for _, test := range tests {
+		value, dataType, found := TryGet([]byte(test.json), test.path...)
+		if string(value) != test.value || dataType != test.dataType || found != test.found {
+			t.Errorf("TryGet(%s, %q) expected %q, %s, %t, obtained %q, %s, %t", test.json, test.path, test.value, test.dataType, test.found, value, dataType, found)
+		}
+		if !found && value != nil {
+			t.Errorf("TryGet(%s, %q) expected a nil value when not found", test.json, test.path)
+		}
+	}
+}
+
+func TestStep(t *testing.T) {
+	data := []byte(`{"1": "key", "list": ["a", {"1": [true, 2]}]}`)
+
+	// The same segment is a key in an object and an index in an array
+	if v, dt, err := Step(data, "1"); err != nil || string(v) != "key" || dt != String {
+		t.Errorf("Step into an object returned unexpected %s, %s, %v", v, dt, err)
+	}
+	list, _, _ := Step(data, "list")
+	if v, dt, err := Step(list, "1"); err != nil || string(v) != `{"1": [true, 2]}` || dt != Object {
+		t.Errorf("Step into an array returned unexpected %s, %s, %v", v, dt, err)
+	}
+
+	// Descending generically, segment by segment
+	v := data
+	for _, segment := range []string{"list", "1", "1", "0"} {
+		var err error
+		if v, _, err = Step(v, segment); err != nil {
+			t.Fatalf("Step(%s) failed: %v", segment, err)
+		}
+	}
+	if string(v) != "true" {
+		t.Errorf("Stepping down the tree expected true, obtained %s", v)
+	}
+
+	if _, _, err := Step(list, "2"); err != KeyPathNotFoundError {
+		t.Errorf("Step past the end of an array should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, _, err := Step(list, "[0]"); err != KeyPathNotFoundError {
+		t.Errorf("Step with a bracketed index should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, _, err := Step(data, "missing"); err != KeyPathNotFoundError {
+		t.Errorf("Step with a missing key should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+	if _, _, err := Step([]byte(` "x"`), "0"); err != PathTypeMismatchError {
+		t.Errorf("Step into a scalar should fail with PathTypeMismatchError, obtained %v", err)
+	}
+	if _, _, err := Step([]byte(" "), "0"); err != EmptyInputError {
+		t.Errorf("Step into empty input should fail with EmptyInputError, obtained %v", err)
+	}
+}
+
+func TestHasKey(t *testing.T) {
+	data := []byte(`{"enabled": null, "name": "x", "list": [{"a": false}], "bad": tru}`)
+
+	tests := []struct {
+		path     []string
+		expected bool
+	}{
+		{[]string{"enabled"}, true},
+		{[]string{"name"}, true},
+		{[]string{"list", "[0]", "a"}, true},
+		{[]string{"disabled"}, false},
+		{[]string{"list", "[1]"}, false},
+		{[]string{"name", "a"}, false},
+		{[]string{"bad"}, false},
+	}
+	for _, test := range tests {
+		if HasKey(data, test.path...) != test.expected {
+			t.Errorf("HasKey(%q) expected %t", test.path, test.expected)
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { HasKey(data, "list", "[0]", "a") }); allocs != 0 {
+		t.Errorf("HasKey expected no allocations, obtained %v", allocs)
+	}
+}
+
+func TestSafeGet(t *testing.T) {
+	data := []byte(`{"a": {"b": "value"}, "c": [1, 2]}`)
+
+	value, dataType, offset, err := SafeGet(data, "a", "b")
+	expectedValue, expectedType, expectedOffset, expectedErr := Get(data, "a", "b")
+	if string(value) != string(expectedValue) || dataType != expectedType || offset != expectedOffset || err != expectedErr {
+		t.Fatalf("SafeGet returned %s, %s, %d, %v; Get returned %s, %s, %d, %v", value, dataType, offset, err, expectedValue, expectedType, expectedOffset, expectedErr)
+	}
+
+	// The result doesn't alias the input, in either direction
+	value[0] = 'X'
+	if string(data) != `{"a": {"b": "value"}, "c": [1, 2]}` {
+		t.Errorf("modifying the SafeGet result changed the input: %s", data)
+	}
+	copy(data[13:], "12345")
+	if string(value) != "Xalue" {
+		t.Errorf("modifying the input changed the SafeGet result: %s", value)
+	}
+
+	if value, _, _, err := SafeGet(data, "missing"); value != nil || err != KeyPathNotFoundError {
+		t.Errorf("SafeGet of a missing key expected nil, KeyPathNotFoundError, obtained %s, %v", value, err)
+	}
+}
+
+func TestGetDetached(t *testing.T) {
+	data := []byte(`{"a": {"b": [1, "two"]}}`)
+
+	value, dataType, err := GetDetached(data, "a", "b")
+	if err != nil || dataType != Array || string(value) != `[1, "two"]` {
+		t.Fatalf("GetDetached returned unexpected %s, %s, %v", value, dataType, err)
+	}
+
+	// Simulate the mapping going away by clobbering the source buffer
+	for i := range data {
+		data[i] = 0
+	}
+	if string(value) != `[1, "two"]` {
+		t.Errorf("GetDetached value changed with its source: %q", value)
+	}
+
+	if value, _, err := GetDetached(data, "a"); value != nil || err == nil {
+		t.Errorf("GetDetached expected an error for invalid data, obtained %q, %v", value, err)
+	}
+}
+
+func TestGetManyCopy(t *testing.T) {
+	data := []byte(`{"a": {"b": "value"}, "c": [1, 2], "d": null}`)
+
+	values, dataTypes, err := GetManyCopy(data, []string{"a", "b"}, []string{"missing"}, []string{"c"}, []string{"c", "[1]"}, []string{"d"})
+	if err != nil {
+		t.Fatalf("GetManyCopy returned error %v", err)
+	}
+	expectedValues := []string{"value", "", "[1, 2]", "2", "null"}
+	expectedTypes := []ValueType{String, NotExist, Array, Number, Null}
+	for i := range expectedValues {
+		if string(values[i]) != expectedValues[i] || dataTypes[i] != expectedTypes[i] {
+			t.Errorf("GetManyCopy path %d expected %s, %s, obtained %s, %s", i, expectedValues[i], expectedTypes[i], values[i], dataTypes[i])
+		}
+	}
+	if values[1] != nil {
+		t.Errorf("GetManyCopy expected nil for a missing path, obtained %q", values[1])
+	}
+
+	// The values don't alias the input, and appending to one doesn't clobber the next
+	copy(data[13:], "12345")
+	values[0] = append(values[0], '!')
+	if string(values[0]) != "value!" || string(values[2]) != "[1, 2]" {
+		t.Errorf("GetManyCopy values changed: %s, %s", values[0], values[2])
+	}
+
+	if _, _, err := GetManyCopy([]byte(`{"a": "unterminated`), []string{"a"}); err == nil {
+		t.Errorf("GetManyCopy expected an error for malformed data")
+	}
+}
+
+func TestParseValueType(t *testing.T) {
+	names := map[string]ValueType{
+		"non-existent": NotExist,
+		"string":       String,
+		"number":       Number,
+		"object":       Object,
+		"array":        Array,
+		"boolean":      Boolean,
+		"null":         Null,
+		"unknown":      Unknown,
+	}
+	for name, expected := range names {
+		if vt, err := ParseValueType(name); err != nil || vt != expected {
+			t.Errorf("ParseValueType(%q) expected %s, obtained %s, %v", name, expected, vt, err)
+		}
+		if expected.String() != name {
+			t.Errorf("%s.String() doesn't round-trip to %q", expected, name)
+		}
+	}
+
+	for _, name := range []string{"", "bool", "String", " number"} {
+		if vt, err := ParseValueType(name); err == nil {
+			t.Errorf("ParseValueType(%q) expected an error, obtained %s", name, vt)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	data := []byte(`{"s": "", "s1": " ", "a": [ ], "a1": [0], "o": {
+	}, "o1": {"": null}, "n": null, "f": false, "t": true, "z": 0, "z1": -0.0e10, "z2": 0.001, "z3": 1e0, "z4": 10}`)
+
+	tests := []struct {
+		key           string
+		empty, zeroes bool // expected result without and with zeroIsEmpty
+	}{
+		{"s", true, true},
+		{"s1", false, false},
+		{"a", true, true},
+		{"a1", false, false},
+		{"o", true, true},
+		{"o1", false, false},
+		{"n", true, true},
+		{"f", false, true},
+		{"t", false, false},
+		{"z", false, true},
+		{"z1", false, true},
+		{"z2", false, false},
+		{"z3", false, false},
+		{"z4", false, false},
+	}
+	for _, test := range tests {
+		if empty, err := IsEmpty(data, false, test.key); err != nil || empty != test.empty {
+			t.Errorf("IsEmpty(%s) expected %t, obtained %t, %v", test.key, test.empty, empty, err)
+		}
+		if empty, err := IsEmpty(data, true, test.key); err != nil || empty != test.zeroes {
+			t.Errorf("IsEmpty(%s) with zeroIsEmpty expected %t, obtained %t, %v", test.key, test.zeroes, empty, err)
+		}
+	}
+
+	if _, err := IsEmpty(data, true, "missing"); err != KeyPathNotFoundError {
+		t.Errorf("IsEmpty of a missing key expected KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestGetLimited(t *testing.T) {
+	data := []byte(`{"s": "12345", "a": [1, 2], "n": 123}`)
+
+	tests := []struct {
+		key    string
+		maxLen int
+		value  string
+		err    error
+	}{
+		{"s", 5, "12345", nil},
+		{"s", 4, "", ValueTooLargeError},
+		{"a", 6, "[1, 2]", nil},
+		{"a", 5, "", ValueTooLargeError},
+		{"n", 4, "123", nil},
+		{"n", 2, "", ValueTooLargeError},
+		{"missing", 100, "", KeyPathNotFoundError},
+	}
+	for _, test := range tests {
+		value, _, err := GetLimited(data, test.maxLen, test.key)
+		if string(value) != test.value || err != test.err {
+			t.Errorf("GetLimited(%s, %d) expected %q, %v, obtained %q, %v", test.key, test.maxLen, test.value, test.err, value, err)
+		}
+	}
+}
+
+func TestGetAt(t *testing.T) {
+	data := []byte(`{"users": [{"name": "a"}, {"name": "b", "tags": ["x", "y"]}]}`)
+
+	tests := []struct {
+		path  []interface{}
+		value string
+		err   error
+	}{
+		{path: []interface{}{"users", 0, "name"}, value: "a"},
+		{path: []interface{}{"users", 1, "tags", 1}, value: "y"},
+		{path: []interface{}{"users", 2, "name"}, err: KeyPathNotFoundError},
+		{path: []interface{}{"users", -1}, err: KeyPathNotFoundError},
+		{path: []interface{}{"users", "0"}, err: KeyPathNotFoundError},
+	}
+	for _, test := range tests {
+		value, _, err := GetAt(data, test.path...)
+		if err != test.err {
+			t.Errorf("GetAt(%v) expected error %v, obtained %v", test.path, test.err, err)
+		} else if err == nil && string(value) != test.value {
+			t.Errorf("GetAt(%v) expected %s, obtained %s", test.path, test.value, value)
+		}
+	}
+
+	if _, _, err := GetAt(data, "users", int64(0)); err == nil || !strings.Contains(err.Error(), "int64") {
+		t.Errorf("GetAt should reject int64 segments with a clear error, obtained %v", err)
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	data := []byte(`{"s": "a\"b", "n": 1.5, "o": {"x": [1, 2]}, "b": false, "z": null}`)
+
+	tests := []struct {
+		key  string
+		raw  string
+		kind ValueType
+	}{
+		{"s", `"a\"b"`, String},
+		{"n", `1.5`, Number},
+		{"o", `{"x": [1, 2]}`, Object},
+		{"b", `false`, Boolean},
+		{"z", `null`, Null},
+	}
+	for _, test := range tests {
+		raw, err := GetRaw(data, test.key)
+		if err != nil {
+			t.Errorf("GetRaw(%s) returned error %v", test.key, err)
+			continue
+		}
+		if string(raw) != test.raw || raw.Type() != test.kind {
+			t.Errorf("GetRaw(%s) expected %s (%s), obtained %s (%s)", test.key, test.raw, test.kind, string(raw), raw.Type())
+		}
+	}
+
+	if _, err := GetRaw(data, "missing"); err != KeyPathNotFoundError {
+		t.Errorf("GetRaw on a missing key should return KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestRawClone(t *testing.T) {
+	data := []byte(`{"a":"value"}`)
+	raw, _ := GetRaw(data, "a")
+	clone := raw.Clone()
+
+	copy(data[6:], "XXXXX")
+	if string(raw) != `"XXXXX"` {
+		t.Errorf("Raw should alias the source data, obtained %s", string(raw))
+	}
+	if string(clone) != `"value"` {
+		t.Errorf("Cloned value should not change with the source data, obtained %s", string(clone))
+	}
+}
+
+var getNthTests = []struct {
+	desc  string
+	json  string
+	n     int
+	path  []string
+	value string
+	err   error
+}{
+	{desc: "first occurrence", json: `{"a":1,"a":2,"a":3}`, n: 0, path: []string{"a"}, value: `1`},
+	{desc: "last occurrence", json: `{"a":1,"a":2,"a":3}`, n: 2, path: []string{"a"}, value: `3`},
+	{desc: "too few occurrences", json: `{"a":1,"a":2,"a":3}`, n: 3, path: []string{"a"}, err: KeyPathNotFoundError},
+	{desc: "nested duplicates", json: `{"h":{"x":"1","y":0,"x":"2"},"x":"3"}`, n: 1, path: []string{"h", "x"}, value: `2`},
+	{desc: "nested keys are not counted", json: `{"b":{"a":0},"a":1}`, n: 0, path: []string{"a"}, value: `1`},
+	{desc: "escaped key", json: `{"a\u00b0":1,"a\u00b0":2}`, n: 1, path: []string{"a°"}, value: `2`},
+	{desc: "array index", json: `{"a":[5,6]}`, n: 0, path: []string{"a", "[1]"}, value: `6`},
+	{desc: "array index repeated", json: `{"a":[5,6]}`, n: 1, path: []string{"a", "[1]"}, err: KeyPathNotFoundError},
+	{desc: "missing parent", json: `{"a":1}`, n: 0, path: []string{"b", "a"}, err: KeyPathNotFoundError},
+}
+
+func TestGetNth(t *testing.T) {
+	for _, test := range getNthTests {
+		value, _, err := GetNth([]byte(test.json), test.n, test.path...)
+		if err != test.err {
+			t.Errorf("GetNth test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+		} else if err == nil && string(value) != test.value {
+			t.Errorf("GetNth test '%s' expected %s, obtained %s", test.desc, test.value, string(value))
+		}
+	}
+}
+
+func TestGetUnion(t *testing.T) {
+	data := []byte(`{"a": {"x": {"c": 2}, "y": [{"c": 3}], "s": "{\\"c\\": 4}"}, "d": 5}`)
+	tests := []struct {
+		desc         string
+		alternatives [][]string
+		value        string
+		matched      int
+		err          error
+	}{
+		{
+			desc:         "first alternative missing",
+			alternatives: [][]string{{"a", "b", "c"}, {"a", "x", "c"}},
+			value:        "2", matched: 1,
+		},
+		{
+			desc:         "first hit wins",
+			alternatives: [][]string{{"a", "x", "c"}, {"d"}},
+			value:        "2", matched: 0,
+		},
+		{
+			desc:         "overlapping prefixes",
+			alternatives: [][]string{{"a", "x", "b"}, {"a", "x", "z"}, {"a", "y", "[1]", "c"}, {"a", "y", "[0]", "c"}},
+			value:        "3", matched: 3,
+		},
+		{
+			desc:         "shared prefix already failed",
+			alternatives: [][]string{{"b", "x"}, {"b", "y"}, {"d"}},
+			value:        "5", matched: 2,
+		},
+		{
+			desc:         "alternative after a skipped one",
+			alternatives: [][]string{{"b", "x"}, {"b", "y"}, {"b"}, {"a", "x", "c"}},
+			value:        "2", matched: 3,
+		},
+		{
+			desc:         "key in a string",
+			alternatives: [][]string{{"a", "s", "c"}},
+			matched:      -1, err: KeyPathNotFoundError,
+		},
+		{
+			desc:         "empty path",
+			alternatives: [][]string{{"b"}, {}},
+			value:        string(data), matched: 1,
+		},
+		{
+			desc:    "no alternatives",
+			matched: -1, err: KeyPathNotFoundError,
+		},
+	}
+
+	for _, test := range tests {
+		value, _, matched, err := GetUnion(data, test.alternatives)
+		if err != test.err || matched != test.matched || string(value) != test.value {
+			t.Errorf("GetUnion test '%s' expected %s, %d, %v, obtained %s, %d, %v", test.desc, test.value, test.matched, test.err, value, matched, err)
+		}
+	}
+
+	if _, _, matched, err := GetUnion([]byte(`{"a": {"b": tru}}`), [][]string{{"a", "b"}}); err == nil || matched != -1 {
+		t.Errorf("GetUnion on malformed JSON expected an error, obtained %d, %v", matched, err)
+	}
+}
+
+func TestGetAnyKey(t *testing.T) {
+	data := []byte(`{"rec": {"name": "x", "uuid": "u-1", "id": 7, "id": 8, "ref": null}, "list": []}`)
+
+	tests := []struct {
+		candidates []string
+		path       []string
+		key        string
+		value      string
+		dataType   ValueType
+		err        error
+	}{
+		{candidates: []string{"id", "uuid"}, path: []string{"rec"}, key: "id", value: "7", dataType: Number},
+		{candidates: []string{"guid", "uuid", "id"}, path: []string{"rec"}, key: "uuid", value: "u-1", dataType: String},
+		{candidates: []string{"ref", "name"}, path: []string{"rec"}, key: "ref", value: "null", dataType: Null},
+		{candidates: []string{"a", "b"}, path: []string{"rec"}, err: KeyPathNotFoundError},
+		{candidates: nil, path: []string{"rec"}, err: KeyPathNotFoundError},
+		{candidates: []string{"rec"}, key: "rec", value: `{"name": "x", "uuid": "u-1", "id": 7, "id": 8, "ref": null}`, dataType: Object},
+		{candidates: []string{"id"}, path: []string{"missing"}, err: KeyPathNotFoundError},
+		{candidates: []string{"id"}, path: []string{"list"}, err: MalformedObjectError},
+	}
+	for _, test := range tests {
+		key, value, dataType, err := GetAnyKey(data, test.candidates, test.path...)
+		if key != test.key || string(value) != test.value || dataType != test.dataType || err != test.err {
+			t.Errorf("GetAnyKey(%q, %q) expected %s, %s, %s, %v, obtained %s, %s, %s, %v",
+				test.candidates, test.path, test.key, test.value, test.dataType, test.err, key, value, dataType, err)
+		}
+	}
+}
+
+func TestLastKey(t *testing.T) {
+	tests := []struct {
+		json     string
+		path     []string
+		key      string
+		value    string
+		dataType ValueType
+		err      error
+	}{
+		{json: `{"a":1,"b":"two","c":{"d":3}}`, key: "c", value: `{"d":3}`, dataType: Object},
+		{json: `{"x":{"a":1,"b\u00b0":"two" }}`, path: []string{"x"}, key: "b°", value: "two", dataType: String},
+		{json: `{"a":1,"a":2}`, key: "a", value: "2", dataType: Number},
+		{json: `{ }`, err: KeyPathNotFoundError},
+		{json: `{"a":1}`, path: []string{"b"}, err: KeyPathNotFoundError},
+		{json: `[1,2]`, err: MalformedObjectError},
+	}
+
+	for _, test := range tests {
+		key, value, dataType, err := LastKey([]byte(test.json), test.path...)
+		if err != test.err {
+			t.Errorf("LastKey(%s) expected error %v, obtained %v", test.json, test.err, err)
+		} else if string(key) != test.key || string(value) != test.value || dataType != test.dataType {
+			t.Errorf("LastKey(%s) expected %s, %s, %s, obtained %s, %s, %s", test.json, test.key, test.value, test.dataType, key, value, dataType)
+		}
+	}
+}
+
+var arrayElementTypeTests = []struct {
+	desc     string
+	json     string
+	index    int
+	path     []string
+	dataType ValueType
+	value    string // returned by ArrayElement
+	err      error
+}{
+	{desc: "string", json: `["a\"]", 1]`, index: 0, dataType: String, value: `a\"]`},
+	{desc: "after escaped string", json: `["a\"]", 1]`, index: 1, dataType: Number, value: `1`},
+	{desc: "object after nested array", json: `[[1,[2]], {"a":[]}]`, index: 1, dataType: Object, value: `{"a":[]}`},
+	{desc: "array", json: ` [ 1 , [2] ]`, index: 1, dataType: Array, value: `[2]`},
+	{desc: "boolean", json: `[null,false]`, index: 1, dataType: Boolean, value: `false`},
+	{desc: "null", json: `[null,false]`, index: 0, dataType: Null, value: `null`},
+	{desc: "negative number", json: `[-1]`, index: 0, dataType: Number, value: `-1`},
+	{desc: "nested path", json: `{"a":{"b":[{},"x"]}}`, index: 1, path: []string{"a", "b"}, dataType: String, value: `x`},
+	{desc: "out of range", json: `[1,2]`, index: 2, err: KeyPathNotFoundError},
+	{desc: "negative index", json: `[1,2]`, index: -1, err: KeyPathNotFoundError},
+	{desc: "empty array", json: `[]`, index: 0, err: KeyPathNotFoundError},
+	{desc: "missing path", json: `{"a":[1]}`, index: 0, path: []string{"b"}, err: KeyPathNotFoundError},
+	{desc: "not an array", json: `{"a":{}}`, index: 0, path: []string{"a"}, err: MalformedArrayError},
+	{desc: "unterminated", json: `[1,2`, index: 3, err: MalformedArrayError},
+	{desc: "unknown type", json: `[1,x]`, index: 1, err: UnknownValueTypeError},
+}
+
+func TestArrayElementType(t *testing.T) {
+	for _, test := range arrayElementTypeTests {
+		dataType, err := ArrayElementType([]byte(test.json), test.index, test.path...)
+		if err != test.err {
+			t.Errorf("ArrayElementType test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+		} else if err == nil && dataType != test.dataType {
+			t.Errorf("ArrayElementType test '%s' expected %s, obtained %s", test.desc, test.dataType, dataType)
+		}
+	}
+}
+
+func TestArrayElement(t *testing.T) {
+	for _, test := range arrayElementTypeTests {
+		value, dataType, err := ArrayElement([]byte(test.json), test.index, test.path...)
+		if err != test.err {
+			t.Errorf("ArrayElement test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+		} else if err == nil && (dataType != test.dataType || string(value) != test.value) {
+			t.Errorf("ArrayElement test '%s' expected %s %s, obtained %s %s", test.desc, test.dataType, test.value, dataType, value)
+		}
+	}
+}
+
+func TestArrayEachFrom(t *testing.T) {
+	data := []byte(` [1, "two", {"three": 3}, [4] ,5 ]`)
+
+	var values []string
+	var offsets []int
+	offset := 0
+	calls := 0
+	for offset < len(data) && calls < 10 {
+		calls++
+		next, err := ArrayEachFrom(data, offset, func(value []byte, dataType ValueType, off int) bool {
+			values = append(values, string(value))
+			offsets = append(offsets, off)
+			return len(values)%2 != 0 // pause after every second element
+		})
+		if err != nil {
+			t.Fatalf("ArrayEachFrom returned error %v at offset %d", err, offset)
+		}
+		if next == offset {
+			break
+		}
+		offset = next
+	}
+
+	expected := []string{`1`, `two`, `{"three": 3}`, `[4]`, `5`}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("ArrayEachFrom expected %v, obtained %v", expected, values)
+	}
+	if offset != len(data) {
+		t.Errorf("ArrayEachFrom should end past the closing bracket (%d), ended at %d", len(data), offset)
+	}
+	if calls != 3 {
+		t.Errorf("ArrayEachFrom should have been called 3 times, was called %d times", calls)
+	}
+	for i, off := range offsets {
+		if v, _, _, _ := Get(data[off:]); string(v) != expected[i] {
+			t.Errorf("ArrayEachFrom offset %d does not point at element %s", off, expected[i])
+		}
+	}
+
+	// Resuming after the end is a no-op
+	if next, err := ArrayEachFrom(data, offset, func([]byte, ValueType, int) bool {
+		t.Error("callback should not be invoked on an exhausted array")
+		return true
+	}); err != nil || next != offset {
+		t.Errorf("ArrayEachFrom on an exhausted array returned %d, %v", next, err)
+	}
+}
+
+func TestArrayEachAbs(t *testing.T) {
+	data := []byte(`{"a": {"b": [1, "two", {"c": [true, null]}, [3]]}}`)
+
+	var values []string
+	end, err := ArrayEachAbs(data, func(value []byte, dataType ValueType, absOffset int, err error) {
+		// Every offset locates the element in the original document
+		text := string(value)
+		if dataType == String {
+			text = `"` + text + `"`
+		}
+		if !bytes.HasPrefix(data[absOffset:], []byte(text)) {
+			t.Errorf("ArrayEachAbs offset %d doesn't point at element %s", absOffset, text)
+		}
+		values = append(values, text)
+	}, "a", "b")
+	if err != nil || end != len(data)-3 || data[end] != ']' {
+		t.Errorf("ArrayEachAbs returned unexpected %d, %v", end, err)
+	}
+	if expected := []string{`1`, `"two"`, `{"c": [true, null]}`, `[3]`}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("ArrayEachAbs expected %q, obtained %q", expected, values)
+	}
+
+	var offsets []int
+	ArrayEachAbs(data, func(value []byte, dataType ValueType, absOffset int, err error) {
+		offsets = append(offsets, absOffset)
+	}, "a", "b", "[2]", "c")
+	if expected := []int{strings.Index(string(data), "true"), strings.Index(string(data), "null")}; !reflect.DeepEqual(offsets, expected) {
+		t.Errorf("ArrayEachAbs of a nested array expected offsets %v, obtained %v", expected, offsets)
+	}
+
+	if _, err := ArrayEachAbs(data, func([]byte, ValueType, int, error) {}, "a"); err != MalformedArrayError {
+		t.Errorf("ArrayEachAbs of an object should fail with MalformedArrayError, obtained %v", err)
+	}
+	if _, err := ArrayEachAbs(data, func([]byte, ValueType, int, error) {}, "x"); err != KeyPathNotFoundError {
+		t.Errorf("ArrayEachAbs of a missing key should fail with KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestArrayEachFromErrors(t *testing.T) {
+	noop := func([]byte, ValueType, int) bool { return true }
+
+	if next, err := ArrayEachFrom([]byte(`[]`), 0, noop); err != nil || next != 2 {
+		t.Errorf("ArrayEachFrom on an empty array returned %d, %v", next, err)
+	}
+	if _, err := ArrayEachFrom([]byte(`{"a":1}`), 0, noop); err != MalformedArrayError {
+		t.Errorf("ArrayEachFrom on an object should return MalformedArrayError, obtained %v", err)
+	}
+	if _, err := ArrayEachFrom([]byte(`[1,2`), 0, noop); err == nil {
+		t.Error("ArrayEachFrom on an unterminated array should return an error")
+	}
+	if _, err := ArrayEachFrom([]byte(`[1,2]`), 1, noop); err != MalformedArrayError {
+		t.Errorf("ArrayEachFrom from a non-boundary offset should return MalformedArrayError, obtained %v", err)
+	}
+}
+
+func TestNormalizeNumbers(t *testing.T) {
+	tests := []struct {
+		in    string
+		out   string
+		isErr bool
+	}{
+		{in: `1`, out: `1`},
+		{in: `-12.50`, out: `-12.5`},
+		{in: `1.0`, out: `1`},
+		{in: `007`, out: `7`},
+		{in: `0.000`, out: `0`},
+		{in: `-0`, out: `0`},
+		{in: `-0.0e7`, out: `0`},
+		{in: `0.25`, out: `0.25`},
+		{in: `1E+05`, out: `1e5`},
+		{in: `1e-007`, out: `1e-7`},
+		{in: `2.500E-0`, out: `2.5`},
+		{in: `100`, out: `100`},
+		{
+			in:  `{"a" : [1.10, {"b":-0.0, "c": "1.0 and 1E+2"}, [[2E2]]], "d":true, "e":null, "f\"1.0": 03 }`,
+			out: `{"a" : [1.1, {"b":0, "c": "1.0 and 1E+2"}, [[2e2]]], "d":true, "e":null, "f\"1.0": 3 }`,
+		},
+		{in: `[1.]`, isErr: true},
+		{in: `[-]`, isErr: true},
+		{in: `[1e]`, isErr: true},
+		{in: `[1e+]`, isErr: true},
+		{in: `[.5]`, out: `[.5]`}, // not a number token, left alone
+		{in: `[1.2.3]`, isErr: true},
+		{in: `[1-2]`, isErr: true},
+		{in: `["1.0]`, isErr: true},
+	}
+
+	for _, test := range tests {
+		out, err := NormalizeNumbers([]byte(test.in))
+		if isErr := (err != nil); isErr != test.isErr {
+			t.Errorf("NormalizeNumbers(%s) isErr mismatch: expected %t, obtained %t (err %v)", test.in, test.isErr, isErr, err)
+		} else if !isErr && string(out) != test.out {
+			t.Errorf("NormalizeNumbers(%s) expected %s, obtained %s", test.in, test.out, out)
+		}
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	data := []byte(`{
+		"id": 1,
+		"user": {"id": 2, "name": "x", "tags": [{"id": 3}, "id", {"nested": {"id": {"id": 4}}}]},
+		"items": [[{"id": "five"}]],
+		"other": {"ids": 6}
+	}`)
+
+	var paths, values []string
+	err := FindAll(data, "id", func(path []string, value []byte, dataType ValueType) {
+		paths = append(paths, strings.Join(path, "."))
+		values = append(values, string(value))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPaths := []string{"id", "user.id", "user.tags.[0].id", "user.tags.[2].nested.id", "user.tags.[2].nested.id.id", "items.[0].[0].id"}
+	expectedValues := []string{"1", "2", "3", `{"id": 4}`, "4", "five"}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("FindAll expected paths %v, obtained %v", expectedPaths, paths)
+	}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("FindAll expected values %v, obtained %v", expectedValues, values)
+	}
+
+	if err := FindAll([]byte(`{"a":[{"id":1},{"id"}]}`), "id", func([]string, []byte, ValueType) {}); err == nil {
+		t.Error("FindAll should report malformed JSON")
+	}
+}
+
+func TestArrayLen(t *testing.T) {
+	tests := []struct {
+		json   string
+		path   []string
+		length int
+		isErr  bool
+	}{
+		{json: `[]`, length: 0},
+		{json: `[ ]`, length: 0},
+		{json: `[1]`, length: 1},
+		{json: `[1, "a,b", "\",", null]`, length: 4},
+		{json: `[[1, 2, [3, 4]], {"a": [5, 6], "b": ","}, [], {}]`, length: 4},
+		{json: `{"a": {"b": [[1], [2, 3], "]"]}}`, path: []string{"a", "b"}, length: 3},
+		{json: `{"a": 1}`, path: []string{"a"}, isErr: true},
+		{json: `{"a": 1}`, isErr: true},
+		{json: `{"a": 1}`, path: []string{"b"}, isErr: true},
+		{json: `[{]`, isErr: true},
+		{json: `[1,{,2]`, isErr: true},
+		{json: `{"a": [[}]}`, path: []string{"a"}, isErr: true},
+	}
+
+	for _, test := range tests {
+		n, err := ArrayLen([]byte(test.json), test.path...)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("ArrayLen(%s, %q) expected an error, obtained %d", test.json, test.path, n)
+			}
+			continue
+		}
+		if err != nil || n != test.length {
+			t.Errorf("ArrayLen(%s, %q) expected %d, obtained %d, %v", test.json, test.path, test.length, n, err)
+		}
+
+		var count int
+		ArrayEach([]byte(test.json), func([]byte, ValueType, int, error) { count++ }, test.path...)
+		if count != n {
+			t.Errorf("ArrayLen(%s, %q) returned %d, but ArrayEach visited %d elements", test.json, test.path, n, count)
+		}
+	}
+}
+
+func TestConcatArrays(t *testing.T) {
+	tests := []struct {
+		arrays []string
+		out    string
+		isErr  bool
+	}{
+		{arrays: nil, out: `[]`},
+		{arrays: []string{`[]`, ` [ ] `}, out: `[]`},
+		{arrays: []string{`[1, 2]`}, out: `[1, 2]`},
+		{arrays: []string{`[1]`, `[]`, `[ "a", {"b": [3]} ]`, `[[]]`}, out: `[1,"a", {"b": [3]},[]]`},
+		{arrays: []string{`[]`, "\n[true]\n"}, out: `[true]`},
+		{arrays: []string{`[1]`, `{"a": [2]}`}, isErr: true},
+		{arrays: []string{`[1]`, `"[2]"`}, isErr: true},
+		{arrays: []string{`[1] [2]`}, isErr: true},
+		{arrays: []string{`[1`}, isErr: true},
+		{arrays: []string{``}, isErr: true},
+	}
+
+	for _, test := range tests {
+		var arrays [][]byte
+		for _, a := range test.arrays {
+			arrays = append(arrays, []byte(a))
+		}
+		out, err := ConcatArrays(arrays...)
+		if isErr := err != nil; isErr != test.isErr {
+			t.Errorf("ConcatArrays(%q) isErr mismatch: expected %t, obtained %t (err %v)", test.arrays, test.isErr, isErr, err)
+		} else if !isErr && string(out) != test.out {
+			t.Errorf("ConcatArrays(%q) expected %s, obtained %s", test.arrays, test.out, out)
+		}
+	}
+}
+
+func TestStringArrayLen(t *testing.T) {
+	tests := []struct {
+		json   string
+		path   []string
+		length int
+		isErr  bool
+	}{
+		{json: `[]`, length: 0},
+		{json: `{"a": ["x", "y,\"]", ""]}`, path: []string{"a"}, length: 3},
+		{json: `{"a": ["x", 1, "z"]}`, path: []string{"a"}, isErr: true},
+		{json: `["x", null]`, isErr: true},
+		{json: `["x", ["y"]]`, isErr: true},
+		{json: `["x", "y`, isErr: true},
+		{json: `{"a": "x"}`, path: []string{"a"}, isErr: true},
+		{json: `{"a": ["x"]}`, path: []string{"b"}, isErr: true},
+	}
+
+	for _, test := range tests {
+		n, err := StringArrayLen([]byte(test.json), test.path...)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("StringArrayLen(%s, %q) expected an error, obtained %d", test.json, test.path, n)
+			}
+			continue
+		}
+		if err != nil || n != test.length {
+			t.Errorf("StringArrayLen(%s, %q) expected %d, obtained %d, %v", test.json, test.path, test.length, n, err)
+		}
+	}
+}
+
+func TestGetDescendant(t *testing.T) {
+	data := []byte(`{
+		"a": {"target": 1},
+		"b": {"x": {"y": [{"z": {"target": "deep"}}]}, "target": "shallow"},
+		"c": [[{"target": {"target": true}}]],
+		"d": {"none": [1, "target"]}
+	}`)
+
+	tests := []struct {
+		path     []string
+		value    string
+		dataType ValueType
+		err      error
+	}{
+		{path: nil, value: "1", dataType: Number},
+		{path: []string{"a"}, value: "1", dataType: Number},
+		{path: []string{"b"}, value: "deep", dataType: String},
+		{path: []string{"b", "x", "y", "[0]"}, value: "deep", dataType: String},
+		{path: []string{"c"}, value: `{"target": true}`, dataType: Object},
+		{path: []string{"c", "[0]", "[0]", "target"}, value: "true", dataType: Boolean},
+		{path: []string{"d"}, err: KeyPathNotFoundError},
+		{path: []string{"a", "target"}, err: KeyPathNotFoundError},
+		{path: []string{"missing"}, err: KeyPathNotFoundError},
+	}
+	for _, test := range tests {
+		value, dataType, err := GetDescendant(data, "target", test.path...)
+		if string(value) != test.value || dataType != test.dataType || err != test.err {
+			t.Errorf("GetDescendant(%q) expected %s, %s, %v, obtained %s, %s, %v", test.path, test.value, test.dataType, test.err, value, dataType, err)
+		}
+	}
+}
+
+func TestDeeplyNested(t *testing.T) {
+	// Neither the search nor the lookups recurse per level of nesting; the search stops at MaxNestingDepth, which this stays below
+	const depth = 3000
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		b.WriteString(`{"k": [`)
+	}
+	b.WriteString(`"leaf"`)
+	for i := 0; i < depth; i++ {
+		b.WriteString(`]}`)
+	}
+	data := []byte(b.String())
+
+	var matches int
+	var lastPath []string
+	err := FindAll(data, "k", func(path []string, value []byte, dataType ValueType) {
+		matches++
+		lastPath = path
+	})
+	if err != nil || matches != depth || len(lastPath) != 2*depth-1 {
+		t.Errorf("FindAll on deeply nested data returned %d matches, last path length %d, %v", matches, len(lastPath), err)
+	}
+
+	keys := make([]string, 0, 2*depth)
+	for i := 0; i < depth; i++ {
+		keys = append(keys, "k", "[0]")
+	}
+	if v, dataType, _, err := Get(data, keys...); err != nil || string(v) != "leaf" || dataType != String {
+		t.Errorf("Get through %d nested arrays returned unexpected %s, %s, %v", depth, v, dataType, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	data := []byte(`{
+		"name": "a:b", "tags": ["x", "y\"]"], "n": -1.5e3,
+		"nested": {"ok": true, "no": false, "none": null, "list": [[1, 2], {"k": {}}]},
+		"empty": []
+	}`)
+
+	st, err := Stats(data)
+	expected := ParseStats{
+		Objects:  4,
+		Arrays:   4,
+		Keys:     10,
+		Strings:  3,
+		Numbers:  3,
+		Booleans: 2,
+		Nulls:    1,
+		MaxDepth: 5,
+	}
+	if err != nil || st != expected {
+		t.Errorf("Stats expected %+v, obtained %+v, %v", expected, st, err)
+	}
+	if depth, _ := Depth(data); depth != st.MaxDepth {
+		t.Errorf("Stats MaxDepth %d differs from Depth %d", st.MaxDepth, depth)
+	}
+
+	if st, err := Stats([]byte(` "x" `)); err != nil || st != (ParseStats{Strings: 1}) {
+		t.Errorf("Stats of a string returned unexpected %+v, %v", st, err)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { Stats(data) }); allocs != 0 {
+		t.Errorf("Stats expected no allocations, obtained %v", allocs)
+	}
+
+	for _, json := range []string{``, ` `, `{"a": "x}`, `{"a": [1}`, `[1]]`} {
+		if st, err := Stats([]byte(json)); err == nil {
+			t.Errorf("Stats(%s) should fail, obtained %+v", json, st)
+		}
+	}
+}
+
+func TestMaxNestingDepth(t *testing.T) {
+	defer func(max int) { MaxNestingDepth = max }(MaxNestingDepth)
+	MaxNestingDepth = 3
+
+	// nested returns `depth` objects nested in one another, with `leaf` as the innermost value
+	nested := func(depth int, leaf string) (data []byte, value interface{}) {
+		data, value = []byte(leaf), leaf
+		for i := 0; i < depth; i++ {
+			data, value = []byte(`{"k":`+string(data)+`}`), map[string]interface{}{"k": value}
+		}
+		return data, value
+	}
+
+	for depth := 2; depth <= 4; depth++ {
+		var expected error
+		if depth > MaxNestingDepth {
+			expected = MaxDepthExceededError
+		}
+		data, value := nested(depth, "1")
+		changed, _ := nested(depth, "2")
+
+		if err := FindAll(data, "k", func([]string, []byte, ValueType) {}); err != expected {
+			t.Errorf("FindAll at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+		if _, _, err := GetDescendant(data, "x"); expected != nil && err != expected {
+			t.Errorf("GetDescendant at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+		if err := Query(data, `$..k`, func([]byte, ValueType) {}); err != expected {
+			t.Errorf("Query at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+		if _, err := Patch(data, changed); err != expected {
+			t.Errorf("Patch at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+		var p Parser
+		if _, err := p.GetAny(data); err != expected {
+			t.Errorf("GetAny at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+		if _, err := Marshal(value); err != expected {
+			t.Errorf("Marshal at depth %d expected %v, obtained %v", depth, expected, err)
+		}
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		json  string
+		path  []string
+		depth int
+		isErr bool
+	}{
+		{json: `1`, depth: 0},
+		{json: `"[{"`, depth: 0},
+		{json: `{}`, depth: 1},
+		{json: `{"a":1}`, depth: 1},
+		{json: `{"a":{"b":1}}`, depth: 2},
+		{json: `[[1],[[2]]]`, depth: 3},
+		{json: `{"a":[{"b":"}]]"}],"c":{}}`, depth: 3},
+		{json: `{"a":[{"b":"}]]"}],"c":{}}`, path: []string{"a"}, depth: 2},
+		{json: `{"a":[{"b":"}]]"}],"c":{}}`, path: []string{"c"}, depth: 1},
+		{json: `{"a":[{"b":"}]]"}],"c":{}}`, path: []string{"a", "[0]", "b"}, depth: 0},
+		{json: `{"a":1}`, path: []string{"b"}, isErr: true},
+		{json: `{"a":{"b":1}`, isErr: true},
+	}
+
+	for _, test := range tests {
+		depth, err := Depth([]byte(test.json), test.path...)
+		if isErr := (err != nil); isErr != test.isErr {
+			t.Errorf("Depth(%s, %v) isErr mismatch: expected %t, obtained %t (err %v)", test.json, test.path, test.isErr, isErr, err)
+		} else if depth != test.depth {
+			t.Errorf("Depth(%s, %v) expected %d, obtained %d", test.json, test.path, test.depth, depth)
+		}
+	}
+}
+
+func TestEqualValue(t *testing.T) {
+	data := []byte(`{"a": { "b":{"c":"d" }}, "s": "x y", "n": [1, 2 ,3]}`)
+
+	tests := []struct {
+		path     []string
+		expected string
+		equal    bool
+	}{
+		{path: []string{"a", "b"}, expected: `{"c": "d"}`, equal: true},
+		{path: []string{"a", "b"}, expected: "{\n\t\"c\" : \"d\"\n}", equal: true},
+		{path: []string{"a", "b"}, expected: `{"c":"e"}`, equal: false},
+		{path: []string{"s"}, expected: `"x y"`, equal: true},
+		{path: []string{"s"}, expected: `"xy"`, equal: false},
+		{path: []string{"n"}, expected: `[1,2,3]`, equal: true},
+		{path: []string{"n"}, expected: `[1,3,2]`, equal: false},
+		{path: []string{}, expected: `{"a":{"b":{"c":"d"}},"s":"x y","n":[1,2,3]}`, equal: true},
+	}
+
+	for _, test := range tests {
+		equal, err := EqualValue(data, []byte(test.expected), test.path...)
+		if err != nil {
+			t.Errorf("EqualValue(%v, %s) returned error %v", test.path, test.expected, err)
+		} else if equal != test.equal {
+			t.Errorf("EqualValue(%v, %s) expected %t, obtained %t", test.path, test.expected, test.equal, equal)
+		}
+	}
+
+	if _, err := EqualValue(data, []byte(`1`), "missing"); err != KeyPathNotFoundError {
+		t.Errorf("EqualValue on a missing key should return KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestArrayOffsets(t *testing.T) {
+	data := []byte(`{"a": {"list": [ 1, "two" , {"three":[3]},[4], null ]}, "empty": []}`)
+	expected := []string{`1`, `two`, `{"three":[3]}`, `[4]`, `null`}
+
+	offsets, err := ArrayOffsets(data, "a", "list")
+	if err != nil {
+		t.Fatalf("ArrayOffsets returned error %v", err)
+	}
+	if len(offsets) != len(expected) {
+		t.Fatalf("ArrayOffsets expected %d offsets, obtained %v", len(expected), offsets)
+	}
+	for i, off := range offsets {
+		if data[off] != `1"{[n`[i] {
+			t.Errorf("ArrayOffsets offset %d does not point at the first byte of element %d", off, i)
+		}
+		if v, _, _, _ := Get(data[off:]); string(v) != expected[i] {
+			t.Errorf("Get at offset %d expected %s, obtained %s", off, expected[i], string(v))
+		}
+	}
+
+	if offsets, err := ArrayOffsets(data, "empty"); err != nil || len(offsets) != 0 {
+		t.Errorf("ArrayOffsets on an empty array returned %v, %v", offsets, err)
+	}
+	if _, err := ArrayOffsets(data, "a"); err != MalformedArrayError {
+		t.Errorf("ArrayOffsets on an object should return MalformedArrayError, obtained %v", err)
+	}
+	if _, err := ArrayOffsets(data, "missing"); err != KeyPathNotFoundError {
+		t.Errorf("ArrayOffsets on a missing key should return KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestEachKeyWithKey(t *testing.T) {
+	data := []byte(`{"name": "Name", "nested": {"k°": 1}, "arr": [{"b": 1}, {"b\"": 2}], "arrInt": [1, 2]}`)
+	paths := [][]string{
+		{"name"},
+		{"nested", "k°"},
+		{"arr", "[1]", "b\""},
+		{"arrInt", "[1]"},
+	}
+	expected := []string{"name", "k°", "b\"", "[1]"}
+
+	found := 0
+	EachKeyWithKey(data, func(idx int, key []byte, value []byte, vt ValueType, err error) {
+		found++
+		if err != nil {
+			t.Errorf("EachKeyWithKey path %d returned error %v", idx, err)
+		} else if string(key) != expected[idx] {
+			t.Errorf("EachKeyWithKey path %d expected key %q, obtained %q", idx, expected[idx], string(key))
+		}
+	}, paths...)
+
+	if found != len(paths) {
+		t.Errorf("EachKeyWithKey should find %d keys, found %d", len(paths), found)
+	}
+}
+
+func TestEachKeyAllocs(t *testing.T) {
+	data := []byte(`{"a": {"b": "x", "c": [1, {"d": 2}, "s"]}, "e": [{"f": 1}, {"f": 2}], "g": true}`)
+	tests := [][][]string{
+		{{"a", "b"}, {"g"}},
+		{{"a", "c", "[1]", "d"}, {"a", "c", "[2]"}, {"e", "[1]", "f"}},
+		{{"e", "[*]", "f"}, {"a", "c", "[*]"}},
+	}
+
+	for _, paths := range tests {
+		allocs := testing.AllocsPerRun(100, func() {
+			EachKey(data, func(int, []byte, ValueType, error) {}, paths...)
+		})
+		if allocs != 0 {
+			t.Errorf("EachKey(%q) expected no allocations, obtained %v", paths, allocs)
+		}
+	}
+}
+
+// String elements matched by a path ending in an array index are passed like any other string value, unquoted and still
+// escaped. They used to be parsed a second time, which reported them as Unknown or as the number or literal they contain.
+func TestEachKeyArrayIndexString(t *testing.T) {
+	data := []byte(`{"a": ["x", "y\"z", 1, "2", "true", ""]}`)
+	tests := []struct {
+		path  []string
+		value string
+		vt    ValueType
+	}{
+		{path: []string{"a", "[0]"}, value: `x`, vt: String},
+		{path: []string{"a", "[1]"}, value: `y\"z`, vt: String},
+		{path: []string{"a", "[2]"}, value: `1`, vt: Number},
+		{path: []string{"a", "[3]"}, value: `2`, vt: String},
+		{path: []string{"a", "[4]"}, value: `true`, vt: String},
+		{path: []string{"a", "[5]"}, value: ``, vt: String},
+	}
+
+	paths := make([][]string, len(tests))
+	for i, test := range tests {
+		paths[i] = test.path
+	}
+	found := 0
+	EachKey(data, func(idx int, value []byte, vt ValueType, err error) {
+		found++
+		test := tests[idx]
+		if err != nil || string(value) != test.value || vt != test.vt {
+			t.Errorf("EachKey path %q expected %s %q, obtained %s %q, %v", test.path, test.vt, test.value, vt, value, err)
+		}
+		if v, getVt, _, _ := Get(data, test.path...); string(v) != string(value) || getVt != vt {
+			t.Errorf("EachKey path %q disagrees with Get, which returned %s %q", test.path, getVt, v)
+		}
+	}, paths...)
+
+	if found != len(tests) {
+		t.Errorf("EachKey should find %d paths, found %d", len(tests), found)
+	}
+}
+
+func TestEachKeyWildcard(t *testing.T) {
+	data := []byte(`{"users": [{"name": "a", "id": 1}, {"id": 2}, {"name": "c\"", "id": 3}], "tags": ["x", "y"], "total": 3}`)
+	paths := [][]string{
+		{"users", "[*]", "name"},
+		{"total"},
+		{"users", "[1]", "id"},
+		{"tags", "[*]"},
+		{"users", "[*]", "id"},
+	}
+
+	var results []string
+	EachKeyIndexed(data, func(idx int, elem int, value []byte, vt ValueType, err error) {
+		if err != nil {
+			t.Errorf("EachKeyIndexed path %d returned error %v", idx, err)
+		}
+		results = append(results, fmt.Sprintf("%d/%d:%s", idx, elem, value))
+	}, paths...)
+
+	expected := []string{
+		"0/0:a", "4/0:1",
+		"4/1:2", "2/-1:2",
+		`0/2:c\"`, "4/2:3",
+		"3/0:x", "3/1:y",
+		"1/-1:3",
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("EachKeyIndexed expected %q, obtained %q", expected, results)
+	}
+
+	// EachKey fires once per element too, and EachKeyWithKey passes the element's index segment
+	var count int
+	EachKey(data, func(idx int, value []byte, vt ValueType, err error) { count++ }, []string{"users", "[*]", "id"})
+	if count != 3 {
+		t.Errorf("EachKey with a wildcard expected 3 calls, obtained %d", count)
+	}
+	var keys []string
+	EachKeyWithKey(data, func(idx int, key []byte, value []byte, vt ValueType, err error) {
+		keys = append(keys, string(key))
+	}, []string{"tags", "[*]"})
+	if !reflect.DeepEqual(keys, []string{"[0]", "[1]"}) {
+		t.Errorf("EachKeyWithKey with a wildcard expected index keys, obtained %q", keys)
+	}
+}
+
+func TestPathsConflict(t *testing.T) {
+	tests := []struct {
+		a, b     []string
+		conflict bool
+	}{
+		{a: []string{"a", "b"}, b: []string{"a", "b"}, conflict: true},
+		{a: []string{"a"}, b: []string{"a", "b", "c"}, conflict: true},
+		{a: []string{"a", "b", "c"}, b: []string{"a"}, conflict: true},
+		{a: []string{"a", "[0]"}, b: []string{"a", "[0]", "b"}, conflict: true},
+		{a: []string{"a", "[*]"}, b: []string{"a", "[1]", "b"}, conflict: true},
+		{a: []string{"a", "[2]", "b"}, b: []string{"a", "[*]", "b"}, conflict: true},
+		{a: []string{}, b: []string{"a"}, conflict: true},
+		{a: []string{"a", "b"}, b: []string{"a", "c"}},
+		{a: []string{"a", "b"}, b: []string{"b", "a"}},
+		{a: []string{"a", "[0]"}, b: []string{"a", "[1]"}},
+		{a: []string{"a", "[*]"}, b: []string{"a", "b"}},
+		{a: []string{"x", "a"}, b: []string{"y", "a", "b"}},
+	}
+
+	for _, test := range tests {
+		if conflict := PathsConflict(test.a, test.b); conflict != test.conflict {
+			t.Errorf("PathsConflict(%q, %q) expected %t, obtained %t", test.a, test.b, test.conflict, conflict)
+		}
+	}
+
+	// EachKey matches both paths of a conflicting pair, the shorter one containing the other
+	var values []string
+	EachKey([]byte(`{"a": {"b": 1}}`), func(idx int, value []byte, vt ValueType, err error) {
+		values = append(values, string(value))
+	}, []string{"a"}, []string{"a", "b"})
+	sort.Strings(values)
+	if !reflect.DeepEqual(values, []string{"1", `{"b": 1}`}) {
+		t.Errorf("EachKey expected both conflicting paths to match, obtained %q", values)
+	}
+}
+
+func TestEachKeyLast(t *testing.T) {
+	data := []byte(`{"events": [{"type": "a", "at": 1}, {"type": "b"}, {"type": "c", "at": 3}], "id": 7}`)
+	paths := [][]string{
+		{"events", "[*]", "type"},
+		{"missing"},
+		{"events", "[*]", "at"},
+		{"id"},
+	}
+
+	var results []string
+	EachKeyLast(data, func(idx int, value []byte, vt ValueType, err error) {
+		if err != nil {
+			t.Errorf("EachKeyLast path %d returned error %v", idx, err)
+		}
+		results = append(results, fmt.Sprintf("%d:%s", idx, value))
+	}, paths...)
+
+	// The last match of every path wins, reported in path order once the pass is over
+	expected := []string{"0:c", "2:3", "3:7"}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("EachKeyLast expected %q, obtained %q", expected, results)
+	}
+}
+
+func TestKeyIndex(t *testing.T) {
+	data := []byte(`{"a": {"b": "x\"y", "c": [1, "two", {"d": null}, [true]]}, "e": 1.5, "f": {}}`)
+	paths := [][]string{
+		{"a", "b"},
+		{"a", "c"},
+		{"a", "c", "[0]"},
+		{"a", "c", "[1]"},
+		{"a", "c", "[2]", "d"},
+		{"a", "c", "[3]"},
+		{"e"},
+		{"f"},
+		{"missing"},
+		{"a", "c", "[9]"},
+	}
+
+	locs := KeyIndex(data, paths...)
+	if len(locs) != len(paths) {
+		t.Fatalf("KeyIndex expected %d results, obtained %d", len(paths), len(locs))
+	}
+	for i, path := range paths {
+		_, dataType, offset, err := Get(data, path...)
+		expected := KeyLoc{Offset: offset, Found: err == nil, Type: dataType}
+		if locs[i] != expected {
+			t.Errorf("KeyIndex path %q expected %+v, obtained %+v", path, expected, locs[i])
+		}
+	}
+
+	// A wildcard path reports its first element
+	if locs := KeyIndex(data, []string{"a", "c", "[*]"}); locs[0] != (KeyLoc{Offset: 27, Found: true, Type: Number}) {
+		t.Errorf("KeyIndex with a wildcard returned unexpected %+v", locs[0])
+	}
+}
+
+func TestInvalidArrayIndex(t *testing.T) {
+	data := []byte(`{"a": [1, 2]}`)
+
+	for _, key := range []string{"[abc]", "[]", "[1e2]", "[-1]", "[+0]", "[ 0]"} {
+		if value, _, _, err := Get(data, "a", key); err != KeyPathNotFoundError {
+			t.Errorf("Get with index %s expected KeyPathNotFoundError, obtained %s, %v", key, value, err)
+		}
+
+		EachKey(data, func(idx int, value []byte, vt ValueType, err error) {
+			t.Errorf("EachKey with index %s should not match, obtained %s, %v", key, value, err)
+		}, []string{"a", key})
+	}
+}
+
+func TestObjectRanges(t *testing.T) {
+	data := []byte(`{"cfg": { "name" : "x\"y",
+		"a\"b": [1, {"c": 2}] ,"n":null,"o": {"k": "v"}, "": 1.5 }}`)
+
+	ranges, err := ObjectRanges(data, "cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedKeys := []string{`"name"`, `"a\"b"`, `"n"`, `"o"`, `""`}
+	if len(ranges) != len(expectedKeys) {
+		t.Fatalf("ObjectRanges expected %d entries, obtained %+v", len(expectedKeys), ranges)
+	}
+
+	for i, r := range ranges {
+		if key := string(data[r.KeyStart:r.KeyEnd]); key != expectedKeys[i] {
+			t.Errorf("ObjectRanges entry %d expected key %s, obtained %s", i, expectedKeys[i], key)
+		}
+
+		// The value range matches what Get reports for the key
+		name, _ := ParseString(data[r.KeyStart+1 : r.KeyEnd-1])
+		value, dataType, offset, err := Get(data, "cfg", name)
+		if err != nil || r.ValueEnd != offset || r.Type != dataType {
+			t.Errorf("ObjectRanges entry %s expected to end at %d with type %s, obtained %+v (%v)", name, offset, dataType, r, err)
+		}
+		raw := string(data[r.ValueStart:r.ValueEnd])
+		if dataType == String {
+			raw = raw[1 : len(raw)-1]
+		}
+		if raw != string(value) {
+			t.Errorf("ObjectRanges entry %s expected value %s, obtained %s", name, value, raw)
+		}
+
+		// searchKeys points just past the colon, which lies between the key and the value
+		if colon := searchKeys(data, "cfg", name) - 1; colon < r.KeyEnd || colon >= r.ValueStart || data[colon] != ':' {
+			t.Errorf("ObjectRanges entry %s: colon at %d is not between the key and the value %+v", name, colon, r)
+		}
+	}
+
+	if ranges, err := ObjectRanges([]byte(` {} `)); err != nil || len(ranges) != 0 {
+		t.Errorf("ObjectRanges of an empty object returned unexpected %+v, %v", ranges, err)
+	}
+	if _, err := ObjectRanges(data, "cfg", "a\"b"); err != MalformedObjectError {
+		t.Errorf("ObjectRanges of an array should fail with MalformedObjectError, obtained %v", err)
+	}
+	if _, err := ObjectRanges([]byte(`{"a": 1, "b" 2}`)); err == nil {
+		t.Error("ObjectRanges should fail on a malformed object")
+	}
+}
+
+func TestGetNextSibling(t *testing.T) {
+	data := []byte(`{"obj": { "a" : 1, "b\u00b0": "two", "c": {"d": [1, 2]} , "e": null }, "z": true}`)
+	_, _, start, _, err := internalGet(data, "obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys, values []string
+	offset := start + 1 // past the opening brace
+	for {
+		key, value, _, next, err := GetNextSibling(data, offset)
+		if err == KeyPathNotFoundError {
+			break
+		} else if err != nil {
+			t.Fatalf("GetNextSibling(%d) returned error %v", offset, err)
+		}
+		keys = append(keys, string(key))
+		values = append(values, string(value))
+		offset = next
+	}
+
+	expectedKeys := []string{"a", "b°", "c", "e"}
+	expectedValues := []string{"1", "two", `{"d": [1, 2]}`, "null"}
+	if !reflect.DeepEqual(keys, expectedKeys) || !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("GetNextSibling expected %q %q, obtained %q %q", expectedKeys, expectedValues, keys, values)
+	}
+
+	// Continuing after a value found with Get
+	_, _, offset, _ = Get(data, "obj", "c")
+	if key, value, dataType, _, err := GetNextSibling(data, offset); err != nil || string(key) != "e" || string(value) != "null" || dataType != Null {
+		t.Errorf("GetNextSibling after Get expected e: null, obtained %s: %s %s (error %v)", key, value, dataType, err)
+	}
+
+	for _, in := range []string{`{"a": 1 "b": 2}`, `{"a": 1, 2}`, `{"a": 1, "b" 2}`, `{"a": 1, "b": tru`, `{"a": 1,`} {
+		_, _, offset, _ := Get([]byte(in), "a")
+		if _, _, _, _, err := GetNextSibling([]byte(in), offset); err == nil || err == KeyPathNotFoundError {
+			t.Errorf("GetNextSibling(%s) expected a malformed JSON error, obtained %v", in, err)
+		}
+	}
+}
+
+func TestObjectEachKeys(t *testing.T) {
+	// The unwanted values are malformed in ways only full parsing would notice
+	data := []byte(`{"cfg": {"skip": nope, "name": "x", "other": {"a": tru}, "n\u0061me2": [1], "port": 80, "more": "\q"}}`)
+
+	var got []string
+	err := ObjectEachKeys(data, []string{"name", "name2", "port", "absent"}, func(key, value []byte, dataType ValueType) error {
+		got = append(got, fmt.Sprintf("%s=%s:%s", key, value, dataType))
+		return nil
+	}, "cfg")
+	if err != nil {
+		t.Fatalf("ObjectEachKeys returned error %v", err)
+	}
+	expected := []string{"name=x:string", "name2=[1]:array", "port=80:number"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ObjectEachKeys expected %q, obtained %q", expected, got)
+	}
+
+	// ObjectEach has to parse every value, so it stops at the first bad one
+	if err := ObjectEach(data, func([]byte, []byte, ValueType, int) error { return nil }, "cfg"); err == nil {
+		t.Error("ObjectEach expected an error for the malformed value")
+	}
+
+	// Wanted values are parsed as usual, and callback errors stop the iteration
+	if err := ObjectEachKeys(data, []string{"skip"}, func(key, value []byte, dataType ValueType) error { return nil }, "cfg"); err == nil {
+		t.Error("ObjectEachKeys expected an error for a malformed wanted value")
+	}
+	if err := ObjectEachKeys(data, []string{"name"}, func(key, value []byte, dataType ValueType) error { return NullValueError }, "cfg"); err != NullValueError {
+		t.Errorf("ObjectEachKeys expected the callback error, obtained %v", err)
+	}
+
+	// Nothing wanted, nothing visited
+	if err := ObjectEachKeys(data, nil, func(key, value []byte, dataType ValueType) error {
+		t.Errorf("ObjectEachKeys without wanted keys visited %s", key)
+		return nil
+	}, "cfg"); err != nil {
+		t.Errorf("ObjectEachKeys without wanted keys returned error %v", err)
+	}
+}
+
+func TestObjectMap(t *testing.T) {
+	data := []byte(`{"x": 0, "obj": {"a": "lower", "b" : 1, "c": "Mixed Case", "d": ["keep"], "e":"x\"y"}}`)
+	original := string(data)
+
+	upper := func(key, value []byte, dataType ValueType) ([]byte, error) {
+		if dataType == String {
+			return bytes.ToUpper(value), nil
+		}
+		return value, nil
+	}
+	out, err := ObjectMap(data, upper, "obj")
+	if err != nil {
+		t.Fatalf("ObjectMap returned error %v", err)
+	}
+	expected := `{"x": 0, "obj": {"a": "LOWER", "b" : 1, "c": "MIXED CASE", "d": ["keep"], "e":"X\"Y"}}`
+	if string(out) != expected {
+		t.Errorf("ObjectMap expected\n%s\nobtained\n%s", expected, out)
+	}
+	if string(data) != original {
+		t.Errorf("ObjectMap modified its input: %s", data)
+	}
+
+	// Non-string values are replaced by raw JSON
+	out, err = ObjectMap([]byte(`{"a": 1, "b": [2]}`), func(key, value []byte, dataType ValueType) ([]byte, error) {
+		return []byte(`{"was":` + string(value) + `}`), nil
+	})
+	if err != nil || string(out) != `{"a": {"was":1}, "b": {"was":[2]}}` {
+		t.Errorf("ObjectMap returned unexpected %s, %v", out, err)
+	}
+
+	// Errors from fn and from parsing are returned
+	if _, err := ObjectMap(data, func(key, value []byte, dataType ValueType) ([]byte, error) {
+		return nil, NullValueError
+	}, "obj"); err != NullValueError {
+		t.Errorf("ObjectMap expected the fn error, obtained %v", err)
+	}
+	if _, err := ObjectMap(data, upper, "x"); err == nil {
+		t.Error("ObjectMap expected an error for a path to a non-object")
+	}
+}
+
+func TestArrayMap(t *testing.T) {
+	data := []byte(`{"a": [ 1, "two" ,{"b": 3}, [4], null ], "c": []}`)
+	original := string(data)
+
+	double := func(index int, value []byte, dataType ValueType) ([]byte, error) {
+		switch dataType {
+		case Number:
+			n, _ := ParseInt(value)
+			return []byte(strconv.FormatInt(n*2, 10)), nil
+		case String:
+			return append(value, value...), nil
+		}
+		return value, nil
+	}
+	out, err := ArrayMap(data, double, "a")
+	if err != nil || string(out) != `{"a": [ 2, "twotwo" ,{"b": 3}, [4], null ], "c": []}` {
+		t.Errorf("ArrayMap returned unexpected %s, %v", out, err)
+	}
+	if string(data) != original {
+		t.Errorf("ArrayMap modified its input: %s", data)
+	}
+
+	tests := []struct {
+		keep     func(index int) bool
+		expected string
+	}{
+		{func(i int) bool { return i%2 == 1 }, `{"a": [ "two", [4] ], "c": []}`},
+		{func(i int) bool { return i != 0 }, `{"a": [ "two" ,{"b": 3}, [4], null ], "c": []}`},
+		{func(i int) bool { return i != 4 }, `{"a": [ 1, "two" ,{"b": 3}, [4] ], "c": []}`},
+		{func(i int) bool { return false }, `{"a": [ ], "c": []}`},
+	}
+	for i, test := range tests {
+		out, err := ArrayMap(data, func(index int, value []byte, dataType ValueType) ([]byte, error) {
+			if !test.keep(index) {
+				return nil, nil
+			}
+			return value, nil
+		}, "a")
+		if err != nil || string(out) != test.expected {
+			t.Errorf("ArrayMap drop test %d expected %s, obtained %s, %v", i, test.expected, out, err)
+		}
+	}
+
+	// An empty array, an empty string result, errors
+	if out, err := ArrayMap(data, double, "c"); err != nil || string(out) != original {
+		t.Errorf("ArrayMap of an empty array returned unexpected %s, %v", out, err)
+	}
+	if out, err := ArrayMap([]byte(`["x"]`), func(int, []byte, ValueType) ([]byte, error) { return []byte{}, nil }); err != nil || string(out) != `[""]` {
+		t.Errorf("ArrayMap returned unexpected %s, %v", out, err)
+	}
+	if _, err := ArrayMap(data, func(int, []byte, ValueType) ([]byte, error) { return nil, NullValueError }, "a"); err != NullValueError {
+		t.Errorf("ArrayMap expected the fn error, obtained %v", err)
+	}
+	if _, err := ArrayMap(data, double, "a", "[1]"); err != MalformedArrayError {
+		t.Errorf("ArrayMap expected MalformedArrayError for a non-array, obtained %v", err)
+	}
+	for _, malformed := range []string{`[1,2,[3,{"a":1}],{"a":"s"]}]`, `[1, {"a": [2}]]`} {
+		if out, err := ArrayMap([]byte(malformed), double); err != MalformedArrayError {
+			t.Errorf("ArrayMap(%s) expected MalformedArrayError, obtained %s, %v", malformed, out, err)
+		}
+	}
+}
+
+func TestObjectEachIndexed(t *testing.T) {
+	data := []byte(`{"x": {"z": 1, "a" : "two", "m":{"n":[true]}, "b":null}}`)
+
+	var indexes []int
+	var keys []string
+	err := ObjectEachIndexed(data, func(index int, key []byte, value []byte, dataType ValueType) error {
+		indexes = append(indexes, index)
+		keys = append(keys, string(key))
+		return nil
+	}, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedKeys := []string{"z", "a", "m", "b"}
+	if !reflect.DeepEqual(indexes, []int{0, 1, 2, 3}) || !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("ObjectEachIndexed expected indexes 0-3 for %q, obtained %v for %q", expectedKeys, indexes, keys)
+	}
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = ObjectEachIndexed(data, func(index int, key []byte, value []byte, dataType ValueType) error {
+		calls++
+		if index == 1 {
+			return stop
+		}
+		return nil
+	}, "x")
+	if err != stop || calls != 2 {
+		t.Errorf("ObjectEachIndexed expected to stop after 2 entries with the callback error, obtained %d, %v", calls, err)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	data := []byte(`{"x": {"s": "a\"b", "n" : -1.5, "o":{"k":[1]}, "arr": [true, null], "b": false, "nil": null, "e\u00b0": ""}}`)
+
+	entries, err := Entries(data, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []KeyValue{
+		{Key: "s", Value: []byte(`a\"b`), Type: String},
+		{Key: "n", Value: []byte(`-1.5`), Type: Number},
+		{Key: "o", Value: []byte(`{"k":[1]}`), Type: Object},
+		{Key: "arr", Value: []byte(`[true, null]`), Type: Array},
+		{Key: "b", Value: []byte(`false`), Type: Boolean},
+		{Key: "nil", Value: []byte(`null`), Type: Null},
+		{Key: "e°", Value: []byte(``), Type: String},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Entries(%s) expected %v, obtained %v", data, expected, entries)
+	}
+
+	if entries, err := Entries([]byte(`{}`)); err != nil || len(entries) != 0 {
+		t.Errorf("Entries({}) expected no entries, obtained %v (error %v)", entries, err)
+	}
+	if _, err := Entries(data, "x", "arr"); err != MalformedObjectError {
+		t.Errorf("Entries of an array expected MalformedObjectError, obtained %v", err)
+	}
+	if _, err := Entries(data, "y"); err != KeyPathNotFoundError {
+		t.Errorf("Entries of a missing key expected KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestObjectEachRange(t *testing.T) {
+	data := []byte(`{"x": {"a": 1, "b" : "two\"", "c":{"d":[true]}, "e\u00b0":null}}`)
+
+	var keys, values []string
+	err := ObjectEachRange(data, func(key []byte, valueStart, valueEnd int, dataType ValueType) error {
+		keys = append(keys, string(key))
+		values = append(values, string(data[valueStart:valueEnd]))
+		if Raw(data[valueStart:valueEnd]).Type() != dataType {
+			t.Errorf("ObjectEachRange type mismatch for key %s: %s", key, dataType)
+		}
+		return nil
+	}, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedKeys := []string{"a", "b", "c", "e°"}
+	expectedValues := []string{"1", `"two\""`, `{"d":[true]}`, "null"}
+	if !reflect.DeepEqual(keys, expectedKeys) || !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("ObjectEachRange expected %q %q, obtained %q %q", expectedKeys, expectedValues, keys, values)
+	}
+
+	if err := ObjectEachRange([]byte(`{"a":1}`), func([]byte, int, int, ValueType) error { return nil }, "b"); err != KeyPathNotFoundError {
+		t.Errorf("ObjectEachRange expected KeyPathNotFoundError, obtained %v", err)
+	}
+}
+
+func TestObjectEachValidated(t *testing.T) {
+	var keys []string
+	err := ObjectEachValidated([]byte(`{"plain": 1, "k°": 2, "smile😃": 3, "°": 4}`), func(key, value []byte, dataType ValueType, offset int) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ObjectEachValidated returned error %v for valid keys", err)
+	}
+	if expected := []string{"plain", "k°", "smile\U0001F603", "°"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("ObjectEachValidated expected keys %q, obtained %q", expected, keys)
+	}
+
+	data := []byte("{\"ok\": 1, \"bad\xff\": 2}")
+	called := 0
+	err = ObjectEachValidated(data, func(key, value []byte, dataType ValueType, offset int) error {
+		called++
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "offset 10") {
+		t.Errorf("ObjectEachValidated should report the invalid key at offset 10, obtained %v", err)
+	}
+	if called != 1 {
+		t.Errorf("ObjectEachValidated should stop before the invalid key, callback called %d times", called)
+	}
+
+	// ObjectEach stays lenient
+	if err := ObjectEach(data, func(key, value []byte, dataType ValueType, offset int) error { return nil }); err != nil {
+		t.Errorf("ObjectEach should not validate keys, obtained %v", err)
+	}
+}
+
+// stringEndBytewise is the original byte-by-byte implementation of stringEnd, kept as a reference for equivalence tests and benchmarks
+func stringEndBytewise(data []byte) (int, bool) {
+	escaped := false
+	for i, c := range data {
+		if c == '"' {
+			if !escaped {
+				return i + 1, false
+			} else {
+				j := i - 1
+				for {
+					if j < 0 || data[j] != '\\' {
+						return i + 1, true // even number of backslashes
+					}
+					j--
+					if j < 0 || data[j] != '\\' {
+						break // odd number of backslashes
+					}
+					j--
+
+				}
+			}
+		} else if c == '\\' {
+			escaped = true
+		}
+	}
+
+	return -1, escaped
+}
+
+var stringEndTests = []string{
+	``,
+	`"`,
+	`abc"`,
+	`abc`,
+	`abc\`,
+	`abc\"`,
+	`abc\""`,
+	`abc\\"`,
+	`abc\\\"`,
+	`abc\\\""`,
+	`\\\\"`,
+	`\n\t" trailing "`,
+	`no escapes here" \" "`,
+	`° long string with an escape at the start and the end \"`,
+	`° long string with an escape at the start and the end \\"x`,
+	strings.Repeat("a", 100) + `\"` + strings.Repeat("b", 100) + `"`,
+	strings.Repeat(`\\`, 50) + `"`,
+}
+
+func TestStringEnd(t *testing.T) {
+	for _, in := range stringEndTests {
+		end, escaped := stringEnd([]byte(in))
+		expectedEnd, expectedEscaped := stringEndBytewise([]byte(in))
+		if end != expectedEnd || escaped != expectedEscaped {
+			t.Errorf("stringEnd(%q) expected (%d, %t), obtained (%d, %t)", in, expectedEnd, expectedEscaped, end, escaped)
+		}
+	}
+}
+
+var longStringValue = []byte(strings.Repeat("lorem ipsum dolor sit amet ", 40) + `\"quoted\" ` + strings.Repeat("consectetur adipiscing elit ", 40) + `"`)
+
+func BenchmarkStringEnd(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringEnd(longStringValue)
+	}
+}
+
+func BenchmarkStringEndBytewise(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringEndBytewise(longStringValue)
+	}
+}
+
+var shortStringValue = []byte(`uuid": "de305d54"`)
+
+func BenchmarkStringEndShort(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringEnd(shortStringValue)
+	}
+}
+
+func BenchmarkStringEndShortBytewise(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		stringEndBytewise(shortStringValue)
+	}
+}
+
+var shortKeysData = []byte(`{"person":{"id":1,"name":{"first":"Leonid","last":"Bugaev","full":"Leonid Bugaev"},"github":{"handle":"buger","followers":95},"gravatar":{"avatar":"http://1.gravatar.com/avatar/f7c8edd577d13b8930d5522f28123510"}},"company":{"name":"Acme"}}`)
+
+func BenchmarkGetShortKeys(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Get(shortKeysData, "person", "name", "full")
+		Get(shortKeysData, "person", "github", "followers")
+		Get(shortKeysData, "company", "name")
+	}
+}
+
+var escapedKeysData = func() []byte {
+	var b strings.Builder
+	b.WriteString(`{`)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, `"k\u00e9y\t%d": %d, `, i, i)
+	}
+	b.WriteString(`"a_target_with_a_longer_name": 1}`)
+	return []byte(b.String())
+}()
+
+func BenchmarkGetEscapedKeys(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Get(escapedKeysData, "a_target_with_a_longer_name")
+	}
+}
+
+func BenchmarkEachKeyShortKeys(b *testing.B) {
+	paths := [][]string{
+		{"person", "name", "full"},
+		{"person", "github", "followers"},
+		{"company", "name"},
+	}
+	for i := 0; i < b.N; i++ {
+		EachKey(shortKeysData, func(idx int, value []byte, vt ValueType, err error) {}, paths...)
+	}
+}
+
+var manyCopyPaths = [][]string{
+	{"person", "name", "full"},
+	{"person", "github", "handle"},
+	{"person", "github", "followers"},
+	{"person", "gravatar", "avatar"},
+	{"company", "name"},
+}
+
+func BenchmarkGetManyCopy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetManyCopy(shortKeysData, manyCopyPaths...)
+	}
+}
+
+func BenchmarkSafeGetEach(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, path := range manyCopyPaths {
+			SafeGet(shortKeysData, path...)
+		}
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Set(shortKeysData, []byte(`100`), "person", "github", "followers")
+	}
+}
+
+func BenchmarkSetBuf(b *testing.B) {
+	b.ReportAllocs()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		buf, _ = SetBuf(buf, shortKeysData, []byte(`100`), "person", "github", "followers")
+	}
+}
@@ -0,0 +1,200 @@
+package jsonparser
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+/*
+
+Patch - Computes a JSON Patch (RFC 6902) document which transforms `from` into `to`, as a JSON array of `add`, `remove`
+and `replace` operations addressing values with JSON pointers (RFC 6901).
+
+Both documents are walked together: members of objects are matched by key, and elements of arrays by index, so an
+element inserted at the front of an array shows up as a replacement of every following element plus an `add` at the
+end. Values of different types, and scalars which differ once whitespace is removed, are replaced as a whole; strings
+are compared as they are written, so `"é"` and `"\u00e9"` are considered different. Values are written compacted.
+
+Operations are ordered so that they can be applied one after the other: within an object, removals come first, then
+changes to the members of `to` in its order; trailing array elements are removed from the last one backwards.
+
+Returns:
+`patch` - The patch document, `[]` if the documents are equal
+`err` - Any error met while parsing `from` or `to`
+
+*/
+func Patch(from, to []byte) (patch []byte, err error) {
+	a, at, err := patchRoot(from)
+	if err != nil {
+		return nil, err
+	}
+	b, bt, err := patchRoot(to)
+	if err != nil {
+		return nil, err
+	}
+
+	patch = append(patch, '[')
+	if patch, err = appendPatchOps(patch, "", a, at, b, bt, 0); err != nil {
+		return nil, err
+	}
+	if patch[len(patch)-1] == ',' {
+		patch = patch[:len(patch)-1]
+	}
+	return append(patch, ']'), nil
+}
+
+// patchRoot returns the root value of a document with the quotes of strings, as values are written into a patch
+func patchRoot(data []byte) ([]byte, ValueType, error) {
+	_, t, offset, endOffset, err := internalGet(data)
+	if err != nil {
+		return nil, t, err
+	}
+	return data[offset:endOffset], t, nil
+}
+
+// patchEntry is a member of an object or an element of an array compared by `Patch`, with the quotes of strings
+type patchEntry struct {
+	key      string
+	value    []byte
+	dataType ValueType
+}
+
+// appendPatchOps appends the operations, each followed by a comma, which transform the value `a` at pointer `ptr` into `b`;
+// `depth` is the number of containers around them
+func appendPatchOps(dst []byte, ptr string, a []byte, at ValueType, b []byte, bt ValueType, depth int) ([]byte, error) {
+	if depth >= MaxNestingDepth && at == bt && (at == Object || at == Array) {
+		return nil, MaxDepthExceededError
+	}
+
+	switch {
+	case at == Object && bt == Object:
+		aEntries, err := objectPatchEntries(a)
+		if err != nil {
+			return nil, err
+		}
+		bEntries, err := objectPatchEntries(b)
+		if err != nil {
+			return nil, err
+		}
+		aIndex := make(map[string]int, len(aEntries))
+		for i, e := range aEntries {
+			if _, ok := aIndex[e.key]; !ok {
+				aIndex[e.key] = i
+			}
+		}
+		bIndex := make(map[string]int, len(bEntries))
+		for i, e := range bEntries {
+			if _, ok := bIndex[e.key]; !ok {
+				bIndex[e.key] = i
+			}
+		}
+
+		for i, e := range aEntries {
+			if _, ok := bIndex[e.key]; !ok && aIndex[e.key] == i {
+				dst = appendPatchOp(dst, "remove", ptr+"/"+pointerEscape(e.key), nil)
+			}
+		}
+		for i, e := range bEntries {
+			if bIndex[e.key] != i {
+				continue // only the first of duplicate keys counts, as with Get
+			}
+			if j, ok := aIndex[e.key]; ok {
+				if dst, err = appendPatchOps(dst, ptr+"/"+pointerEscape(e.key), aEntries[j].value, aEntries[j].dataType, e.value, e.dataType, depth+1); err != nil {
+					return nil, err
+				}
+			} else {
+				dst = appendPatchOp(dst, "add", ptr+"/"+pointerEscape(e.key), e.value)
+			}
+		}
+		return dst, nil
+
+	case at == Array && bt == Array:
+		aElements, err := arrayPatchEntries(a)
+		if err != nil {
+			return nil, err
+		}
+		bElements, err := arrayPatchEntries(b)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < len(aElements) && i < len(bElements); i++ {
+			if dst, err = appendPatchOps(dst, ptr+"/"+strconv.Itoa(i), aElements[i].value, aElements[i].dataType, bElements[i].value, bElements[i].dataType, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		for i := len(aElements) - 1; i >= len(bElements); i-- {
+			dst = appendPatchOp(dst, "remove", ptr+"/"+strconv.Itoa(i), nil)
+		}
+		for i := len(aElements); i < len(bElements); i++ {
+			dst = appendPatchOp(dst, "add", ptr+"/"+strconv.Itoa(i), bElements[i].value)
+		}
+		return dst, nil
+	}
+
+	var stackbuf [unescapeStackBufSize]byte
+	if at != bt || !bytes.Equal(appendCompact(stackbuf[:0], a), appendCompact(nil, b)) {
+		dst = appendPatchOp(dst, "replace", ptr, b)
+	}
+	return dst, nil
+}
+
+// appendPatchOp appends a single operation followed by a comma; `value` is omitted if nil
+func appendPatchOp(dst []byte, op, ptr string, value []byte) []byte {
+	dst = append(dst, `{"op":"`...)
+	dst = append(dst, op...)
+	dst = append(dst, `","path":`...)
+	dst = appendEscaped(dst, StringToBytes(ptr))
+	if value != nil {
+		dst = append(dst, `,"value":`...)
+		dst = appendCompact(dst, value)
+	}
+	return append(dst, '}', ',')
+}
+
+// pointerEscape escapes a key for use as a JSON pointer reference token
+func pointerEscape(key string) string {
+	if strings.IndexAny(key, "~/") == -1 {
+		return key
+	}
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// objectPatchEntries returns the members of an object, with unescaped keys
+func objectPatchEntries(data []byte) ([]patchEntry, error) {
+	ranges, err := ObjectRanges(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]patchEntry, len(ranges))
+	for i, r := range ranges {
+		key, err := ParseString(data[r.KeyStart+1 : r.KeyEnd-1])
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = patchEntry{key: key, value: data[r.ValueStart:r.ValueEnd], dataType: r.Type}
+	}
+	return entries, nil
+}
+
+// arrayPatchEntries returns the elements of an array
+func arrayPatchEntries(data []byte) ([]patchEntry, error) {
+	var entries []patchEntry
+	var entryErr error
+	_, err := ArrayEachAbs(data, func(value []byte, dataType ValueType, absOffset int, err error) {
+		if err != nil || entryErr != nil {
+			return
+		}
+		_, t, offset, endOffset, e := internalGet(data[absOffset:])
+		if e != nil {
+			entryErr = e
+			return
+		}
+		entries = append(entries, patchEntry{value: data[absOffset+offset : absOffset+endOffset], dataType: t})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, entryErr
+}
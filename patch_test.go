@@ -0,0 +1,118 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyPatchForTest applies the add, remove and replace operations of a patch with Set and Delete. Elements are only
+// added to arrays at their end, which is all Patch produces.
+func applyPatchForTest(t *testing.T, doc, patch []byte) []byte {
+	_, err := ArrayEach(patch, func(op []byte, dataType ValueType, offset int, err error) {
+		name, _ := GetString(op, "op")
+		path, _ := GetString(op, "path")
+		var value []byte
+		if _, _, start, end, err := internalGet(op, "value"); err == nil {
+			value = op[start:end]
+		}
+
+		if name == "add" && path != "" {
+			// Patch only adds array elements at the end, which Set can't do: append them to the parent instead
+			parentKeys, err := pointerKeys(doc, "#"+path[:strings.LastIndexByte(path, '/')])
+			if err != nil {
+				t.Fatalf("Patch operation %s: %v", op, err)
+			}
+			if _, parentType, start, end, _ := internalGet(doc, parentKeys...); parentType == Array {
+				arr, _ := ConcatArrays(doc[start:end], append(append([]byte("["), value...), ']'))
+				if len(parentKeys) == 0 {
+					doc = arr
+				} else if doc, err = Set(doc, arr, parentKeys...); err != nil {
+					t.Fatalf("Patch operation %s: %v", op, err)
+				}
+				return
+			}
+		}
+
+		keys, err := pointerKeys(doc, "#"+path)
+		if err != nil {
+			t.Fatalf("Patch operation %s: %v", op, err)
+		}
+
+		switch {
+		case name == "remove":
+			doc = Delete(doc, keys...)
+		case len(keys) == 0:
+			doc = value
+		default:
+			doc, err = Set(doc, value, keys...)
+			if err != nil {
+				t.Fatalf("Patch operation %s: %v", op, err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Patch %s is not an array: %v", patch, err)
+	}
+	return doc
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		from, to string
+		patch    string
+	}{
+		{from: `{"a": 1}`, to: `{"a":1}`, patch: `[]`},
+		{from: `{"a": 1, "b": 2}`, to: `{"a": 3, "c": {"d": [1]}}`, patch: `[{"op":"remove","path":"/b"},{"op":"replace","path":"/a","value":3},{"op":"add","path":"/c","value":{"d":[1]}}]`},
+		{from: `{"a/b": {"m~n": "x"}}`, to: `{"a/b": {"m~n": "y"}}`, patch: `[{"op":"replace","path":"/a~1b/m~0n","value":"y"}]`},
+		{from: `[1, 2, 3]`, to: `[1, 5]`, patch: `[{"op":"replace","path":"/1","value":5},{"op":"remove","path":"/2"}]`},
+		{from: `[1]`, to: `[1, {"a": true}, null]`, patch: `[{"op":"add","path":"/1","value":{"a":true}},{"op":"add","path":"/2","value":null}]`},
+		{from: `{"a": [1]}`, to: `{"a": {"0": 1}}`, patch: `[{"op":"replace","path":"/a","value":{"0":1}}]`},
+		{from: `"x"`, to: `2`, patch: `[{"op":"replace","path":"","value":2}]`},
+	}
+
+	for _, test := range tests {
+		patch, err := Patch([]byte(test.from), []byte(test.to))
+		if err != nil || string(patch) != test.patch {
+			t.Errorf("Patch(%s, %s) expected %s, obtained %s (error %v)", test.from, test.to, test.patch, patch, err)
+		}
+	}
+
+	if _, err := Patch([]byte(`{"a": `), []byte(`{}`)); err == nil {
+		t.Errorf("Patch of a malformed document expected an error")
+	}
+}
+
+func TestPatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		from, to string
+	}{
+		{
+			from: `{"name": "Ann", "age": 30, "tags": ["a", "b", "c"], "address": {"city": "Oslo", "zip": "0150"}, "old": true}`,
+			to:   `{"name": "Ann B", "age": 30, "tags": ["a", "x"], "address": {"city": "Bergen", "country": "NO"}, "new": [1, 2]}`,
+		},
+		{
+			from: `{"items": [{"id": 1, "qty": 2}, {"id": 2}], "meta": {}}`,
+			to:   `{"items": [{"id": 1, "qty": 3}, {"id": 2, "note": "a/b~c"}, {"id": 3}], "meta": {"k/v": null}}`,
+		},
+		{from: `[[1, 2], [3]]`, to: `[[1], [3, 4], []]`},
+		{from: `{"a": 1}`, to: `[1]`},
+	}
+
+	for _, test := range tests {
+		patch, err := Patch([]byte(test.from), []byte(test.to))
+		if err != nil {
+			t.Fatalf("Patch(%s, %s) returned error %v", test.from, test.to, err)
+		}
+		result := applyPatchForTest(t, []byte(test.from), patch)
+
+		var expected, obtained interface{}
+		if err := json.Unmarshal([]byte(test.to), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(result, &obtained); err != nil || !reflect.DeepEqual(expected, obtained) {
+			t.Errorf("Applying Patch(%s, %s) = %s expected %s, obtained %s (error %v)", test.from, test.to, patch, test.to, result, err)
+		}
+	}
+}
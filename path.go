@@ -0,0 +1,241 @@
+package jsonparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors
+var (
+	MalformedPathError    = errors.New("Path is not a valid dotted key path")
+	EmptyPathSegmentError = errors.New("Dotted key path contains an empty segment")
+)
+
+/*
+
+PathString - Renders a key path, as passed to `Get`, in the dotted form read by `GetPath`, e.g. `a[0].b` for
+[]string{"a", "[0]", "b"}, for use in error messages and logs.
+
+Array index segments are attached to the previous key. Within keys, `.`, `[` and `\` are escaped with a backslash, so
+that the result can be parsed back into the same path, unless it contains an empty key: the dotted form has no way to
+write one, so `GetPath` rejects the resulting empty segment.
+
+*/
+func PathString(keys []string) string {
+	var b strings.Builder
+	for i, k := range keys {
+		if len(k) > 0 && k[0] == '[' {
+			b.WriteString(k)
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		for j := 0; j < len(k); j++ {
+			if c := k[j]; c == '.' || c == '[' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(k[j])
+		}
+	}
+	return b.String()
+}
+
+/*
+
+GetPath - Receives data structure, and a key path in dotted form like `person.emails[0].address`, as written by
+`PathString`. Otherwise it works exactly like `Get`.
+
+Keys are separated by `.` and array indexes (including `[*]` where supported) follow the key they apply to, or start the
+path. A backslash makes the next character part of the key, e.g. `a\.b` is the single key `a.b`. An empty path is the
+whole document. Empty segments, as in `a..b`, `.a` or `a.`, are rejected with `EmptyPathSegmentError` rather than
+matching an empty key; use `Get` for documents with empty keys.
+
+Returns the same as `Get`, `MalformedPathError` if the path can't be parsed, or `EmptyPathSegmentError`.
+
+*/
+func GetPath(data []byte, path string) (value []byte, dataType ValueType, offset int, err error) {
+	keys, err := parsePath(path)
+	if err != nil {
+		return nil, NotExist, -1, err
+	}
+	return Get(data, keys...)
+}
+
+// Resolve looks up several paths in dotted form, as read by `GetPath`, e.g. the `{{a.b.c}}` placeholders of a template,
+// in a single pass over `data` with `EachKey`. It returns a map from every expression to its value, as `Get` would return
+// it, or nil if the path isn't found. Repeated expressions are resolved once. An expression which isn't a valid path
+// fails the whole call, like a malformed document does.
+func Resolve(data []byte, exprs []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(exprs))
+	var paths [][]string
+	var pathExprs []string
+	for _, expr := range exprs {
+		if _, ok := values[expr]; ok {
+			continue
+		}
+		keys, err := parsePath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Expression %q: %v", expr, err)
+		}
+		values[expr] = nil
+
+		if len(keys) == 0 {
+			// the whole document, which EachKey doesn't report
+			value, _, _, err := Get(data)
+			if err != nil {
+				return nil, err
+			}
+			values[expr] = value
+			continue
+		}
+		paths = append(paths, keys)
+		pathExprs = append(pathExprs, expr)
+	}
+
+	var err error
+	EachKey(data, func(idx int, value []byte, vt ValueType, e error) {
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			return
+		}
+		if expr := pathExprs[idx]; values[expr] == nil {
+			values[expr] = value
+		}
+	}, paths...)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parsePath splits a dotted path into the key path it stands for, see `GetPath`
+func parsePath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	var keys []string
+	var key strings.Builder
+	afterIndex := false // whether the current segment so far consists of an array index, which is already in keys
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '\\':
+			if i++; i == len(path) {
+				return nil, MalformedPathError
+			}
+			key.WriteByte(path[i])
+			afterIndex = false
+		case '.':
+			if !afterIndex {
+				if key.Len() == 0 {
+					return nil, EmptyPathSegmentError
+				}
+				keys = append(keys, key.String())
+			}
+			key.Reset()
+			afterIndex = false
+		case '[':
+			if key.Len() > 0 {
+				keys = append(keys, key.String())
+				key.Reset()
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end == -1 {
+				return nil, MalformedPathError
+			}
+			keys = append(keys, path[i:i+end+1])
+			i += end
+			if i+1 < len(path) && path[i+1] != '.' && path[i+1] != '[' {
+				return nil, MalformedPathError
+			}
+			afterIndex = true
+		default:
+			key.WriteByte(c)
+			afterIndex = false
+		}
+	}
+	if !afterIndex {
+		if key.Len() == 0 {
+			return nil, EmptyPathSegmentError
+		}
+		keys = append(keys, key.String())
+	}
+	return keys, nil
+}
+
+// CompiledPath is a key path checked and pre-parsed once by `CompilePath`, for lookups repeated over many documents
+type CompiledPath struct {
+	keys    []string
+	indexes []int // array index of every segment, or -1 for object keys
+}
+
+// CompilePath checks a key path, as passed to `Get`, and parses its array index segments once. Every segment starting
+// with `[` is taken for an array index, so a malformed one like `[abc]` is reported here instead of silently not
+// matching on every lookup. Only concrete indexes are supported, not `[*]`.
+func CompilePath(keys ...string) (*CompiledPath, error) {
+	cp := &CompiledPath{keys: append([]string(nil), keys...), indexes: make([]int, len(keys))}
+	for i, k := range keys {
+		cp.indexes[i] = -1
+		if len(k) > 0 && k[0] == '[' {
+			idx, ok := parseArrayIndex(k)
+			if !ok {
+				return nil, fmt.Errorf("Malformed array index in key path: %s", k)
+			}
+			cp.indexes[i] = idx
+		}
+	}
+	return cp, nil
+}
+
+// Get works like the package-level `Get` with the compiled key path. Arrays are only iterated up to the requested
+// element.
+func (cp *CompiledPath) Get(data []byte) (value []byte, dataType ValueType, offset int, err error) {
+	base := nextToken(data)
+	if base == -1 {
+		return nil, NotExist, -1, EmptyInputError
+	}
+
+	for i, k := range cp.keys {
+		if idx := cp.indexes[i]; idx >= 0 {
+			if data[base] != '[' {
+				return nil, NotExist, -1, KeyPathNotFoundError
+			}
+			found, n := -1, 0
+			ArrayEachFrom(data, base, func(value []byte, dataType ValueType, offset int) bool {
+				if n == idx {
+					found = offset
+					return false
+				}
+				n++
+				return true
+			})
+			if found == -1 {
+				return nil, NotExist, -1, KeyPathNotFoundError
+			}
+			base = found
+		} else {
+			if data[base] != '{' {
+				return nil, NotExist, -1, KeyPathNotFoundError
+			}
+			off := searchKeys(data[base:], k)
+			if off == -1 {
+				return nil, NotExist, -1, KeyPathNotFoundError
+			}
+			next := nextToken(data[base+off:])
+			if next == -1 {
+				return nil, NotExist, -1, KeyPathNotFoundError
+			}
+			base += off + next
+		}
+	}
+
+	value, dataType, offset, err = Get(data[base:])
+	if err != nil {
+		return nil, dataType, -1, err
+	}
+	return value, dataType, base + offset, nil
+}
@@ -0,0 +1,183 @@
+package jsonparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+var pathStringTests = []struct {
+	keys []string
+	path string
+}{
+	{keys: []string{"a", "[0]", "b"}, path: "a[0].b"},
+	{keys: []string{"a"}, path: "a"},
+	{keys: []string{"[1]", "[*]", "c"}, path: "[1][*].c"},
+	{keys: []string{"a.b", "c[d]", `e\f`}, path: `a\.b.c\[d].e\\f`},
+	{keys: []string{"person", "emails", "[10]", "address"}, path: "person.emails[10].address"},
+	{keys: []string{"ключ", "[0]"}, path: "ключ[0]"},
+	{keys: nil, path: ""},
+}
+
+func TestPathString(t *testing.T) {
+	for _, test := range pathStringTests {
+		if path := PathString(test.keys); path != test.path {
+			t.Errorf("PathString(%q) expected %q, obtained %q", test.keys, test.path, path)
+		}
+		if keys, err := parsePath(test.path); err != nil || !reflect.DeepEqual(keys, test.keys) {
+			t.Errorf("parsePath(%q) expected %q, obtained %q, %v", test.path, test.keys, keys, err)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	// Forms PathString doesn't produce, but which are accepted
+	tests := map[string][]string{
+		"a.[0].b": {"a", "[0]", "b"},
+		`\a`:      {"a"},
+		"a]":      {"a]"},
+	}
+	for path, expected := range tests {
+		if keys, err := parsePath(path); err != nil || !reflect.DeepEqual(keys, expected) {
+			t.Errorf("parsePath(%q) expected %q, obtained %q, %v", path, expected, keys, err)
+		}
+	}
+
+	for _, path := range []string{"a[0", `a\`, "a[0]b", "[0"} {
+		if keys, err := parsePath(path); err != MalformedPathError {
+			t.Errorf("parsePath(%q) expected MalformedPathError, obtained %q, %v", path, keys, err)
+		}
+	}
+
+	for _, path := range []string{"a..b", "a.", ".a", ".", "a[0].", "a[0]..b", "a...b"} {
+		if keys, err := parsePath(path); err != EmptyPathSegmentError {
+			t.Errorf("parsePath(%q) expected EmptyPathSegmentError, obtained %q, %v", path, keys, err)
+		}
+	}
+
+	// Empty keys can still be written with PathString and looked up with Get, just not through the dotted form
+	if path := PathString([]string{"a", "", "b"}); path != "a..b" {
+		t.Errorf("PathString expected a..b, obtained %q", path)
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	data := []byte(`{"person": {"emails": [{"address": "a@b.c"}], "a.b": {"c[d]": 1}}, "list": [[1, 2]]}`)
+
+	paths := [][]string{
+		{"person", "emails", "[0]", "address"},
+		{"person", "a.b", "c[d]"},
+		{"list", "[0]", "[1]"},
+		{"person", "emails", "[1]"},
+		{"missing"},
+		{},
+	}
+	for _, keys := range paths {
+		value, dataType, offset, err := GetPath(data, PathString(keys))
+		eValue, eDataType, eOffset, eErr := Get(data, keys...)
+		if string(value) != string(eValue) || dataType != eDataType || offset != eOffset || err != eErr {
+			t.Errorf("GetPath(%q) returned %s, %s, %d, %v; Get returned %s, %s, %d, %v", PathString(keys), value, dataType, offset, err, eValue, eDataType, eOffset, eErr)
+		}
+	}
+
+	if _, _, _, err := GetPath(data, "person[0"); err != MalformedPathError {
+		t.Errorf("GetPath expected MalformedPathError, obtained %v", err)
+	}
+	if _, _, _, err := GetPath([]byte(`{"a": {"": {"b": 1}}}`), "a..b"); err != EmptyPathSegmentError {
+		t.Errorf("GetPath expected EmptyPathSegmentError, obtained %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	data := []byte(`{"user": {"name": "Ann", "tags": ["a", "b"], "empty": ""}, "a.b": 1, "n": null}`)
+	exprs := []string{"user.name", "user.tags[1]", "user.missing", `a\.b`, "n", "user.empty", "user.name", "nope[0]"}
+
+	values, err := Resolve(data, exprs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"user.name": "Ann", "user.tags[1]": "b", `a\.b`: "1", "n": "null", "user.empty": ""}
+	if len(values) != 7 {
+		t.Errorf("Resolve expected 7 expressions, obtained %d: %q", len(values), values)
+	}
+	for _, expr := range exprs {
+		value, ok := values[expr]
+		if !ok {
+			t.Errorf("Resolve(%q) missing from the result", expr)
+		} else if e, present := expected[expr]; present != (value != nil) || string(value) != e {
+			t.Errorf("Resolve(%q) expected %q (present %t), obtained %q", expr, e, present, value)
+		}
+	}
+
+	if values, err := Resolve(data, []string{""}); err != nil || string(values[""]) != string(data) {
+		t.Errorf("Resolve of the empty expression expected the whole document, obtained %q, %v", values[""], err)
+	}
+	if _, err := Resolve(data, []string{"user.name", "a..b"}); err == nil {
+		t.Errorf("Resolve with an invalid expression expected an error")
+	}
+	if _, err := Resolve([]byte(`{"user": {"name": "Ann`), []string{"user.name"}); err == nil {
+		t.Errorf("Resolve of a malformed document expected an error")
+	}
+}
+
+var compiledPathData = []byte(`{"a": {"b": [{"c": "x\"y"}, [1, {"d": true}], "s"]}, "e\"f": 2, "g": "h", "n": null, "a2": {"b": 3}}`)
+
+func TestCompiledPath(t *testing.T) {
+	paths := [][]string{
+		{"a", "b", "[0]", "c"},
+		{"a", "b", "[1]", "[1]", "d"},
+		{"a", "b", "[2]"},
+		{"a", "b", "[3]"},
+		{"a", "b"},
+		{"e\"f"},
+		{"g"},
+		{"g", "h"},
+		{"g", "[0]"},
+		{"a", "[0]"},
+		{"n"},
+		{"missing"},
+		{"a2", "b"},
+		{},
+	}
+
+	for _, keys := range paths {
+		cp, err := CompilePath(keys...)
+		if err != nil {
+			t.Fatalf("CompilePath(%q) failed: %v", keys, err)
+		}
+		// The same compiled path can be used over and over
+		for i := 0; i < 2; i++ {
+			value, dataType, offset, err := cp.Get(compiledPathData)
+			eValue, eDataType, eOffset, eErr := Get(compiledPathData, keys...)
+			if string(value) != string(eValue) || dataType != eDataType || offset != eOffset || err != eErr {
+				t.Errorf("CompiledPath(%q).Get returned %s, %s, %d, %v; Get returned %s, %s, %d, %v", keys, value, dataType, offset, err, eValue, eDataType, eOffset, eErr)
+			}
+		}
+	}
+
+	cp, _ := CompilePath("id")
+	for i, doc := range []string{`{"id": 1}`, `{"x": {"id": 0}, "id": 2}`, `[{"id": 3}]`, ` `} {
+		v, _, _, err := cp.Get([]byte(doc))
+		if ev, _, _, eErr := Get([]byte(doc), "id"); string(v) != string(ev) || err != eErr {
+			t.Errorf("CompiledPath.Get of document %d returned %s, %v; Get returned %s, %v", i, v, err, ev, eErr)
+		}
+	}
+
+	for _, keys := range [][]string{{"a", "[abc]"}, {"[]"}, {"a", "[-1]"}, {"[*]"}, {"[1"}} {
+		if _, err := CompilePath(keys...); err == nil {
+			t.Errorf("CompilePath(%q) should fail", keys)
+		}
+	}
+}
+
+func BenchmarkCompiledPathGet(b *testing.B) {
+	cp, _ := CompilePath("a", "b", "[1]", "[1]", "d")
+	for i := 0; i < b.N; i++ {
+		cp.Get(compiledPathData)
+	}
+}
+
+func BenchmarkCompiledPathGetUncompiled(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Get(compiledPathData, "a", "b", "[1]", "[1]", "d")
+	}
+}
@@ -0,0 +1,237 @@
+package jsonparser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// planField is a struct field filled by a Plan
+type planField struct {
+	index []int // field indexes from the root struct, through nested structs
+	name  string
+}
+
+// Plan fills structs of one type from JSON documents, see `CompilePlan`
+type Plan struct {
+	typ    reflect.Type
+	paths  [][]string // key path of every field, as passed to EachKey
+	fields []planField
+	err    error
+}
+
+/*
+
+CompilePlan - Receives a pointer to a struct, and prepares the mapping of its fields to key paths once, so that
+`Plan.Unmarshal` can fill structs of that type from a document in a single `EachKey` traversal instead of searching for
+every field separately.
+
+Fields are named as with `Marshal`, honouring `json` struct tags. Nested structs and pointers to structs are mapped to nested
+objects, so their fields get longer key paths. Supported field types are booleans, strings, integers, floats, []byte (as
+base64, like `Marshal` encodes it) and pointers to them.
+
+Problems, such as a field of another type or `v` not being a pointer to a struct, are reported by every `Plan.Unmarshal`
+call rather than here, so a plan can be compiled into a package-level variable.
+
+*/
+func CompilePlan(v interface{}) *Plan {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return &Plan{err: fmt.Errorf("CompilePlan needs a pointer to a struct, not %v", t)}
+	}
+
+	p := &Plan{typ: t.Elem()}
+	p.err = p.addFields(t.Elem(), nil, nil, map[reflect.Type]bool{})
+	return p
+}
+
+// addFields adds the fields of struct type `t`, found at key path `path` and field index `index`, to the plan.
+// `visiting` guards against types which contain themselves through a pointer.
+func (p *Plan) addFields(t reflect.Type, path []string, index []int, visiting map[reflect.Type]bool) error {
+	if visiting[t] {
+		return fmt.Errorf("Unsupported recursive type: %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for _, f := range structFields(t) {
+		fieldPath := append(path[:len(path):len(path)], f.name)
+		fieldIndex := append(index[:len(index):len(index)], f.index...)
+		name := strings.Join(fieldPath, ".")
+
+		if err := checkEmbeddedPointers(t, f.index, name); err != nil {
+			return err
+		}
+
+		ft := t.FieldByIndex(f.index).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		switch ft.Kind() {
+		case reflect.Struct:
+			if err := p.addFields(ft, fieldPath, fieldIndex, visiting); err != nil {
+				return err
+			}
+			continue
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+		case reflect.Slice:
+			if ft.Elem().Kind() != reflect.Uint8 {
+				return fmt.Errorf("Unsupported type %s for field %s", ft, name)
+			}
+		default:
+			return fmt.Errorf("Unsupported type %s for field %s", ft, name)
+		}
+
+		p.paths = append(p.paths, fieldPath)
+		p.fields = append(p.fields, planField{index: fieldIndex, name: name})
+	}
+	return nil
+}
+
+// checkEmbeddedPointers fails if the field at `index` in `t` is promoted through a pointer to an unexported embedded
+// struct, which can't be allocated through reflection
+func checkEmbeddedPointers(t reflect.Type, index []int, name string) error {
+	for _, x := range index[:len(index)-1] {
+		sf := t.Field(x)
+		t = sf.Type
+		if t.Kind() == reflect.Ptr {
+			if sf.PkgPath != "" {
+				return fmt.Errorf("Field %s is promoted through an unexported embedded pointer %s", name, t)
+			}
+			t = t.Elem()
+		}
+	}
+	return nil
+}
+
+// Unmarshal fills the struct `v` points to, which must have the type the plan was compiled for, from `data`. Fields whose
+// key isn't found, or whose value is null, are left unchanged; pointers on the way to a value which is found are allocated
+// as needed. Only the values mapped to fields are parsed, so the rest of the document isn't validated.
+func (p *Plan) Unmarshal(data []byte, v interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != p.typ {
+		return fmt.Errorf("Plan for %s can't unmarshal into %T", p.typ, v)
+	}
+	rv = rv.Elem()
+
+	var err error
+	EachKey(data, func(idx int, value []byte, vt ValueType, e error) {
+		if err != nil {
+			return
+		}
+		if e != nil {
+			err = e
+		} else if vt != Null {
+			f := p.fields[idx]
+			if e := setPlanValue(allocFieldByIndex(rv, f.index), value, vt); e != nil {
+				err = fmt.Errorf("Field %s: %v", f.name, e)
+			}
+		}
+	}, p.paths...)
+	return err
+}
+
+// allocFieldByIndex is like reflect.Value.FieldByIndex, but allocates nil pointers to structs on the way
+func allocFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, x := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// setPlanValue stores the JSON value into the field `v`, which has one of the types supported by `CompilePlan`
+func setPlanValue(v reflect.Value, value []byte, vt ValueType) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	var expected ValueType
+	switch v.Kind() {
+	case reflect.Bool:
+		if expected = Boolean; vt == expected {
+			b, err := ParseBoolean(value)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.String:
+		if expected = String; vt == expected {
+			s, err := ParseString(value)
+			if err != nil {
+				return err
+			}
+			v.SetString(s)
+			return nil
+		}
+	case reflect.Slice: // []byte
+		if expected = String; vt == expected {
+			s, err := ParseString(value)
+			if err != nil {
+				return err
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if expected = Number; vt == expected {
+			n, err := ParseInt(value)
+			if err != nil {
+				return err
+			}
+			if v.OverflowInt(n) {
+				return fmt.Errorf("Value %s overflows %s", value, v.Type())
+			}
+			v.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if expected = Number; vt == expected {
+			n, err := strconv.ParseUint(string(value), 10, 64)
+			if err != nil {
+				return MalformedValueError
+			}
+			if v.OverflowUint(n) {
+				return fmt.Errorf("Value %s overflows %s", value, v.Type())
+			}
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if expected = Number; vt == expected {
+			f, err := ParseFloat(value)
+			if err != nil {
+				return err
+			}
+			if v.OverflowFloat(f) {
+				return fmt.Errorf("Value %s overflows %s", value, v.Type())
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	}
+	return fmt.Errorf("Value is not a %s: %s", expected, value)
+}
@@ -0,0 +1,127 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type planAddress struct {
+	City string `json:"city"`
+	Zip  *int   `json:"zip"`
+}
+
+type PlanEmbedded struct {
+	Source string `json:"source"`
+}
+
+type planTest struct {
+	*PlanEmbedded
+	Name    string       `json:"name"`
+	Age     uint8        `json:"age"`
+	Score   float32      `json:"score"`
+	Active  bool         `json:"active"`
+	Nick    *string      `json:"nick"`
+	Raw     []byte       `json:"raw"`
+	Home    planAddress  `json:"home"`
+	Work    *planAddress `json:"work"`
+	Missing int          `json:"missing"`
+	Skipped string       `json:"-"`
+}
+
+func TestPlanUnmarshal(t *testing.T) {
+	data := []byte(`{
+		"name": "Ann \"A\"", "age": 42, "score": 1.5, "active": true, "nick": "annie", "raw": "aGk=",
+		"home": {"city": "Oslo", "zip": 150}, "work": {"city": "Bergen"}, "source": "crm", "Skipped": "x",
+		"extra": {"name": "ignored"}
+	}`)
+
+	plan := CompilePlan(&planTest{})
+	var v planTest
+	v.Missing = 7
+	if err := plan.Unmarshal(data, &v); err != nil {
+		t.Fatalf("Plan.Unmarshal returned error %v", err)
+	}
+
+	var expected planTest
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatal(err)
+	}
+	expected.Missing = 7
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Plan.Unmarshal expected %+v, obtained %+v", expected, v)
+	}
+
+	// null leaves fields unchanged, like encoding/json
+	if err := plan.Unmarshal([]byte(`{"name": null, "nick": null}`), &v); err != nil || v.Name != `Ann "A"` || v.Nick == nil {
+		t.Errorf("Plan.Unmarshal of null values returned unexpected %+v, %v", v, err)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	plan := CompilePlan(&planTest{})
+	var v planTest
+
+	for _, data := range []string{`{"age": 256}`, `{"age": -1}`, `{"name": 1}`, `{"active": "yes"}`, `{"raw": "!"}`, `{"home": {"zip": 1.5}}`} {
+		if err := plan.Unmarshal([]byte(data), &v); err == nil {
+			t.Errorf("Plan.Unmarshal(%s) expected an error", data)
+		}
+	}
+	if err := plan.Unmarshal([]byte(`{"age": 256}`), &v); err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("Plan.Unmarshal error should name the field, obtained %v", err)
+	}
+
+	if err := plan.Unmarshal([]byte(`{}`), v); err == nil {
+		t.Error("Plan.Unmarshal expected an error for a non-pointer")
+	}
+	if err := plan.Unmarshal([]byte(`{}`), &planAddress{}); err == nil {
+		t.Error("Plan.Unmarshal expected an error for another type")
+	}
+
+	type unsupported struct {
+		List []int `json:"list"`
+	}
+	type recursive struct {
+		Next *recursive
+	}
+	for _, v := range []interface{}{planTest{}, nil, &unsupported{}, &recursive{}} {
+		if err := CompilePlan(v).Unmarshal([]byte(`{}`), v); err == nil {
+			t.Errorf("CompilePlan(%T) expected an error", v)
+		}
+	}
+}
+
+type planBenchmark struct {
+	Person struct {
+		Name struct {
+			Full string `json:"fullName"`
+		} `json:"name"`
+		Github struct {
+			Followers int `json:"followers"`
+		} `json:"github"`
+	} `json:"person"`
+	Company string `json:"company"`
+}
+
+var planBenchmarkData = []byte(`{"person": {"id": "d50887ca-a6ce-4e59-b89f-14f0b5d03b03", "name": {"fullName": "Leonid Bugaev",
+	"givenName": "Leonid", "familyName": "Bugaev"}, "email": "leonsbox@gmail.com", "gender": "male", "location": "Saint Petersburg",
+	"github": {"handle": "buger", "id": 14009, "followers": 95, "following": 10}, "bio": "Senior engineer"},
+	"company": "Granify"}`)
+
+func BenchmarkPlanUnmarshal(b *testing.B) {
+	plan := CompilePlan(&planBenchmark{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v planBenchmark
+		plan.Unmarshal(planBenchmarkData, &v)
+	}
+}
+
+func BenchmarkEncodingJSONUnmarshal(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v planBenchmark
+		json.Unmarshal(planBenchmarkData, &v)
+	}
+}
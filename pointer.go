@@ -0,0 +1,99 @@
+package jsonparser
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	ExternalReferenceError = errors.New("Reference does not point into the same document")
+	MalformedPointerError  = errors.New("Reference is not a valid JSON pointer")
+	CyclicReferenceError   = errors.New("Reference cycle detected")
+	ReferenceDepthError    = errors.New("Too many chained references")
+)
+
+// maxReferenceDepth is the number of `$ref` hops GetResolved follows before giving up
+const maxReferenceDepth = 32
+
+// pointerKeys converts a JSON pointer (RFC 6901) in URI fragment form, like `#/definitions/a~1b/0`, into a key path for
+// `data`. Numeric segments become array indexes when they address an array.
+func pointerKeys(data []byte, ref string) ([]string, error) {
+	if len(ref) == 0 || ref[0] != '#' {
+		return nil, ExternalReferenceError
+	}
+	ptr, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, MalformedPointerError
+	}
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, MalformedPointerError
+	}
+
+	segments := strings.Split(ptr[1:], "/")
+	keys := make([]string, 0, len(segments))
+	for _, s := range segments {
+		s = strings.Replace(strings.Replace(s, "~1", "/", -1), "~0", "~", -1)
+
+		if _, t, _, err := Get(data, keys...); err != nil {
+			return nil, err
+		} else if t == Array {
+			if n, err := strconv.Atoi(s); err != nil || n < 0 || strconv.Itoa(n) != s {
+				return nil, KeyPathNotFoundError
+			}
+			s = "[" + s + "]"
+		}
+		keys = append(keys, s)
+	}
+	return keys, nil
+}
+
+/*
+
+GetResolved - Receives data structure and path like `Get`, and if the value found is a reference object such as
+`{"$ref":"#/definitions/a"}`, follows the reference to the value it points to, repeating as long as that is a reference too.
+
+Only references within the same document are supported; other members of a reference object are ignored.
+
+Returns:
+`value` - The referenced value, or the value at the path if it is not a reference
+`dataType` - Type of `value`, as with `Get`
+`err` - `ExternalReferenceError` for references to other documents, `CyclicReferenceError` or `ReferenceDepthError` when
+the references can't be resolved to a value, and any error `Get` returns
+
+*/
+func GetResolved(data []byte, keys ...string) ([]byte, ValueType, error) {
+	var visited map[string]bool
+
+	for {
+		value, dataType, _, err := Get(data, keys...)
+		if err != nil || dataType != Object {
+			return value, dataType, err
+		}
+
+		ref, err := GetString(value, "$ref")
+		if err != nil {
+			return value, dataType, nil // not a reference
+		}
+
+		if visited[ref] {
+			return nil, NotExist, CyclicReferenceError
+		}
+		if len(visited) == maxReferenceDepth {
+			return nil, NotExist, ReferenceDepthError
+		}
+		if visited == nil {
+			visited = map[string]bool{}
+		}
+		visited[ref] = true
+
+		if keys, err = pointerKeys(data, ref); err != nil {
+			return nil, NotExist, err
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package jsonparser
+
+import (
+	"strconv"
+	"testing"
+)
+
+var refDocument = []byte(`{
+	"definitions": {
+		"name": {"type": "string"},
+		"alias": {"$ref": "#/definitions/name"},
+		"a/b~c": {"type": "escaped"},
+		"list": [{"type": "first"}, {"$ref": "#/definitions/name"}],
+		"loop1": {"$ref": "#/definitions/loop2"},
+		"loop2": {"$ref": "#/definitions/loop1"},
+		"self": {"$ref": "#/definitions/self"}
+	},
+	"properties": {
+		"plain": {"type": "integer"},
+		"direct": {"$ref": "#/definitions/name"},
+		"chained": {"$ref": "#/definitions/alias"},
+		"escaped": {"$ref": "#/definitions/a~1b~0c"},
+		"percent": {"$ref": "#/definitions/a~1b%7E0c"},
+		"index": {"$ref": "#/definitions/list/0"},
+		"indexRef": {"$ref": "#/definitions/list/1"},
+		"root": {"$ref": "#"},
+		"external": {"$ref": "other.json#/definitions/name"},
+		"missing": {"$ref": "#/definitions/nope"},
+		"badIndex": {"$ref": "#/definitions/list/01"},
+		"loop": {"$ref": "#/definitions/loop1"},
+		"self": {"$ref": "#/definitions/self"},
+		"nonString": {"$ref": 1}
+	}
+}`)
+
+var getResolvedTests = []struct {
+	desc     string
+	key      string
+	typeName string // value of the resolved object's "type" member
+	err      error
+}{
+	{desc: "not a reference", key: "plain", typeName: "integer"},
+	{desc: "direct reference", key: "direct", typeName: "string"},
+	{desc: "chained reference", key: "chained", typeName: "string"},
+	{desc: "escaped pointer", key: "escaped", typeName: "escaped"},
+	{desc: "percent-encoded pointer", key: "percent", typeName: "escaped"},
+	{desc: "array index", key: "index", typeName: "first"},
+	{desc: "reference in array", key: "indexRef", typeName: "string"},
+	{desc: "external reference", key: "external", err: ExternalReferenceError},
+	{desc: "missing target", key: "missing", err: KeyPathNotFoundError},
+	{desc: "non-canonical array index", key: "badIndex", err: KeyPathNotFoundError},
+	{desc: "reference cycle", key: "loop", err: CyclicReferenceError},
+	{desc: "self reference", key: "self", err: CyclicReferenceError},
+}
+
+func TestGetResolved(t *testing.T) {
+	for _, test := range getResolvedTests {
+		value, dataType, err := GetResolved(refDocument, "properties", test.key)
+		if err != test.err {
+			t.Errorf("GetResolved test '%s' expected error %v, obtained %v", test.desc, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if dataType != Object {
+			t.Errorf("GetResolved test '%s' expected an object, obtained %s", test.desc, dataType)
+		}
+		if typeName, _ := GetString(value, "type"); typeName != test.typeName {
+			t.Errorf("GetResolved test '%s' expected type %q, obtained %q", test.desc, test.typeName, typeName)
+		}
+	}
+
+	// A reference to the whole document
+	if value, _, err := GetResolved(refDocument, "properties", "root"); err != nil || len(value) != len(refDocument) {
+		t.Errorf("GetResolved should resolve '#' to the whole document, obtained %d bytes, %v", len(value), err)
+	}
+
+	// A "$ref" member which is not a string is not a reference
+	if value, _, err := GetResolved(refDocument, "properties", "nonString"); err != nil || string(value) != `{"$ref": 1}` {
+		t.Errorf("GetResolved should return non-string $ref objects as is, obtained %s, %v", value, err)
+	}
+
+	// Scalars are returned as with Get
+	if value, dataType, err := GetResolved(refDocument, "properties", "plain", "type"); err != nil || dataType != String || string(value) != "integer" {
+		t.Errorf("GetResolved returned unexpected %s, %s, %v", value, dataType, err)
+	}
+}
+
+func TestGetResolvedDepthLimit(t *testing.T) {
+	data := []byte(`{`)
+	for i := 0; i <= maxReferenceDepth; i++ {
+		data = append(data, `"r`+strconv.Itoa(i)+`":{"$ref":"#/r`+strconv.Itoa(i+1)+`"},`...)
+	}
+	data = append(data, `"end":1}`...)
+
+	if _, _, err := GetResolved(data, "r0"); err != ReferenceDepthError {
+		t.Errorf("GetResolved expected ReferenceDepthError, obtained %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package jsonparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	MalformedQueryError = errors.New("Query is not a valid expression of the supported JSONPath subset")
+)
+
+/*
+
+Query - Evaluates a JSONPath-like expression against `data`, invoking `cb` with every value it selects, in document
+order. Values are passed like `Get` returns them, so string contents are not unescaped.
+
+The supported subset of JSONPath is:
+
+- `$` - the root, optional at the start of the expression.
+- `.name` or `['name']` - the member `name` of an object. The dot can be omitted for the first member, e.g. `users[0]`.
+- `*` or `[*]` (e.g. `.*`) - every member of an object or element of an array.
+- `[n]` - the element at index `n` of an array, counting from the end if negative.
+- `[start:end]` - the elements of an array from `start` up to, but not including, `end`. Either bound can be omitted,
+and negative bounds count from the end. Steps are not supported.
+- `..name`, `..*`, `..[n]` etc. - recursive descent: the selector which follows is applied to the current value and
+to all of its descendants.
+- `[?(@.field op literal)]` - the elements of an array, or members of an object, which are objects whose `field` (a
+dotted path such as `@.a.b`) compares to the literal with `op`: one of `==`, `!=`, `<`, `<=`, `>` and `>=`. Literals are
+numbers, strings in single or double quotes (without escapes), `true`, `false` and `null`. Numbers and strings are
+compared by value, booleans and null only for (in)equality. A missing field, or a field of another type than the
+literal, never matches.
+
+Unions, functions, script expressions and filters with more than one comparison are not supported. Selecting a member
+of something which is not an object, or an element of something which is not an array, selects nothing.
+
+Returns:
+`err` - `MalformedQueryError` if `expr` is not in the supported subset, or an error met while parsing the parts of
+`data` the query walks through
+
+*/
+func Query(data []byte, expr string, cb func(value []byte, dataType ValueType)) error {
+	segments, err := parseQuery(expr)
+	if err != nil {
+		return err
+	}
+	value, dataType, _, err := Get(data)
+	if err != nil {
+		return err
+	}
+	return evalQuery(value, dataType, segments, 0, cb)
+}
+
+// querySelector is the kind of a query segment
+type querySelector int
+
+const (
+	qsName querySelector = iota
+	qsWildcard
+	qsIndex
+	qsSlice
+	qsFilter
+)
+
+// querySegment is one step of a parsed query
+type querySegment struct {
+	selector  querySelector
+	recursive bool   // whether the selector applies to all descendants too (`..`)
+	name      string // qsName
+	index     int    // qsIndex, or the start of a qsSlice
+	end       int    // end of a qsSlice
+	hasStart  bool
+	hasEnd    bool
+	filter    *queryFilter
+}
+
+// queryFilter is the comparison of a `[?(@.field op literal)]` segment
+type queryFilter struct {
+	path    []string
+	op      string
+	litType ValueType // String, Number, Boolean or Null
+	str     string    // String literal
+	num     float64   // Number literal
+	boolean bool      // Boolean literal
+}
+
+// parseQuery parses an expression accepted by `Query`
+func parseQuery(expr string) ([]querySegment, error) {
+	var segments []querySegment
+	i := 0
+	if strings.HasPrefix(expr, "$") {
+		i = 1
+	}
+
+	for i < len(expr) {
+		var seg querySegment
+		switch {
+		case strings.HasPrefix(expr[i:], ".."):
+			seg.recursive = true
+			i += 2
+		case expr[i] == '.':
+			i++
+			if i < len(expr) && expr[i] == '[' {
+				return nil, MalformedQueryError
+			}
+		case expr[i] == '[' || i == 0:
+		default:
+			return nil, MalformedQueryError
+		}
+
+		if i < len(expr) && expr[i] == '[' {
+			n, err := parseQueryBracket(expr[i:], &seg)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+		} else {
+			end := i
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+			switch name := expr[i:end]; name {
+			case "":
+				return nil, MalformedQueryError
+			case "*":
+				seg.selector = qsWildcard
+			default:
+				seg.selector, seg.name = qsName, name
+			}
+			i = end
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// parseQueryBracket parses the bracketed selector at the beginning of `s` into `seg`, returning its length
+func parseQueryBracket(s string, seg *querySegment) (int, error) {
+	if strings.HasPrefix(s, "[?(") {
+		end := queryFilterEnd(s)
+		if end == -1 {
+			return 0, MalformedQueryError
+		}
+		f, err := parseQueryFilter(s[3:end])
+		if err != nil {
+			return 0, err
+		}
+		seg.selector, seg.filter = qsFilter, f
+		return end + 2, nil
+	}
+
+	if len(s) > 1 && (s[1] == '\'' || s[1] == '"') {
+		end := strings.IndexByte(s[2:], s[1])
+		if end == -1 || !strings.HasPrefix(s[2+end+1:], "]") {
+			return 0, MalformedQueryError
+		}
+		seg.selector, seg.name = qsName, s[2:2+end]
+		return 2 + end + 2, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end == -1 {
+		return 0, MalformedQueryError
+	}
+	content := s[1:end]
+	switch colon := strings.IndexByte(content, ':'); {
+	case content == "*":
+		seg.selector = qsWildcard
+	case colon != -1:
+		seg.selector = qsSlice
+		var err error
+		if seg.index, seg.hasStart, err = parseQueryBound(content[:colon]); err != nil {
+			return 0, err
+		}
+		if seg.end, seg.hasEnd, err = parseQueryBound(content[colon+1:]); err != nil {
+			return 0, err
+		}
+	default:
+		n, err := strconv.Atoi(content)
+		if err != nil || content[0] == '+' {
+			return 0, MalformedQueryError
+		}
+		seg.selector, seg.index = qsIndex, n
+	}
+	return end + 1, nil
+}
+
+// parseQueryBound parses an optional slice bound
+func parseQueryBound(s string) (int, bool, error) {
+	if s == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || s[0] == '+' {
+		return 0, false, MalformedQueryError
+	}
+	return n, true, nil
+}
+
+// queryFilterEnd returns the offset of the `)]` closing the filter at the beginning of `s`, skipping quoted literals, or
+// -1 if there is none
+func queryFilterEnd(s string) int {
+	var quote byte
+	for i := 3; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ')' && i+1 < len(s) && s[i+1] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseQueryFilter parses the `@.field op literal` comparison of a filter
+func parseQueryFilter(s string) (*queryFilter, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "@.") {
+		return nil, MalformedQueryError
+	}
+	opStart := strings.IndexAny(s, "=!<>")
+	if opStart == -1 {
+		return nil, MalformedQueryError
+	}
+
+	f := &queryFilter{path: strings.Split(strings.TrimSpace(s[2:opStart]), ".")}
+	for _, k := range f.path {
+		if k == "" {
+			return nil, MalformedQueryError
+		}
+	}
+
+	rest := s[opStart:]
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, op) {
+			f.op = op
+			break
+		}
+	}
+	if f.op == "" {
+		return nil, MalformedQueryError
+	}
+
+	lit := strings.TrimSpace(rest[len(f.op):])
+	switch {
+	case len(lit) >= 2 && (lit[0] == '\'' || lit[0] == '"') && lit[len(lit)-1] == lit[0]:
+		if strings.IndexByte(lit[1:len(lit)-1], lit[0]) != -1 {
+			return nil, MalformedQueryError
+		}
+		f.litType, f.str = String, lit[1:len(lit)-1]
+	case lit == "true" || lit == "false":
+		f.litType, f.boolean = Boolean, lit == "true"
+	case lit == "null":
+		f.litType = Null
+	case isNumber([]byte(lit)):
+		f.litType = Number
+		f.num, _ = strconv.ParseFloat(lit, 64)
+	default:
+		return nil, MalformedQueryError
+	}
+
+	if (f.litType == Boolean || f.litType == Null) && f.op != "==" && f.op != "!=" {
+		return nil, MalformedQueryError
+	}
+	return f, nil
+}
+
+// match reports whether the value, an element or member value, passes the filter
+func (f *queryFilter) match(value []byte, dataType ValueType) bool {
+	if dataType != Object {
+		return false
+	}
+	v, t, _, err := Get(value, f.path...)
+	if err != nil || t != f.litType {
+		return false
+	}
+
+	cmp := 0
+	switch t {
+	case Number:
+		n, err := ParseFloat(v)
+		if err != nil {
+			return false
+		}
+		if n < f.num {
+			cmp = -1
+		} else if n > f.num {
+			cmp = 1
+		}
+	case String:
+		s, err := ParseString(v)
+		if err != nil {
+			return false
+		}
+		cmp = strings.Compare(s, f.str)
+	case Boolean:
+		b, err := ParseBoolean(v)
+		if err != nil {
+			return false
+		}
+		if b != f.boolean {
+			cmp = 1
+		}
+	}
+
+	switch f.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+// evalQuery applies the query segments to a value, as returned by `Get`, found `depth` levels below the queried value
+func evalQuery(value []byte, dataType ValueType, segments []querySegment, depth int, cb func(value []byte, dataType ValueType)) error {
+	if len(segments) == 0 {
+		cb(value, dataType)
+		return nil
+	}
+	if depth >= MaxNestingDepth && (dataType == Object || dataType == Array) {
+		return MaxDepthExceededError
+	}
+	seg, rest := segments[0], segments[1:]
+
+	if seg.recursive {
+		here := seg
+		here.recursive = false
+		if err := evalQuery(value, dataType, append([]querySegment{here}, rest...), depth, cb); err != nil {
+			return err
+		}
+		return eachQueryChild(value, dataType, func(v []byte, t ValueType) error {
+			return evalQuery(v, t, segments, depth+1, cb)
+		})
+	}
+
+	switch seg.selector {
+	case qsName:
+		if dataType != Object {
+			return nil
+		}
+		v, t, _, err := Get(value, seg.name)
+		if err == KeyPathNotFoundError {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return evalQuery(v, t, rest, depth+1, cb)
+	case qsWildcard:
+		return eachQueryChild(value, dataType, func(v []byte, t ValueType) error {
+			return evalQuery(v, t, rest, depth+1, cb)
+		})
+	case qsFilter:
+		return eachQueryChild(value, dataType, func(v []byte, t ValueType) error {
+			if !seg.filter.match(v, t) {
+				return nil
+			}
+			return evalQuery(v, t, rest, depth+1, cb)
+		})
+	}
+
+	// Index and slice selectors need the number of elements to resolve negative positions
+	if dataType != Array {
+		return nil
+	}
+	type element struct {
+		value    []byte
+		dataType ValueType
+	}
+	var elements []element
+	if err := eachQueryChild(value, dataType, func(v []byte, t ValueType) error {
+		elements = append(elements, element{v, t})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	n := len(elements)
+	start, end := seg.index, seg.index+1
+	if seg.selector == qsSlice {
+		start, end = 0, n
+		if seg.hasStart {
+			start = seg.index
+		}
+		if seg.hasEnd {
+			end = seg.end
+		}
+	}
+	if start < 0 {
+		start += n
+	}
+	if end < 0 || seg.selector == qsIndex && seg.index < 0 {
+		end += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	for i := start; i < end; i++ {
+		if err := evalQuery(elements[i].value, elements[i].dataType, rest, depth+1, cb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachQueryChild calls `fn` with every member value of an object or element of an array, and nothing for other values
+func eachQueryChild(value []byte, dataType ValueType, fn func(value []byte, dataType ValueType) error) error {
+	switch dataType {
+	case Object:
+		return ObjectEach(value, func(key []byte, v []byte, t ValueType, offset int) error {
+			return fn(v, t)
+		})
+	case Array:
+		var fnErr error
+		_, err := ArrayEach(value, func(v []byte, t ValueType, offset int, e error) {
+			if fnErr == nil {
+				fnErr = fn(v, t)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return fnErr
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package jsonparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+var queryData = []byte(`{
+	"users": [
+		{"name": "ann", "age": 17, "tags": ["a"]},
+		{"name": "bob", "age": 42, "address": {"city": "Oslo"}},
+		{"name": "cid", "age": 19, "admin": true},
+		{"name": "dee", "age": "old"}
+	],
+	"owner": {"name": "eve", "age": 30},
+	"count": 4
+}`)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		// Child, index and slice
+		{expr: `$.users[0].name`, expected: []string{"ann"}},
+		{expr: `users[1].address.city`, expected: []string{"Oslo"}},
+		{expr: `$['owner']['name']`, expected: []string{"eve"}},
+		{expr: `$.users[-1].name`, expected: []string{"dee"}},
+		{expr: `$.users[9].name`, expected: nil},
+		{expr: `$.users[1:3].name`, expected: []string{"bob", "cid"}},
+		{expr: `$.users[:2].name`, expected: []string{"ann", "bob"}},
+		{expr: `$.users[-2:].name`, expected: []string{"cid", "dee"}},
+		{expr: `$.users[2:1].name`, expected: nil},
+
+		// Wildcards
+		{expr: `$.users[*].name`, expected: []string{"ann", "bob", "cid", "dee"}},
+		{expr: `$.owner.*`, expected: []string{"eve", "30"}},
+		{expr: `$.count[*]`, expected: nil},
+
+		// Recursive descent
+		{expr: `$..name`, expected: []string{"ann", "bob", "cid", "dee", "eve"}},
+		{expr: `$..city`, expected: []string{"Oslo"}},
+		{expr: `$..tags[0]`, expected: []string{"a"}},
+
+		// Filters
+		{expr: `$.users[?(@.age>18)].name`, expected: []string{"bob", "cid"}},
+		{expr: `users[?(@.age >= 19)].name`, expected: []string{"bob", "cid"}},
+		{expr: `$.users[?(@.age < 18)].name`, expected: []string{"ann"}},
+		{expr: `$.users[?(@.age != 42)].name`, expected: []string{"ann", "cid"}},
+		{expr: `$.users[?(@.name == 'bob')].age`, expected: []string{"42"}},
+		{expr: `$.users[?(@.name > "bz")].name`, expected: []string{"cid", "dee"}},
+		{expr: `$.users[?(@.admin == true)].name`, expected: []string{"cid"}},
+		{expr: `$.users[?(@.address.city == "Oslo")].name`, expected: []string{"bob"}},
+		{expr: `$..[?(@.age == 30)].name`, expected: []string{"eve"}},
+	}
+
+	for _, test := range tests {
+		var results []string
+		err := Query(queryData, test.expr, func(value []byte, dataType ValueType) {
+			results = append(results, string(value))
+		})
+		if err != nil {
+			t.Errorf("Query(%s) returned error %v", test.expr, err)
+		} else if !reflect.DeepEqual(results, test.expected) {
+			t.Errorf("Query(%s) expected %q, obtained %q", test.expr, test.expected, results)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, expr := range []string{
+		`$.`, `$..`, `$users`, `a..`, `a.[0]`, `a[0`, `a[x]`, `a[+1]`, `a[1:x]`, `a['b]`, `a['b'`,
+		`a[?(@.b > 1]`, `a[?(b > 1)]`, `a[?(@.b)]`, `a[?(@.b ~ 1)]`, `a[?(@. > 1)]`, `a[?(@.b > x)]`,
+		`a[?(@.b > true)]`, `a[?(@.b == 'it's')]`,
+	} {
+		if err := Query(queryData, expr, func([]byte, ValueType) {}); err != MalformedQueryError {
+			t.Errorf("Query(%s) should fail with MalformedQueryError, obtained %v", expr, err)
+		}
+	}
+
+	if err := Query([]byte(`{"a": [1, }`), `$.a[0]`, func([]byte, ValueType) {}); err == nil {
+		t.Error("Query should fail on malformed data")
+	}
+}
@@ -0,0 +1,47 @@
+package jsonparser
+
+// Slice is a part of a larger JSON document, together with the offset at which it starts in that document. Its methods
+// work like the package-level functions on `Data`, but return offsets relative to the original document instead of
+// relative to `Data`, so sub-documents can be passed around without losing track of where they came from.
+type Slice struct {
+	Data []byte
+	Base int // offset of Data[0] in the original document
+}
+
+// rebase converts an offset within s.Data into an offset within the original document, leaving -1 (not found) as is
+func (s Slice) rebase(offset int) int {
+	if offset < 0 {
+		return offset
+	}
+	return s.Base + offset
+}
+
+// Sub returns the part of the slice between `start` and `end`, which are relative to s.Data like regular slice indexes
+func (s Slice) Sub(start, end int) Slice {
+	return Slice{Data: s.Data[start:end], Base: s.Base + start}
+}
+
+// Get is like the package-level `Get`, with `offset` relative to the original document
+func (s Slice) Get(keys ...string) (value []byte, dataType ValueType, offset int, err error) {
+	value, dataType, offset, err = Get(s.Data, keys...)
+	return value, dataType, s.rebase(offset), err
+}
+
+// GetSlice is like `Get`, but returns the value as a Slice, so it can be searched further without losing its position.
+// String values include their quotes, as with `GetRaw`.
+func (s Slice) GetSlice(keys ...string) (Slice, ValueType, error) {
+	_, dataType, start, end, err := internalGet(s.Data, keys...)
+	if err != nil {
+		return Slice{}, dataType, err
+	}
+	return s.Sub(start, end), dataType, nil
+}
+
+// ArrayEach is like the package-level `ArrayEach`, with the offsets passed to `cb` and the returned offset relative to
+// the original document
+func (s Slice) ArrayEach(cb func(value []byte, dataType ValueType, offset int, err error), keys ...string) (offset int, err error) {
+	offset, err = ArrayEach(s.Data, func(value []byte, dataType ValueType, offset int, err error) {
+		cb(value, dataType, s.rebase(offset), err)
+	}, keys...)
+	return s.rebase(offset), err
+}
@@ -0,0 +1,58 @@
+package jsonparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlice(t *testing.T) {
+	data := []byte(`{"padding": "xxxx", "sub": {"a": [1, "two", {"b": true}], "c": 3}}`)
+
+	// Carve out the "sub" object by hand, the way callers typically do
+	_, _, end, _ := Get(data, "sub")
+	start := end - len(`{"a": [1, "two", {"b": true}], "c": 3}`)
+	s := Slice{Data: data[start:end], Base: start}
+
+	value, dataType, offset, err := s.Get("c")
+	if err != nil || string(value) != "3" || dataType != Number {
+		t.Fatalf("Slice.Get returned unexpected %s, %s, %v", value, dataType, err)
+	}
+	if _, _, expected, _ := Get(data, "sub", "c"); offset != expected {
+		t.Errorf("Slice.Get offset expected %d, obtained %d", expected, offset)
+	}
+
+	if _, _, offset, err := s.Get("missing"); err != KeyPathNotFoundError || offset != -1 {
+		t.Errorf("Slice.Get of a missing key expected -1, KeyPathNotFoundError, obtained %d, %v", offset, err)
+	}
+
+	var offsets, expected []int
+	sliceEnd, err := s.ArrayEach(func(value []byte, dataType ValueType, offset int, err error) {
+		offsets = append(offsets, offset)
+	}, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEnd, _ := ArrayEach(data, func(value []byte, dataType ValueType, offset int, err error) {
+		expected = append(expected, offset)
+	}, "sub", "a")
+	if !reflect.DeepEqual(offsets, expected) || sliceEnd != expectedEnd {
+		t.Errorf("Slice.ArrayEach offsets expected %v, %d, obtained %v, %d", expected, expectedEnd, offsets, sliceEnd)
+	}
+
+	// Nested slices keep the original base
+	inner, dataType, err := s.GetSlice("a", "[2]")
+	if err != nil || dataType != Object || string(inner.Data) != `{"b": true}` {
+		t.Fatalf("Slice.GetSlice returned unexpected %s, %s, %v", inner.Data, dataType, err)
+	}
+	if string(data[inner.Base:inner.Base+len(inner.Data)]) != `{"b": true}` {
+		t.Errorf("Slice.GetSlice base %d doesn't point at the value in the original document", inner.Base)
+	}
+	if _, _, offset, _ := inner.Get("b"); string(data[offset-4:offset]) != "true" {
+		t.Errorf("nested Slice.Get offset %d doesn't point past the value in the original document", offset)
+	}
+
+	str, _, _ := s.GetSlice("a", "[1]")
+	if string(str.Data) != `"two"` || string(data[str.Base:str.Base+5]) != `"two"` {
+		t.Errorf("Slice.GetSlice of a string returned unexpected %s at %d", str.Data, str.Base)
+	}
+}
@@ -0,0 +1,70 @@
+package jsonparser
+
+import (
+	"io"
+)
+
+/*
+
+Transform - Streams `data` to `w`, replacing the value of every object member whose key has a rule, at any depth, with
+the output of that rule. Everything else, including whitespace, is copied verbatim, and no tree is built, which makes
+it suitable for e.g. redacting fields of large log records.
+
+A rule receives the value and its type the way `Get` returns them (strings without their quotes, still escaped) and
+returns the raw JSON to write instead, e.g. `"***"` or `null`. Members inside a replaced value are not visited.
+
+Output is written as `data` is scanned, so on a malformed document the error is returned after the part before the
+problem has already been written.
+
+*/
+func Transform(w io.Writer, data []byte, rules map[string]func(value []byte, dataType ValueType) []byte) error {
+	var stackbuf [unescapeStackBufSize]byte // stack-allocated array for allocation-free unescaping of small strings
+
+	last := 0 // everything before this offset has been written
+	for i := 0; i < len(data); i++ {
+		if data[i] != '"' {
+			continue
+		}
+
+		keyBegin := i + 1
+		strEnd, keyEscaped := stringEnd(data[keyBegin:])
+		if strEnd == -1 {
+			return MalformedStringError
+		}
+		i = keyBegin + strEnd - 1 // closing quote
+
+		colon := nextToken(data[i+1:])
+		if colon == -1 || data[i+1+colon] != ':' {
+			continue // not a key
+		}
+
+		key := data[keyBegin:i]
+		if keyEscaped {
+			var err error
+			if key, err = Unescape(key, stackbuf[:]); err != nil {
+				return MalformedStringEscapeError
+			}
+		}
+		rule, ok := rules[string(key)]
+		if !ok {
+			continue
+		}
+
+		valueBegin := i + 1 + colon + 1
+		value, dataType, start, end, err := internalGet(data[valueBegin:])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data[last : valueBegin+start]); err != nil {
+			return err
+		}
+		if _, err := w.Write(rule(value, dataType)); err != nil {
+			return err
+		}
+		last = valueBegin + end
+		i = last - 1
+	}
+
+	_, err := w.Write(data[last:])
+	return err
+}
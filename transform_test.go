@@ -0,0 +1,48 @@
+package jsonparser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	data := []byte(`{"user": "bob", "password": "hunter\"2", "nested": {"password": 123, "list": [{"password": null}]},
+	"token": {"password": "inner"}, "note": "\"password\": \"in a string\""}`)
+
+	redact := func(value []byte, dataType ValueType) []byte {
+		return []byte(`"***"`)
+	}
+	var seen []string
+	rules := map[string]func([]byte, ValueType) []byte{
+		"password": redact,
+		"token": func(value []byte, dataType ValueType) []byte {
+			seen = append(seen, dataType.String()+" "+string(value))
+			return []byte(`null`)
+		},
+	}
+
+	var out bytes.Buffer
+	if err := Transform(&out, data, rules); err != nil {
+		t.Fatalf("Transform returned error %v", err)
+	}
+
+	expected := `{"user": "bob", "password": "***", "nested": {"password": "***", "list": [{"password": "***"}]},
+	"token": null, "note": "\"password\": \"in a string\""}`
+	if out.String() != expected {
+		t.Errorf("Transform expected\n%s\nobtained\n%s", expected, out.String())
+	}
+	if len(seen) != 1 || seen[0] != `object {"password": "inner"}` {
+		t.Errorf("Transform passed unexpected values to the rule: %q", seen)
+	}
+
+	// Without matching keys the document is copied as is
+	out.Reset()
+	if err := Transform(&out, data, nil); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("Transform without rules expected a verbatim copy, obtained %s, %v", out.Bytes(), err)
+	}
+
+	out.Reset()
+	if err := Transform(&out, []byte(`{"a": 1, "password": "unterminated}`), rules); err == nil {
+		t.Error("Transform expected an error for malformed data")
+	}
+}
@@ -0,0 +1,295 @@
+package jsonparser
+
+import (
+	"fmt"
+)
+
+// States of ValidateIncremental
+const (
+	vsValue       = iota // expecting a value
+	vsArrayFirst         // after '[': expecting a value or ']'
+	vsObjectFirst        // after '{': expecting a key or '}'
+	vsKey                // after ',' in an object: expecting a key
+	vsColon              // after a key: expecting ':'
+	vsAfterValue         // after a value inside a container: expecting ',' or the closing bracket
+	vsEnd                // after the root value: only whitespace is allowed
+	vsString             // inside a string
+	vsEscape             // after '\' inside a string
+	vsUnicode            // inside a \uXXXX escape sequence
+	vsLiteral            // inside true, false or null
+	vsNeg                // after the leading '-' of a number
+	vsZero               // after a leading '0'
+	vsInt                // inside the integer part
+	vsDot                // after the decimal point
+	vsFrac               // inside the fraction
+	vsExp                // after 'e' or 'E'
+	vsExpSign            // after the sign of the exponent
+	vsExpDigits          // inside the exponent
+)
+
+/*
+
+ValidateIncremental checks whether a JSON document is well-formed while it is being received in chunks, e.g. an upload
+streamed through `io.Copy`. The document is never buffered: only the nesting of the currently open objects and arrays
+and the position inside the current token are kept between calls to `Write`, so a chunk may end anywhere, including in
+the middle of a string, an escape sequence or a number.
+
+Strings are checked for valid escape sequences and the absence of control characters, but not for UTF-8 validity.
+
+The zero value is ready to use. Feed the whole document through `Write`, then call `Done` for the verdict.
+
+*/
+type ValidateIncremental struct {
+	state   int
+	stack   []byte // open containers, '{' or '['
+	isKey   bool   // whether the current string is an object key
+	pending string // rest of the literal being matched, or the number of hex digits left in a \u escape
+	offset  int
+	err     error
+}
+
+// Write consumes the next chunk of the document. It fails as soon as the data seen so far can't be the beginning of a
+// valid document, reporting the offset of the offending byte; all later calls return the same error.
+func (v *ValidateIncremental) Write(p []byte) (n int, err error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	for i, c := range p {
+		if !v.step(c) {
+			v.err = fmt.Errorf("Invalid JSON: unexpected %q at offset %d", c, v.offset)
+			return i, v.err
+		}
+		v.offset++
+	}
+	return len(p), nil
+}
+
+// Done reports whether everything written so far forms exactly one valid JSON document
+func (v *ValidateIncremental) Done() error {
+	if v.err != nil {
+		return v.err
+	}
+
+	switch v.state {
+	case vsEnd:
+		return nil
+	case vsZero, vsInt, vsFrac, vsExpDigits:
+		if len(v.stack) == 0 { // a number is the root value; it can only end here
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid JSON: unexpected end of input at offset %d", v.offset)
+}
+
+// SafeParse reports whether `data` is exactly one valid JSON document, as checked by `ValidateIncremental`. It never
+// panics, whatever the input: a panic inside the validator is recovered and reported as invalid. This makes it a cheap
+// pre-check for untrusted input before using the faster, lenient lookups, which don't validate the parts of a document
+// they skip.
+func SafeParse(data []byte) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	var v ValidateIncremental
+	if _, err := v.Write(data); err != nil {
+		return false
+	}
+	return v.Done() == nil
+}
+
+func (v *ValidateIncremental) step(c byte) bool {
+	switch v.state {
+	case vsValue:
+		if isSpace(c) {
+			return true
+		}
+		return v.beginValue(c)
+	case vsArrayFirst:
+		if isSpace(c) {
+			return true
+		}
+		if c == ']' {
+			return v.closeContainer('[')
+		}
+		return v.beginValue(c)
+	case vsObjectFirst, vsKey:
+		if isSpace(c) {
+			return true
+		}
+		if c == '}' && v.state == vsObjectFirst {
+			return v.closeContainer('{')
+		}
+		if c == '"' {
+			v.state, v.isKey = vsString, true
+			return true
+		}
+		return false
+	case vsColon:
+		if isSpace(c) {
+			return true
+		}
+		if c == ':' {
+			v.state = vsValue
+			return true
+		}
+		return false
+	case vsAfterValue:
+		switch c {
+		case ' ', '\n', '\r', '\t':
+			return true
+		case ',':
+			if v.stack[len(v.stack)-1] == '{' {
+				v.state = vsKey
+			} else {
+				v.state = vsValue
+			}
+			return true
+		case '}':
+			return v.closeContainer('{')
+		case ']':
+			return v.closeContainer('[')
+		}
+		return false
+	case vsEnd:
+		return isSpace(c)
+
+	case vsString:
+		switch {
+		case c == '"':
+			if v.isKey {
+				v.state = vsColon
+			} else {
+				v.endValue()
+			}
+		case c == '\\':
+			v.state = vsEscape
+		case c < 0x20:
+			return false
+		}
+		return true
+	case vsEscape:
+		switch c {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			v.state = vsString
+		case 'u':
+			v.state, v.pending = vsUnicode, "XXXX"
+		default:
+			return false
+		}
+		return true
+	case vsUnicode:
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+		if v.pending = v.pending[1:]; v.pending == "" {
+			v.state = vsString
+		}
+		return true
+	case vsLiteral:
+		if c != v.pending[0] {
+			return false
+		}
+		if v.pending = v.pending[1:]; v.pending == "" {
+			v.endValue()
+		}
+		return true
+
+	case vsNeg:
+		switch {
+		case c == '0':
+			v.state = vsZero
+		case '1' <= c && c <= '9':
+			v.state = vsInt
+		default:
+			return false
+		}
+		return true
+	case vsDot, vsExpSign:
+		if c < '0' || c > '9' {
+			return false
+		}
+		if v.state == vsDot {
+			v.state = vsFrac
+		} else {
+			v.state = vsExpDigits
+		}
+		return true
+	case vsExp:
+		switch {
+		case c == '+' || c == '-':
+			v.state = vsExpSign
+		case '0' <= c && c <= '9':
+			v.state = vsExpDigits
+		default:
+			return false
+		}
+		return true
+	case vsZero, vsInt, vsFrac, vsExpDigits:
+		switch {
+		case '0' <= c && c <= '9' && v.state != vsZero:
+			return true
+		case c == '.' && (v.state == vsZero || v.state == vsInt):
+			v.state = vsDot
+			return true
+		case (c == 'e' || c == 'E') && v.state != vsExpDigits:
+			v.state = vsExp
+			return true
+		}
+		// The number ended with the previous byte, so this one belongs to whatever follows it
+		v.endValue()
+		return v.step(c)
+	}
+
+	return false
+}
+
+// beginValue starts the value whose first byte is c
+func (v *ValidateIncremental) beginValue(c byte) bool {
+	switch c {
+	case '{', '[':
+		v.stack = append(v.stack, c)
+		if c == '{' {
+			v.state = vsObjectFirst
+		} else {
+			v.state = vsArrayFirst
+		}
+	case '"':
+		v.state, v.isKey = vsString, false
+	case '-':
+		v.state = vsNeg
+	case '0':
+		v.state = vsZero
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		v.state = vsInt
+	case 't':
+		v.state, v.pending = vsLiteral, "rue"
+	case 'f':
+		v.state, v.pending = vsLiteral, "alse"
+	case 'n':
+		v.state, v.pending = vsLiteral, "ull"
+	default:
+		return false
+	}
+	return true
+}
+
+// closeContainer pops the innermost container, which must have been opened with `open`
+func (v *ValidateIncremental) closeContainer(open byte) bool {
+	if len(v.stack) == 0 || v.stack[len(v.stack)-1] != open {
+		return false
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+	v.endValue()
+	return true
+}
+
+// endValue moves on after a complete value
+func (v *ValidateIncremental) endValue() {
+	if len(v.stack) == 0 {
+		v.state = vsEnd
+	} else {
+		v.state = vsAfterValue
+	}
+}
@@ -0,0 +1,110 @@
+package jsonparser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var validateIncrementalTests = []struct {
+	json  string
+	valid bool
+}{
+	{`{}`, true},
+	{` [ ] `, true},
+	{`0`, true},
+	{`-0.5e+10`, true},
+	{`12E3`, true},
+	{`"a\"b\\c\/\b\f\n\r\tüd"`, true},
+	{`true`, true},
+	{`null`, true},
+	{`{"a":[1,2.5,{"b":null}],"c":"d","e":false}`, true},
+	{`[[[]],[{}],""]`, true},
+	{"{\n\t\"a\" : 1 ,\r\n\"b\":[ true ]\n}\n", true},
+
+	{``, false},
+	{`   `, false},
+	{`{`, false},
+	{`{"a"}`, false},
+	{`{"a":}`, false},
+	{`{"a":1,}`, false},
+	{`[1,]`, false},
+	{`[1 2]`, false},
+	{`{"a":1]`, false},
+	{`[1}`, false},
+	{`]`, false},
+	{`{1:2}`, false},
+	{`{} {}`, false},
+	{`01`, false},
+	{`-`, false},
+	{`1.`, false},
+	{`.5`, false},
+	{`1e`, false},
+	{`1e+`, false},
+	{`+1`, false},
+	{`tru`, false},
+	{`trux`, false},
+	{`nul`, false},
+	{`"abc`, false},
+	{`"\x"`, false},
+	{`"\u12G4"`, false},
+	{`"\u123"`, false},
+	{"\"a\tb\"", false},
+	{`NaN`, false},
+	{`[1,2`, false},
+}
+
+func validateInChunks(data []byte, splits ...int) error {
+	var v ValidateIncremental
+	prev := 0
+	for _, s := range append(splits, len(data)) {
+		if _, err := v.Write(data[prev:s]); err != nil {
+			return err
+		}
+		prev = s
+	}
+	return v.Done()
+}
+
+func TestValidateIncremental(t *testing.T) {
+	for _, test := range validateIncrementalTests {
+		data := []byte(test.json)
+
+		if json.Valid(data) != test.valid {
+			t.Fatalf("Test case %q disagrees with encoding/json", test.json)
+		}
+
+		if err := validateInChunks(data); (err == nil) != test.valid {
+			t.Errorf("ValidateIncremental(%q) expected valid=%t, obtained error %v", test.json, test.valid, err)
+		}
+
+		// Split in two at every boundary
+		for i := 0; i <= len(data); i++ {
+			if err := validateInChunks(data, i); (err == nil) != test.valid {
+				t.Errorf("ValidateIncremental(%q) split at %d expected valid=%t, obtained error %v", test.json, i, test.valid, err)
+			}
+		}
+
+		// One byte at a time
+		splits := make([]int, len(data))
+		for i := range splits {
+			splits[i] = i
+		}
+		if err := validateInChunks(data, splits...); (err == nil) != test.valid {
+			t.Errorf("ValidateIncremental(%q) byte by byte expected valid=%t, obtained error %v", test.json, test.valid, err)
+		}
+	}
+}
+
+func TestValidateIncrementalErrorOffset(t *testing.T) {
+	var v ValidateIncremental
+	if n, err := v.Write([]byte(`{"a":`)); n != 5 || err != nil {
+		t.Fatalf("Write returned unexpected %d, %v", n, err)
+	}
+	n, err := v.Write([]byte(` [1, x]`))
+	if n != 5 || err == nil || err.Error() != `Invalid JSON: unexpected 'x' at offset 10` {
+		t.Errorf("Write expected to stop at the invalid byte, obtained %d, %v", n, err)
+	}
+	if v.Done() != err {
+		t.Errorf("Done should report the Write error, obtained %v", v.Done())
+	}
+}